@@ -0,0 +1,128 @@
+package kong
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var aliasPlaceholderRe = regexp.MustCompile(`\$(\d+|@)`)
+
+// UserAliases adds user-defined command aliases, such as those loaded from a configuration file.
+//
+// The first argument that is not a flag is expanded if it matches an alias, in the same way as git
+// aliases. Placeholders "$1" to "$N" in the expansion are replaced by the arguments following the
+// alias, and "$@" by all arguments not otherwise referenced. Unreferenced arguments are otherwise
+// appended to the expansion. eg.
+//
+//     kong.UserAliases(map[string]string{"deploy-prod": "deploy --env prod $1"})
+//
+// Aliases never shadow commands of the application. The expanded command-line is available in
+// Context.ExpandedArgs.
+func UserAliases(aliases map[string]string) Option {
+	return OptionFunc(func(k *Kong) error {
+		if k.userAliases == nil {
+			k.userAliases = map[string]string{}
+		}
+		for name, expansion := range aliases {
+			k.userAliases[name] = expansion
+		}
+		return nil
+	})
+}
+
+// Expand the first user alias in args, if any.
+func (k *Kong) expandUserAliases(args []string) ([]string, error) {
+	if len(k.userAliases) == 0 {
+		return args, nil
+	}
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		expansion, ok := k.userAliases[arg]
+		if !ok || hasCommandNamed(k.Model.Node, arg) {
+			break
+		}
+		expanded, err := expandUserAlias(arg, expansion, args[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]string{}, args[:i]...), expanded...), nil
+	}
+	return args, nil
+}
+
+func expandUserAlias(name, expansion string, args []string) ([]string, error) {
+	words, err := SplitCommandLine(expansion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "alias %q", name)
+	}
+	// Find the arguments referenced by placeholders, so that the rest can be substituted for "$@" or appended.
+	used := map[int]bool{}
+	for _, word := range words {
+		for _, match := range aliasPlaceholderRe.FindAllStringSubmatch(word, -1) {
+			n, err := strconv.Atoi(match[1])
+			if err != nil || n < 1 {
+				continue
+			}
+			if n > len(args) {
+				return nil, errors.Errorf("alias %q expects at least %d argument(s) but got %d", name, n, len(args))
+			}
+			used[n] = true
+		}
+	}
+	rest := []string{}
+	for i, arg := range args {
+		if !used[i+1] {
+			rest = append(rest, arg)
+		}
+	}
+	out := []string{}
+	all := false
+	for _, word := range words {
+		if word == "$@" {
+			out = append(out, rest...)
+			all = true
+			continue
+		}
+		out = append(out, aliasPlaceholderRe.ReplaceAllStringFunc(word, func(placeholder string) string {
+			if placeholder == "$@" {
+				all = true
+				return strings.Join(rest, " ")
+			}
+			n, _ := strconv.Atoi(placeholder[1:])
+			if n < 1 {
+				return placeholder
+			}
+			return args[n-1]
+		}))
+	}
+	if !all {
+		out = append(out, rest...)
+	}
+	return out, nil
+}
+
+// Returns true if name is a command, or command alias, of node.
+func hasCommandNamed(node *Node, name string) bool {
+	for _, child := range node.Children {
+		if child.Type != CommandNode {
+			continue
+		}
+		if child.Name == name {
+			return true
+		}
+		for _, alias := range child.Aliases {
+			if alias == name {
+				return true
+			}
+		}
+	}
+	return false
+}
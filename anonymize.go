@@ -0,0 +1,106 @@
+package kong
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// An AnonymizedContext describes an invocation without revealing its values, so that it can be attached
+// to bug reports. See Context.Anonymize().
+type AnonymizedContext struct {
+	// Selected command, with positional arguments as placeholders, eg. "deploy <service>".
+	Command string `json:"command"`
+	// The flags and positional arguments that were set, positional arguments first.
+	Values []AnonymizedValue `json:"values"`
+}
+
+// An AnonymizedValue describes a flag or positional argument of an AnonymizedContext.
+type AnonymizedValue struct {
+	// Name of the value, as "--name" for flags and "<name>" for positional and branching arguments.
+	Name string `json:"name"`
+	// Go type of the value, eg. "string" or "[]int".
+	Type string `json:"type"`
+	// Number of elements of slices and maps, or the length of other values formatted as text.
+	Length int `json:"length"`
+	// Hash of the value, unique to each call of Anonymize(), so equal values can be recognised within a
+	// report but not guessed. Empty for values tagged with redact:"".
+	Hash string `json:"hash,omitempty"`
+	// Where the value came from.
+	Source ValueSourceKind `json:"source"`
+	// Environment variable the value came from, if Source is SourceEnv.
+	Env string `json:"env,omitempty"`
+}
+
+// Anonymize describes the invocation with its commands, the names, types and lengths of the flags and
+// positional arguments that were set, and where their values came from, with the values themselves
+// replaced by salted hashes, or removed for values tagged with redact:"".
+//
+// Users can attach the result to bug reports to share how an application was run without sharing
+// secrets or personal data. It should be called after Parse().
+func (c *Context) Anonymize() *AnonymizedContext {
+	salt := make([]byte, 16)
+	_, _ = rand.Read(salt)
+	anonymized := &AnonymizedContext{Command: c.Command(), Values: []AnonymizedValue{}}
+	values := []*Value{}
+	for _, path := range c.Path {
+		if path.Argument != nil {
+			values = append(values, path.Argument.Argument)
+		}
+		if node := path.Node(); node != nil {
+			values = append(values, node.Positional...)
+		}
+	}
+	for _, flag := range c.Flags() {
+		values = append(values, flag.Value)
+	}
+	for _, value := range values {
+		source := c.valueSource(value)
+		if source.Kind == SourceUnset || (value.Flag != nil && isActionFlag(value.Flag)) {
+			continue
+		}
+		anonymized.Values = append(anonymized.Values, anonymizeValue(value, source, salt))
+	}
+	return anonymized
+}
+
+func anonymizeValue(value *Value, source ValueSource, salt []byte) AnonymizedValue {
+	target := value.Target
+	text := fmt.Sprintf("%v", target.Interface())
+	anonymized := AnonymizedValue{
+		Name:   snapshotName(value),
+		Type:   target.Type().String(),
+		Length: len(text),
+		Source: source.Kind,
+		Env:    source.Env,
+	}
+	switch target.Kind() {
+	case reflect.Slice, reflect.Map:
+		anonymized.Length = target.Len()
+	}
+	if !value.Tag.Redact {
+		hash := sha256.Sum256(append(append([]byte(nil), salt...), text...))
+		anonymized.Hash = hex.EncodeToString(hash[:4])
+	}
+	return anonymized
+}
+
+// String formats the anonymized context for humans, with a line per value.
+func (a *AnonymizedContext) String() string {
+	lines := []string{"command: " + a.Command}
+	for _, value := range a.Values {
+		hash := "redacted"
+		if value.Hash != "" {
+			hash = "hash=" + value.Hash
+		}
+		source := value.Source.String()
+		if value.Env != "" {
+			source += " (" + value.Env + ")"
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s length=%d %s source=%s", value.Name, value.Type, value.Length, hash, source))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,61 @@
+package kong_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestAnonymize(t *testing.T) {
+	t.Setenv("DEPLOY_TOKEN", "secret-token")
+	var cli struct {
+		Verbose bool
+		Region  string `default:"us-east-1"`
+		Debug   bool
+
+		Deploy struct {
+			Token    string   `env:"DEPLOY_TOKEN" redact:""`
+			Tags     []string `help:"Tags."`
+			Service  string   `arg:""`
+			Replicas int      `arg:"" optional:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"deploy", "--verbose", "--tags=a,b,c", "billing", "--region=billing"})
+	require.NoError(t, err)
+	anonymized := ctx.Anonymize()
+	require.Equal(t, "deploy <service>", anonymized.Command)
+
+	hashes := map[string]string{}
+	for i, value := range anonymized.Values {
+		hashes[value.Name] = value.Hash
+		anonymized.Values[i].Hash = ""
+	}
+	require.Equal(t, []kong.AnonymizedValue{
+		{Name: "<service>", Type: "string", Length: 7, Source: kong.SourceCommandLine},
+		{Name: "--verbose", Type: "bool", Length: 4, Source: kong.SourceCommandLine},
+		{Name: "--region", Type: "string", Length: 7, Source: kong.SourceCommandLine},
+		{Name: "--token", Type: "string", Length: 12, Source: kong.SourceEnv, Env: "DEPLOY_TOKEN"},
+		{Name: "--tags", Type: "[]string", Length: 3, Source: kong.SourceCommandLine},
+	}, anonymized.Values)
+	require.Len(t, hashes["<service>"], 8)
+	require.Equal(t, hashes["<service>"], hashes["--region"], "equal values have equal hashes")
+	require.NotEqual(t, hashes["<service>"], hashes["--verbose"])
+	require.Equal(t, "", hashes["--token"])
+	require.NotEqual(t, hashes["<service>"], ctx.Anonymize().Values[0].Hash, "hashes are salted")
+
+	data, err := json.Marshal(ctx.Anonymize())
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "billing")
+	require.NotContains(t, string(data), "secret")
+	require.Contains(t, string(data), `{"name":"--token","type":"string","length":12,"source":"env","env":"DEPLOY_TOKEN"}`)
+
+	lines := strings.Split(ctx.Anonymize().String(), "\n")
+	require.Equal(t, "command: deploy <service>", lines[0])
+	require.Regexp(t, `^  <service> string length=7 hash=[0-9a-f]{8} source=command-line$`, lines[1])
+	require.Equal(t, "  --token string length=12 redacted source=env (DEPLOY_TOKEN)", lines[4])
+}
@@ -0,0 +1,106 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AsciiDocRenderer renders reference documentation as AsciiDoc, for WriteDocs() and WriteDocPages().
+//
+// Pages link to each other with xref: macros, so they can be added to an Antora module as they are.
+type AsciiDocRenderer struct{}
+
+// Extension implements DocRenderer.
+func (AsciiDocRenderer) Extension() string { return ".adoc" }
+
+// Render implements DocRenderer.
+func (AsciiDocRenderer) Render(w io.Writer, doc *CommandDoc) error {
+	b := &strings.Builder{}
+	printf := func(format string, args ...interface{}) { fmt.Fprintf(b, format, args...) }
+	heading := strings.Repeat("=", doc.Level)
+	subheading := heading + "="
+	printf("[#%s]\n%s %s\n", doc.ID, heading, doc.Path)
+	if doc.Help != "" {
+		printf("\n%s\n", doc.Help)
+	}
+
+	printf("\n%s Usage\n\n----\n%s\n----\n", subheading, doc.Usage)
+
+	if len(doc.Arguments) > 0 {
+		printf("\n%s Arguments\n\n", subheading)
+		printf("[cols=\"1,3,1\",options=\"header\"]\n|===\n|Argument |Description |Default\n")
+		for _, arg := range doc.Arguments {
+			printf("\n|%s\n|%s\n|%s\n", asciiDocCode(arg.Name), asciiDocDescription(arg), asciiDocCode(arg.Default))
+		}
+		printf("|===\n")
+	}
+
+	for _, group := range doc.Flags {
+		asciiDocFlags(b, subheading+" "+docGroupHeading(group.Title, "Flags"), group)
+	}
+	for _, group := range doc.InheritedFlags {
+		asciiDocFlags(b, subheading+" "+docInheritedHeading(group.Title), group)
+	}
+
+	for _, group := range doc.Commands {
+		printf("\n%s %s\n\n", subheading, docGroupHeading(group.Title, "Commands"))
+		if group.Description != "" {
+			printf("%s\n\n", group.Description)
+		}
+		printf("[cols=\"1,3\",options=\"header\"]\n|===\n|Command |Description\n")
+		for _, command := range group.Commands {
+			link := fmt.Sprintf("<<%s,%s>>", command.ID, asciiDocCode(command.Path))
+			if command.Page != "" {
+				link = fmt.Sprintf("xref:%s[%s]", command.Page, asciiDocCode(command.Path))
+			}
+			printf("\n|%s\n|%s\n", link, asciiDocCell(command.Help))
+		}
+		printf("|===\n")
+	}
+
+	if len(doc.Examples) > 0 {
+		printf("\n%s Examples\n\n----\n%s\n----\n", subheading, strings.Join(doc.Examples, "\n"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Write a table of a group of flags under heading.
+func asciiDocFlags(b *strings.Builder, heading string, group *FlagGroupDoc) {
+	fmt.Fprintf(b, "\n%s\n\n", heading)
+	if group.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", group.Description)
+	}
+	fmt.Fprintf(b, "[cols=\"2,3,1,1\",options=\"header\"]\n|===\n|Flag |Description |Default |Environment\n")
+	for _, flag := range group.Flags {
+		fmt.Fprintf(b, "\n|%s\n|%s\n|%s\n|%s\n", asciiDocCode(flag.Name), asciiDocDescription(flag), asciiDocCode(flag.Default), asciiDocCode(flag.Env))
+	}
+	fmt.Fprintf(b, "|===\n")
+}
+
+// The help of a value for a table cell, followed by its enum values if any.
+func asciiDocDescription(value *ValueDoc) string {
+	description := asciiDocCell(value.Help)
+	if len(value.Enum) > 0 {
+		enums := make([]string, len(value.Enum))
+		for i, enum := range value.Enum {
+			enums[i] = asciiDocCode(enum)
+		}
+		description = strings.TrimSpace(description + " One of: " + strings.Join(enums, ", ") + ".")
+	}
+	return description
+}
+
+// s as literal monospace, which is exempt from substitutions, or nothing if s is empty.
+func asciiDocCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`+" + asciiDocCell(s) + "+`"
+}
+
+// Escape s for a table cell.
+func asciiDocCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
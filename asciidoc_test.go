@@ -0,0 +1,42 @@
+package kong_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestWriteAsciiDoc(t *testing.T) {
+	var cli manCLI
+	p := mustNew(t, &cli, kong.Name("app"))
+	w := &bytes.Buffer{}
+	require.NoError(t, p.WriteDocs(w, kong.AsciiDocRenderer{}))
+	doc := w.String()
+	require.True(t, strings.HasPrefix(doc, "[#app]\n= app\n\n== Usage\n\n----\napp [flags] <command>\n----\n"), doc)
+	require.Contains(t, doc, "|<<app-serve,`+app serve+`>>\n|Start the server.\n|===\n\n[#app-serve]\n== app serve\n\nStart the server.\n\n=== Usage\n")
+	require.Contains(t, doc, "|`+-p, --port=INT+`\n|Port to listen on.\n|`+8080+`\n|`+APP_PORT+`\n")
+	require.Contains(t, doc, "|`+--format=STRING+`\n|Log format. One of: `+json+`, `+text+`.\n|`+json+`\n|\n")
+	require.True(t, strings.HasSuffix(doc, "=== Examples\n\n----\napp serve --port=80 ./public\napp serve -p 8081\n----\n"), doc)
+}
+
+func TestWriteAsciiDocPages(t *testing.T) {
+	var cli manCLI
+	p := mustNew(t, &cli, kong.Name("app"))
+	dir := t.TempDir()
+	require.NoError(t, p.WriteDocPages(dir, kong.AsciiDocRenderer{}))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.adoc"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "|xref:app-serve.adoc[`+app serve+`]\n|Start the server.\n")
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app-serve.adoc"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "[#app-serve]\n= app serve\n\nStart the server.\n\n== Usage\n")
+}
@@ -0,0 +1,113 @@
+package kong
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Redacted replaces the values of flags tagged with redact:"" in an AuditRecord.
+const Redacted = "[REDACTED]"
+
+// An AuditRecord is a structured record of a run of a command. See Audit().
+type AuditRecord struct {
+	// Time the run started.
+	Time time.Time `json:"time"`
+	// Selected command, with positional arguments as placeholders, eg. "deploy <service>".
+	Command string `json:"command"`
+	// Values of the flags that were explicitly set, on the command-line, by an environment variable or by
+	// a resolver, keyed by name. Values of flags tagged with redact:"" are replaced with Redacted.
+	Flags map[string]string `json:"flags"`
+	// Name of the user running the application.
+	User string `json:"user"`
+	// Duration of the Run() methods.
+	Duration time.Duration `json:"duration"`
+	// Exit status of the run: 0 on success, the result of ExitCode() if the error implements it, or 1.
+	ExitStatus int `json:"exit_status"`
+	// Error returned by the run, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// An AuditLogger records each run of a command.
+type AuditLogger interface {
+	Audit(record *AuditRecord) error
+}
+
+// AuditLoggerFunc is a function that implements the AuditLogger interface.
+type AuditLoggerFunc func(record *AuditRecord) error
+
+// Audit calls the function.
+func (a AuditLoggerFunc) Audit(record *AuditRecord) error { return a(record) }
+
+// JSONAuditLogger writes each AuditRecord to w as a line of JSON.
+func JSONAuditLogger(w io.Writer) AuditLogger {
+	lock := &sync.Mutex{}
+	return AuditLoggerFunc(func(record *AuditRecord) error {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	})
+}
+
+// Audit records each run of a command with logger, for compliance-sensitive tools.
+//
+// Records include the values of flags that were explicitly set, so flags holding secrets should be
+// tagged with redact:"". If the record can't be written, Context.Run() fails.
+//
+//     kong.Audit(kong.JSONAuditLogger(auditLog))
+func Audit(logger AuditLogger) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.auditLogger = logger
+		return nil
+	})
+}
+
+func (c *Context) audit(start time.Time, err error) error {
+	if c.auditLogger == nil {
+		return nil
+	}
+	record := &AuditRecord{
+		Time:       start,
+		Command:    c.Command(),
+		Flags:      map[string]string{},
+		User:       currentUser(),
+		Duration:   time.Since(start),
+		ExitStatus: exitStatus(err),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	for _, flag := range c.Flags() {
+		if !c.IsSet(flag.Name) {
+			continue
+		}
+		if flag.Tag.Redact {
+			record.Flags[flag.Name] = Redacted
+		} else {
+			record.Flags[flag.Name] = fmt.Sprint(flag.Target.Interface())
+		}
+	}
+	return errors.Wrap(c.auditLogger.Audit(record), "failed to write audit record")
+}
+
+// Name of the user running the application, or "" if it can't be determined.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -77,3 +78,97 @@ func Benchmark_interpolateValue(b *testing.B) {
 	}
 	b.ReportAllocs()
 }
+
+func BenchmarkKong_Parse(b *testing.B) {
+	var cli struct {
+		Verbose  int      `short:"v" type:"counter"`
+		Include  []string `short:"I"`
+		Dry      bool
+		Optional string
+		Files    []string `arg:""`
+	}
+	for _, count := range []int{100, 1000, 10000} {
+		count := count
+		args := make([]string, 0, count*4)
+		for i := 0; i < count; i++ {
+			args = append(args, "--include", "dir"+strconv.Itoa(i), "-v")
+		}
+		for i := 0; i < count; i++ {
+			args = append(args, "file"+strconv.Itoa(i))
+		}
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			k, err := New(&cli)
+			require.NoError(b, err)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err = k.Parse(args)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkScanner(b *testing.B) {
+	args := make([]string, 10000)
+	for i := range args {
+		args[i] = "arg" + strconv.Itoa(i)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		scan := Scan(args...)
+		for !scan.Peek().IsEOL() {
+			token := scan.Pop()
+			scan.PushTyped(token.Value, PositionalArgumentToken)
+			scan.Pop()
+		}
+	}
+}
+
+func BenchmarkKong_Reset(b *testing.B) {
+	var cli struct {
+		Name    string        `default:"bob"`
+		Count   int           `default:"3"`
+		Verbose bool          `default:"true"`
+		Timeout time.Duration `default:"5s"`
+		Tags    []string      `default:"a,b,c"`
+	}
+	k, err := New(&cli)
+	require.NoError(b, err)
+	ctx, err := Trace(k, nil)
+	require.NoError(b, err)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		err = ctx.Reset()
+	}
+	require.NoError(b, err)
+}
+
+func BenchmarkKong_EnvOnly(b *testing.B) {
+	var cli struct {
+		Addr    string        `env:"BENCH_ADDR"`
+		Workers int           `env:"BENCH_WORKERS" default:"4"`
+		Timeout time.Duration `env:"BENCH_TIMEOUT" default:"5s"`
+		Tags    []string      `env:"BENCH_TAGS"`
+	}
+	b.Setenv("BENCH_ADDR", ":8080")
+	b.Setenv("BENCH_TAGS", "a,b,c")
+	for _, envOnly := range []bool{false, true} {
+		options := []Option{}
+		if envOnly {
+			options = append(options, EnvOnly())
+		}
+		k, err := New(&cli, options...)
+		require.NoError(b, err)
+		b.Run(fmt.Sprintf("EnvOnly=%v", envOnly), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err = k.Parse(nil)
+			}
+			require.NoError(b, err)
+		})
+	}
+}
@@ -33,12 +33,79 @@ func build(k *Kong, ast interface{}) (app *Application, err error) {
 		return nil, fmt.Errorf("can't mix positional arguments and branching arguments on %T", ast)
 	}
 	app.Node = node
+	app.Presets = collectPresets(node)
+	if len(app.Presets) > 0 {
+		if hasFlagNamed(node, "preset") {
+			return nil, fmt.Errorf("presets are defined but the --preset flag is already in use")
+		}
+		extraFlags = append(extraFlags, k.presetFlag(app.Presets))
+	}
+	if hasRememberedFlags(node) {
+		if hasFlagNamed(node, "forget") {
+			return nil, fmt.Errorf("flags are remembered but the --forget flag is already in use")
+		}
+		extraFlags = append(extraFlags, k.forgetFlag())
+		// Remembered values have the lowest precedence of all resolvers.
+		k.resolvers = append([]Resolver{k.rememberResolver()}, k.resolvers...)
+	}
+	if k.completionScript && !hasFlagNamed(node, "completion-script") {
+		extraFlags = append(extraFlags, k.completionScriptFlag())
+	}
+	// The config schema flag is only added if it does not conflict with the grammar.
+	if k.loader != nil && !hasFlagNamed(node, "config-schema") {
+		extraFlags = append(extraFlags, k.configSchemaFlag())
+	}
 	app.Node.Flags = append(extraFlags, app.Node.Flags...)
+	if err := compileChecks(app.Node); err != nil {
+		return nil, err
+	}
 	app.Tag = newEmptyTag()
 	app.Tag.Vars = k.vars
 	return app, nil
 }
 
+// Returns true if a flag called name is defined anywhere in the tree rooted at node.
+func hasFlagNamed(node *Node, name string) bool {
+	found := false
+	_ = Visit(node, func(node Visitable, next Next) error {
+		if flag, ok := node.(*Flag); ok && flag.Name == name {
+			found = true
+		}
+		return next(nil)
+	})
+	return found
+}
+
+// Index the visible commands in the tree rooted at node by name and alias.
+func indexCommands(node *Node) map[string][]*Node {
+	index := map[string][]*Node{}
+	_ = Visit(node, func(node Visitable, next Next) error {
+		if node, ok := node.(*Node); ok && node.Type == CommandNode {
+			if node.Hidden {
+				return nil
+			}
+			for _, name := range append([]string{node.Name}, node.Aliases...) {
+				index[name] = append(index[name], node)
+			}
+		}
+		return next(nil)
+	})
+	return index
+}
+
+// Path of command and argument names from the root to node, eg. "server start" or "user <id> delete".
+func commandPath(node *Node) string {
+	names := []string{}
+	for ; node != nil && node.Type != ApplicationNode; node = node.Parent {
+		name := node.Name
+		if node.Type == ArgumentNode {
+			name = "<" + name + ">"
+		}
+		names = append([]string{name}, names...)
+	}
+	return strings.Join(names, " ")
+}
+
 func dashedString(s string) string {
 	return strings.Join(camelCase(s), "-")
 }
@@ -49,7 +116,17 @@ type flattenedField struct {
 	tag   *Tag
 }
 
-func flattenedFields(v reflect.Value) (out []flattenedField, err error) {
+// Returns true if the field ft of parent is skipped by IgnoreFields() or similar options.
+func (k *Kong) isIgnoredField(parent reflect.Type, ft reflect.StructField, tag *Tag) bool {
+	for _, ignore := range k.ignoreFields {
+		if ignore(parent, ft, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func flattenedFields(k *Kong, v reflect.Value) (out []flattenedField, err error) {
 	v = reflect.Indirect(v)
 	for i := 0; i < v.NumField(); i++ {
 		ft := v.Type().Field(i)
@@ -58,9 +135,15 @@ func flattenedFields(v reflect.Value) (out []flattenedField, err error) {
 		if err != nil {
 			return nil, err
 		}
-		if tag.Ignored {
+		if tag.ShortName != "" && !k.multiRuneShort {
+			return nil, failField(v, ft, "invalid short flag name %q: invalid rune", tag.ShortName)
+		}
+		if tag.Ignored || k.isIgnoredField(v.Type(), ft, tag) {
 			continue
 		}
+		if err := k.parseTagExtensions(tag); err != nil {
+			return nil, failField(v, ft, "%s", err)
+		}
 		// Command and embedded structs can be pointers, so we hydrate them now.
 		if (tag.Cmd || tag.Embed) && ft.Type.Kind() == reflect.Ptr {
 			fv = reflect.New(ft.Type.Elem()).Elem()
@@ -78,7 +161,7 @@ func flattenedFields(v reflect.Value) (out []flattenedField, err error) {
 			fv = fv.Elem()
 		} else if fv.Type() == reflect.TypeOf(Plugins{}) {
 			for i := 0; i < fv.Len(); i++ {
-				fields, ferr := flattenedFields(fv.Index(i).Elem())
+				fields, ferr := flattenedFields(k, fv.Index(i).Elem())
 				if ferr != nil {
 					return nil, ferr
 				}
@@ -86,7 +169,7 @@ func flattenedFields(v reflect.Value) (out []flattenedField, err error) {
 			}
 			continue
 		}
-		sub, err := flattenedFields(fv)
+		sub, err := flattenedFields(k, fv)
 		if err != nil {
 			return nil, err
 		}
@@ -100,6 +183,8 @@ func flattenedFields(v reflect.Value) (out []flattenedField, err error) {
 			subf.tag.EnvPrefix = tag.EnvPrefix + subf.tag.EnvPrefix
 			// Combine parent vars.
 			subf.tag.Vars = tag.Vars.CloneWith(subf.tag.Vars)
+			// And named resolvers.
+			subf.tag.Resolvers = append(append([]string(nil), tag.Resolvers...), subf.tag.Resolvers...)
 		}
 		out = append(out, sub...)
 	}
@@ -115,19 +200,12 @@ func buildNode(k *Kong, v reflect.Value, typ NodeType, seenFlags map[string]bool
 		Target: v,
 		Tag:    newEmptyTag(),
 	}
-	fields, err := flattenedFields(v)
+	fields, err := flattenedFields(k, v)
 	if err != nil {
 		return nil, err
 	}
 
-MAIN:
 	for _, field := range fields {
-		for _, r := range k.ignoreFields {
-			if r.MatchString(v.Type().Name() + "." + field.field.Name) {
-				continue MAIN
-			}
-		}
-
 		ft := field.field
 		fv := field.value
 
@@ -141,8 +219,23 @@ MAIN:
 
 		tag.Env = tag.EnvPrefix + tag.Env
 
+		// Interface commands are built from their implementation.
+		if fv.Kind() == reflect.Interface && (tag.Cmd || tag.Arg) {
+			if fv, err = implementationOf(k, v, ft, fv, tag); err != nil {
+				return nil, err
+			}
+		}
+
+		// Slices of structs tagged as commands are repeated commands.
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct && tag.Cmd {
+			if err := buildRepeatedChild(k, node, v, ft, fv, tag, name, seenFlags); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		// Nested structs are either commands or args, unless they implement the Mapper interface.
-		if field.value.Kind() == reflect.Struct && (tag.Cmd || tag.Arg) && k.registry.ForValue(fv) == nil {
+		if fv.Kind() == reflect.Struct && (tag.Cmd || tag.Arg) && k.registry.ForValue(fv) == nil {
 			typ := CommandNode
 			if tag.Arg {
 				typ = ArgumentNode
@@ -178,6 +271,35 @@ MAIN:
 	return node, nil
 }
 
+// Set an interface field to a copy of the implementation supplied by Implement(), or a value created
+// by the command factory named by its use:"" tag, if it's nil, and return the struct it points to.
+func implementationOf(k *Kong, v reflect.Value, ft reflect.StructField, fv reflect.Value, tag *Tag) (reflect.Value, error) {
+	if fv.IsNil() && tag.Use != "" {
+		cmd, err := newFactoryCommand(tag.Use)
+		if err != nil {
+			return reflect.Value{}, failField(v, ft, "%s", err)
+		}
+		impl := reflect.ValueOf(cmd)
+		if !impl.Type().AssignableTo(fv.Type()) {
+			return reflect.Value{}, failField(v, ft, "command factory %q returned %s, which does not implement %s", tag.Use, impl.Type(), fv.Type())
+		}
+		fv.Set(impl)
+	} else if fv.IsNil() {
+		impl, ok := k.implementations[fv.Type()]
+		if !ok {
+			return reflect.Value{}, failField(v, ft, "no implementation of %s, use kong.Implement()", fv.Type())
+		}
+		ptr := reflect.New(impl.Elem().Type())
+		ptr.Elem().Set(impl.Elem())
+		fv.Set(ptr)
+	}
+	impl := fv.Elem()
+	if impl.Kind() != reflect.Ptr || impl.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, failField(v, ft, "implementation of %s must be a pointer to a struct but got %s", fv.Type(), impl.Type())
+	}
+	return impl.Elem(), nil
+}
+
 func buildChild(k *Kong, node *Node, typ NodeType, v reflect.Value, ft reflect.StructField, fv reflect.Value, tag *Tag, name string, seenFlags map[string]bool) error {
 	child, err := buildNode(k, fv, typ, seenFlags)
 	if err != nil {
@@ -189,6 +311,7 @@ func buildChild(k *Kong, node *Node, typ NodeType, v reflect.Value, ft reflect.S
 	child.Help = tag.Help
 	child.Hidden = tag.Hidden
 	child.Group = buildGroupForKey(k, tag.Group)
+	child.Category = tag.Category
 	child.Aliases = tag.Aliases
 
 	if provider, ok := fv.Addr().Interface().(HelpProvider); ok {
@@ -228,11 +351,41 @@ func buildChild(k *Kong, node *Node, typ NodeType, v reflect.Value, ft reflect.S
 	return nil
 }
 
+// Build a command from the element type of the slice fv, each invocation of which is appended to fv.
+func buildRepeatedChild(k *Kong, node *Node, v reflect.Value, ft reflect.StructField, fv reflect.Value, tag *Tag, name string, seenFlags map[string]bool) error {
+	if tag.Default != "" {
+		return failField(v, ft, "repeated command can't be a default command")
+	}
+	if err := buildChild(k, node, CommandNode, v, ft, reflect.New(fv.Type().Elem()).Elem(), tag, name, seenFlags); err != nil {
+		return err
+	}
+	child := node.Children[len(node.Children)-1]
+	if len(child.Children) > 0 {
+		return failField(v, ft, "repeated command %s must not have subcommands", child.Summary())
+	}
+	child.Repeated = true
+	child.invocations = fv
+	return nil
+}
+
 func buildField(k *Kong, node *Node, v reflect.Value, ft reflect.StructField, fv reflect.Value, tag *Tag, name string, seenFlags map[string]bool) error {
 	mapper := k.registry.ForNamedValue(tag.Type, fv)
 	if mapper == nil {
 		return failField(v, ft, "unsupported field type %s, perhaps missing a cmd:\"\" tag?", ft.Type)
 	}
+	transform, err := k.registry.ForTransforms(tag.Transforms)
+	if err != nil {
+		return failField(v, ft, "%s", err)
+	}
+	normalise, err := unitTransform(tag.Unit, ft.Type)
+	if err != nil {
+		return failField(v, ft, "%s", err)
+	}
+	if tag.Tuple > 0 {
+		if err := checkTupleType(ft.Type, tag.Tuple); err != nil {
+			return failField(v, ft, "%s", err)
+		}
+	}
 
 	value := &Value{
 		Name:         name,
@@ -244,10 +397,13 @@ func buildField(k *Kong, node *Node, v reflect.Value, ft reflect.StructField, fv
 		Target:       fv,
 		Enum:         tag.Enum,
 		Passthrough:  tag.Passthrough,
+		Transform:    chainTransforms(transform, normalise),
+		indirections: k.indirections,
 
 		// Flags are optional by default, and args are required by default.
 		Required: (!tag.Arg && tag.Required) || (tag.Arg && !tag.Optional),
 		Format:   tag.Format,
+		Unit:     tag.Unit,
 	}
 
 	if tag.Arg {
@@ -263,6 +419,12 @@ func buildField(k *Kong, node *Node, v reflect.Value, ft reflect.StructField, fv
 			}
 			seenFlags["-"+string(tag.Short)] = true
 		}
+		if tag.ShortName != "" {
+			if seenFlags["-"+tag.ShortName] {
+				return failField(v, ft, "duplicate short flag -%s", tag.ShortName)
+			}
+			seenFlags["-"+tag.ShortName] = true
+		}
 		flag := &Flag{
 			Value:       value,
 			Short:       tag.Short,
@@ -270,6 +432,7 @@ func buildField(k *Kong, node *Node, v reflect.Value, ft reflect.StructField, fv
 			Env:         tag.Env,
 			Group:       buildGroupForKey(k, tag.Group),
 			Xor:         tag.Xor,
+			And:         tag.And,
 			Hidden:      tag.Hidden,
 		}
 		value.Flag = flag
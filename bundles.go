@@ -0,0 +1,60 @@
+package kong
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// An OptionBundle is a named set of Options applied together.
+//
+// Bundles allow common option sets to be shared, including by third-party packages, eg.
+//
+//     var Corporate = kong.Bundle("corporate", kong.DefaultEnvars("ACME"), kong.UsageOnError())
+//
+// As with other options, options passed after a bundle override those in it.
+type OptionBundle struct {
+	Name    string
+	Options []Option
+}
+
+// Bundle creates a named OptionBundle.
+func Bundle(name string, options ...Option) *OptionBundle {
+	return &OptionBundle{Name: name, Options: options}
+}
+
+// Apply each Option in the bundle.
+func (b *OptionBundle) Apply(k *Kong) error {
+	for _, option := range b.Options {
+		if err := option.Apply(k); err != nil {
+			return errors.Wrapf(err, "%s", b.Name)
+		}
+	}
+	return nil
+}
+
+// DefaultsForDaemon bundles options suited to long-running services named "name":
+//
+// - Envars for all flags, prefixed with the upper-cased name, eg. MYAPP_LOG_LEVEL.
+// - Configuration discovered in /etc/<name>/config.json and ~/.config/<name>/config.json.
+// - Panics in Run() methods are recovered and returned as errors.
+func DefaultsForDaemon(name string) *OptionBundle {
+	return Bundle("daemon",
+		DefaultEnvars(strings.ToUpper(strings.ReplaceAll(name, "-", "_"))),
+		Configuration(JSON, "/etc/"+name+"/config.json", "~/.config/"+name+"/config.json"),
+		RecoverPanics(),
+	)
+}
+
+// DefaultsForScripting bundles options suited to tools invoked from scripts:
+//
+// - Compact help.
+// - Short usage is printed on errors.
+// - Flags must be specified on the command they belong to.
+func DefaultsForScripting() *OptionBundle {
+	return Bundle("scripting",
+		ConfigureHelp(HelpOptions{Compact: true}),
+		ShortUsageOnError(),
+		StrictFlags(),
+	)
+}
@@ -77,26 +77,68 @@ func getMethod(value reflect.Value, name string) reflect.Value {
 }
 
 func callMethod(name string, v, f reflect.Value, bindings bindings) error {
-	in := []reflect.Value{}
 	t := f.Type()
 	if t.NumOut() != 1 || t.Out(0) != callbackReturnSignature {
 		return fmt.Errorf("return value of %T.%s() must be exactly \"error\"", v.Type(), name)
 	}
+	out, err := callWithBindings(name, v, f, bindings)
+	if err != nil {
+		return err
+	}
+	return errorValue(out[0])
+}
+
+// Call a Run() method, which may return either "error" or "(T, error)". T is returned if present.
+func callRunMethod(name string, v, f reflect.Value, bindings bindings) (interface{}, error) {
+	t := f.Type()
+	if t.NumOut() == 1 {
+		return nil, callMethod(name, v, f, bindings)
+	}
+	if t.NumOut() != 2 || t.Out(1) != callbackReturnSignature {
+		return nil, fmt.Errorf("return value of %s.%s() must be \"error\" or \"(T, error)\"", v.Type(), name)
+	}
+	out, err := callWithBindings(name, v, f, bindings)
+	if err != nil {
+		return nil, err
+	}
+	return out[0].Interface(), errorValue(out[1])
+}
+
+// Check that a Run() method returns "error" or "(T, error)" and that all of its parameters are bound.
+func checkRunMethod(name string, v, f reflect.Value, bindings bindings) error {
+	t := f.Type()
+	if t.NumOut() == 0 || t.NumOut() > 2 || t.Out(t.NumOut()-1) != callbackReturnSignature {
+		return fmt.Errorf("return value of %s.%s() must be \"error\" or \"(T, error)\"", v.Type(), name)
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		if pt := t.In(i); bindings[pt] == nil {
+			return fmt.Errorf("couldn't find binding of type %s for parameter %d of %s.%s(), use kong.Bind(%s)", pt, i, v.Type(), name, pt)
+		}
+	}
+	return nil
+}
+
+func callWithBindings(name string, v, f reflect.Value, bindings bindings) ([]reflect.Value, error) {
+	in := []reflect.Value{}
+	t := f.Type()
 	for i := 0; i < t.NumIn(); i++ {
 		pt := t.In(i)
 		if argf, ok := bindings[pt]; ok {
 			argv, err := argf()
 			if err != nil {
-				return err
+				return nil, err
 			}
 			in = append(in, argv)
 		} else {
-			return fmt.Errorf("couldn't find binding of type %s for parameter %d of %s.%s(), use kong.Bind(%s)", pt, i, v.Type(), name, pt)
+			return nil, fmt.Errorf("couldn't find binding of type %s for parameter %d of %s.%s(), use kong.Bind(%s)", pt, i, v.Type(), name, pt)
 		}
 	}
-	out := f.Call(in)
-	if out[0].IsNil() {
+	return f.Call(in), nil
+}
+
+func errorValue(v reflect.Value) error {
+	if v.IsNil() {
 		return nil
 	}
-	return out[0].Interface().(error)
+	return v.Interface().(error)
 }
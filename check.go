@@ -0,0 +1,598 @@
+package kong
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// A compiled check:"" expression.
+//
+// Expressions support the boolean operators "||", "&&" and "!", comparisons, arithmetic with "+", "-",
+// "*", "/" and "%", parentheses, integer, float, 'string' and true/false literals, and the functions
+// len(x), duration('5m') and matches(s, 'regexp'). Identifiers are the names of flags and positional
+// arguments, with "-" replaced by "_".
+type checkExpr struct {
+	text   string
+	idents []string
+	eval   checkEval
+}
+
+type checkEval func(lookup func(name string) (interface{}, error)) (interface{}, error)
+
+// Evaluate the expression against the values in scope of node, returning false if it does not hold.
+func (c *checkExpr) holds(node *Node) (bool, error) {
+	scope := checkScope(node)
+	result, err := c.eval(func(name string) (interface{}, error) {
+		return checkValue(scope[name].Target)
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "check %q", c.text)
+	}
+	ok, isBool := result.(bool)
+	if !isBool {
+		return false, errors.Errorf("check %q is not a boolean expression", c.text)
+	}
+	return ok, nil
+}
+
+// Evaluate the check:"" expressions of node, and of its flags and positional arguments that are set.
+func checkExpressions(node *Node) []error {
+	errs := []error{}
+	check := func(expr *checkExpr, desc string) {
+		if expr == nil {
+			return
+		}
+		ok, err := expr.holds(node)
+		if err != nil {
+			errs = append(errs, errors.Wrap(err, desc))
+		} else if !ok {
+			errs = append(errs, errors.Errorf("%s must satisfy %q", desc, expr.text))
+		}
+	}
+	check(node.check, node.Path())
+	for _, value := range node.Positional {
+		if value.Set {
+			check(value.check, value.ShortSummary())
+		}
+	}
+	for _, flag := range node.Flags {
+		if flag.Set {
+			check(flag.check, flag.ShortSummary())
+		}
+	}
+	return errs
+}
+
+// Values visible to expressions on node, keyed by name. Values of the node shadow those of its ancestors.
+func checkScope(node *Node) map[string]*Value {
+	scope := map[string]*Value{}
+	for n := node; n != nil; n = n.Parent {
+		values := append([]*Value{}, n.Positional...)
+		if n.Argument != nil {
+			values = append(values, n.Argument)
+		}
+		for _, flag := range n.Flags {
+			values = append(values, flag.Value)
+		}
+		for _, value := range values {
+			name := strings.ReplaceAll(value.Name, "-", "_")
+			if _, ok := scope[name]; !ok {
+				scope[name] = value
+			}
+		}
+	}
+	return scope
+}
+
+// Compile the check:"" expressions of node and its descendants.
+func compileChecks(node *Node) error {
+	return Visit(node, func(visitable Visitable, next Next) error {
+		switch visitable := visitable.(type) {
+		case *Node:
+			check, err := compileCheck(visitable, visitable.Tag)
+			if err != nil {
+				return errors.Wrap(err, visitable.Path())
+			}
+			visitable.check = check
+			for _, value := range visitable.Positional {
+				if value.check, err = compileCheck(visitable, value.Tag); err != nil {
+					return errors.Wrap(err, value.ShortSummary())
+				}
+			}
+			for _, flag := range visitable.Flags {
+				if flag.check, err = compileCheck(visitable, flag.Tag); err != nil {
+					return errors.Wrap(err, flag.ShortSummary())
+				}
+			}
+		}
+		return next(nil)
+	})
+}
+
+func compileCheck(node *Node, tag *Tag) (*checkExpr, error) {
+	if tag == nil || tag.Check == "" {
+		return nil, nil
+	}
+	check, err := parseCheck(tag.Check)
+	if err != nil {
+		return nil, err
+	}
+	scope := checkScope(node)
+	for _, ident := range check.idents {
+		if _, ok := scope[ident]; !ok {
+			return nil, errors.Errorf("check %q refers to unknown value %q", tag.Check, ident)
+		}
+	}
+	return check, nil
+}
+
+// Convert a target to a value expressions operate on.
+func checkValue(target reflect.Value) (interface{}, error) {
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			return nil, nil
+		}
+		target = target.Elem()
+	}
+	switch target.Kind() {
+	case reflect.Bool:
+		return target.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return target.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return int64(target.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return target.Float(), nil
+	case reflect.String:
+		return target.String(), nil
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return target, nil
+	}
+	return nil, errors.Errorf("unsupported type %s", target.Type())
+}
+
+func parseCheck(text string) (*checkExpr, error) {
+	tokens, err := lexCheck(text)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid check %q", text)
+	}
+	p := &checkParser{tokens: tokens}
+	eval, err := p.or()
+	if err == nil && p.peek() != "" {
+		err = errors.Errorf("unexpected %q", p.peek())
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid check %q", text)
+	}
+	return &checkExpr{text: text, idents: p.idents, eval: eval}, nil
+}
+
+func lexCheck(text string) ([]string, error) {
+	tokens := []string{}
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+		case unicode.IsDigit(r) || r == '.':
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+		case r == '\'':
+			for i++; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated string")
+			}
+			i++
+		case i+1 < len(runes) && checkOperators[string(runes[i:i+2])]:
+			i += 2
+		case checkOperators[string(r)]:
+			i++
+		default:
+			return nil, errors.Errorf("unexpected %q", string(r))
+		}
+		tokens = append(tokens, string(runes[start:i]))
+	}
+	return tokens, nil
+}
+
+var checkOperators = map[string]bool{
+	"||": true, "&&": true, "==": true, "!=": true, "<=": true, ">=": true,
+	"!": true, "<": true, ">": true, "+": true, "-": true, "*": true, "/": true, "%": true,
+	"(": true, ")": true, ",": true,
+}
+
+type checkParser struct {
+	tokens []string
+	idents []string
+}
+
+func (p *checkParser) peek() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	return p.tokens[0]
+}
+
+func (p *checkParser) next() string {
+	token := p.peek()
+	if token != "" {
+		p.tokens = p.tokens[1:]
+	}
+	return token
+}
+
+func (p *checkParser) expect(token string) error {
+	if next := p.next(); next != token {
+		if next == "" {
+			return errors.Errorf("expected %q but got end of expression", token)
+		}
+		return errors.Errorf("expected %q but got %q", token, next)
+	}
+	return nil
+}
+
+// Parse a left-associative chain of binary operators, each operand parsed by operand.
+func (p *checkParser) binary(operand func() (checkEval, error), ops ...string) (checkEval, error) {
+	lhs, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		found := false
+		for _, candidate := range ops {
+			found = found || op == candidate
+		}
+		if !found {
+			return lhs, nil
+		}
+		p.next()
+		rhs, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		lhs = binaryEval(op, lhs, rhs)
+	}
+}
+
+func (p *checkParser) or() (checkEval, error) {
+	return p.binary(p.and, "||")
+}
+
+func (p *checkParser) and() (checkEval, error) {
+	return p.binary(p.comparison, "&&")
+}
+
+func (p *checkParser) comparison() (checkEval, error) {
+	return p.binary(p.sum, "==", "!=", "<", "<=", ">", ">=")
+}
+
+func (p *checkParser) sum() (checkEval, error) {
+	return p.binary(p.product, "+", "-")
+}
+
+func (p *checkParser) product() (checkEval, error) {
+	return p.binary(p.unary, "*", "/", "%")
+}
+
+func (p *checkParser) unary() (checkEval, error) {
+	op := p.peek()
+	if op != "!" && op != "-" {
+		return p.primary()
+	}
+	p.next()
+	operand, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(lookup func(string) (interface{}, error)) (interface{}, error) {
+		value, err := operand(lookup)
+		if err != nil {
+			return nil, err
+		}
+		switch value := value.(type) {
+		case bool:
+			if op == "!" {
+				return !value, nil
+			}
+		case int64:
+			if op == "-" {
+				return -value, nil
+			}
+		case float64:
+			if op == "-" {
+				return -value, nil
+			}
+		}
+		return nil, errors.Errorf("can't apply %s to %v", op, value)
+	}, nil
+}
+
+func (p *checkParser) primary() (checkEval, error) {
+	token := p.next()
+	switch {
+	case token == "":
+		return nil, errors.New("unexpected end of expression")
+
+	case token == "(":
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		return expr, p.expect(")")
+
+	case token == "true" || token == "false":
+		return constEval(token == "true"), nil
+
+	case token[0] == '\'':
+		value, err := strconv.Unquote(`"` + strings.ReplaceAll(token[1:len(token)-1], `"`, `\"`) + `"`)
+		if err != nil {
+			return nil, errors.Errorf("invalid string %s", token)
+		}
+		return constEval(value), nil
+
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		if n, err := strconv.ParseInt(token, 10, 64); err == nil {
+			return constEval(n), nil
+		}
+		n, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid number %s", token)
+		}
+		return constEval(n), nil
+
+	case p.peek() == "(":
+		return p.call(token)
+
+	case unicode.IsLetter(rune(token[0])) || token[0] == '_':
+		p.idents = append(p.idents, token)
+		return func(lookup func(string) (interface{}, error)) (interface{}, error) {
+			return lookup(token)
+		}, nil
+	}
+	return nil, errors.Errorf("unexpected %q", token)
+}
+
+func (p *checkParser) call(name string) (checkEval, error) {
+	p.next()
+	args := []checkEval{}
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.next()
+	fn, ok := checkFuncs[name]
+	if !ok {
+		return nil, errors.Errorf("unknown function %s()", name)
+	}
+	if len(args) != fn.args {
+		return nil, errors.Errorf("%s() expects %d argument(s) but got %d", name, fn.args, len(args))
+	}
+	return func(lookup func(string) (interface{}, error)) (interface{}, error) {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			value, err := arg(lookup)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return fn.call(values)
+	}, nil
+}
+
+var checkFuncs = map[string]struct {
+	args int
+	call func(args []interface{}) (interface{}, error)
+}{
+	"len": {1, func(args []interface{}) (interface{}, error) {
+		switch value := args[0].(type) {
+		case string:
+			return int64(len(value)), nil
+		case reflect.Value:
+			return int64(value.Len()), nil
+		case nil:
+			return int64(0), nil
+		}
+		return nil, errors.Errorf("can't take len() of %v", args[0])
+	}},
+	"duration": {1, func(args []interface{}) (interface{}, error) {
+		value, ok := args[0].(string)
+		if !ok {
+			return nil, errors.Errorf("duration() expects a string but got %v", args[0])
+		}
+		d, err := time.ParseDuration(value)
+		return int64(d), err
+	}},
+	"matches": {2, func(args []interface{}) (interface{}, error) {
+		value, ok := args[0].(string)
+		pattern, isString := args[1].(string)
+		if !ok || !isString {
+			return nil, errors.Errorf("matches() expects strings but got %v and %v", args[0], args[1])
+		}
+		return regexp.MatchString(pattern, value)
+	}},
+}
+
+func constEval(value interface{}) checkEval {
+	return func(func(string) (interface{}, error)) (interface{}, error) {
+		return value, nil
+	}
+}
+
+func binaryEval(op string, lhs, rhs checkEval) checkEval {
+	return func(lookup func(string) (interface{}, error)) (interface{}, error) {
+		left, err := lhs(lookup)
+		if err != nil {
+			return nil, err
+		}
+		// Boolean operators short-circuit.
+		if op == "||" || op == "&&" {
+			l, ok := left.(bool)
+			if !ok {
+				return nil, errors.Errorf("%s expects booleans but got %v", op, left)
+			}
+			if l == (op == "||") {
+				return l, nil
+			}
+		}
+		right, err := rhs(lookup)
+		if err != nil {
+			return nil, err
+		}
+		return applyBinary(op, left, right)
+	}
+}
+
+func applyBinary(op string, left, right interface{}) (interface{}, error) {
+	switch l := left.(type) {
+	case bool:
+		r, ok := right.(bool)
+		if !ok {
+			break
+		}
+		switch op {
+		case "||", "&&":
+			return r, nil
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		}
+
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			break
+		}
+		switch op {
+		case "+":
+			return l + r, nil
+		case "==":
+			return l == r, nil
+		case "!=":
+			return l != r, nil
+		case "<":
+			return l < r, nil
+		case "<=":
+			return l <= r, nil
+		case ">":
+			return l > r, nil
+		case ">=":
+			return l >= r, nil
+		}
+
+	case int64:
+		if r, ok := right.(int64); ok {
+			return applyInt(op, l, r)
+		}
+		if r, ok := right.(float64); ok {
+			return applyFloat(op, float64(l), r)
+		}
+
+	case float64:
+		if r, ok := right.(int64); ok {
+			return applyFloat(op, l, float64(r))
+		}
+		if r, ok := right.(float64); ok {
+			return applyFloat(op, l, r)
+		}
+
+	case nil:
+		switch op {
+		case "==":
+			return right == nil, nil
+		case "!=":
+			return right != nil, nil
+		}
+	}
+	return nil, fmt.Errorf("can't apply %s to %v and %v", op, left, right)
+}
+
+func applyInt(op string, l, r int64) (interface{}, error) {
+	switch op {
+	case "/", "%":
+		if r == 0 {
+			return nil, errors.New("division by zero")
+		}
+		if op == "/" {
+			return l / r, nil
+		}
+		return l % r, nil
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, errors.Errorf("can't apply %s to numbers", op)
+}
+
+func applyFloat(op string, l, r float64) (interface{}, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		return l / r, nil
+	case "%":
+		return math.Mod(l, r), nil
+	case "==":
+		return l == r, nil
+	case "!=":
+		return l != r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+	return nil, errors.Errorf("can't apply %s to numbers", op)
+}
@@ -0,0 +1,99 @@
+package kong_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestCheck(t *testing.T) {
+	type cli struct {
+		Size int    `check:"size < 1024 && size % 2 == 0"`
+		Name string `check:"matches(name, '^[a-z]+$') && len(name) <= 8"`
+	}
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{args: []string{}},
+		{args: []string{"--size=512", "--name=web"}},
+		{args: []string{"--size=2048"}, err: `--size must satisfy "size < 1024 && size % 2 == 0"`},
+		{args: []string{"--size=3"}, err: `--size must satisfy "size < 1024 && size % 2 == 0"`},
+		{args: []string{"--name=Web"}, err: `--name must satisfy "matches(name, '^[a-z]+$') && len(name) <= 8"`},
+	} {
+		t.Run(test.err, func(t *testing.T) {
+			p := mustNew(t, &cli{})
+			_, err := p.Parse(test.args)
+			if test.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestCheckCommand(t *testing.T) {
+	type cli struct {
+		Verbose bool
+		Serve   struct {
+			MinConns int           `default:"1"`
+			MaxConns int           `default:"10"`
+			Timeout  time.Duration `default:"30s" check:"timeout <= duration('1m') || verbose"`
+		} `cmd:"" check:"min_conns <= max_conns"`
+	}
+	p := mustNew(t, &cli{})
+	_, err := p.Parse([]string{"serve", "--max-conns=5"})
+	require.NoError(t, err)
+
+	p = mustNew(t, &cli{})
+	_, err = p.Parse([]string{"serve", "--min-conns=6", "--max-conns=5"})
+	require.EqualError(t, err, `serve must satisfy "min_conns <= max_conns"`)
+
+	p = mustNew(t, &cli{})
+	_, err = p.Parse([]string{"serve", "--timeout=5m"})
+	require.EqualError(t, err, `--timeout must satisfy "timeout <= duration('1m') || verbose"`)
+
+	p = mustNew(t, &cli{})
+	_, err = p.Parse([]string{"--verbose", "serve", "--timeout=5m"})
+	require.NoError(t, err)
+}
+
+func TestCheckAggregateErrors(t *testing.T) {
+	var cli struct {
+		Min int `check:"min >= 0"`
+		Max int `check:"max > min"`
+	}
+	p := mustNew(t, &cli, kong.AggregateErrors())
+	_, err := p.Parse([]string{"--min=-1", "--max=-2"})
+	require.EqualError(t, err, "2 validation errors:\n"+
+		"  - --min must satisfy \"min >= 0\"\n"+
+		"  - --max must satisfy \"max > min\"")
+}
+
+func TestInvalidCheck(t *testing.T) {
+	for _, test := range []struct {
+		check string
+		err   string
+	}{
+		{check: "size <", err: `--size: invalid check "size <": unexpected end of expression`},
+		{check: "size = 1", err: `--size: invalid check "size = 1": unexpected "="`},
+		{check: "(size > 1", err: `--size: invalid check "(size > 1": expected ")" but got end of expression`},
+		{check: "count > 1", err: `--size: check "count > 1" refers to unknown value "count"`},
+		{check: "size(1)", err: `--size: invalid check "size(1)": unknown function size()`},
+	} {
+		t.Run(test.check, func(t *testing.T) {
+			typ := reflect.StructOf([]reflect.StructField{{
+				Name: "Size",
+				Type: reflect.TypeOf(0),
+				Tag:  reflect.StructTag(`check:"` + test.check + `"`),
+			}})
+			_, err := kong.New(reflect.New(typ).Interface())
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,591 @@
+package kong
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Maximum number of previously used values remembered for each flag.
+	completionHistoryLimit = 20
+	// Maximum length of help summaries shown alongside completions.
+	completionHelpWidth = 60
+	// Hidden entry point of the completion protocol.
+	completeCommand = "__complete"
+)
+
+// A Completion is a candidate for completing a command-line word.
+type Completion struct {
+	Value string
+	// Summary of the help of the command or flag, if any, for shells that display descriptions.
+	Help string
+}
+
+// CompletionArgs describes the command-line being completed.
+type CompletionArgs struct {
+	// Words preceding the one being completed.
+	Completed []string
+	// The partial word being completed, eg. "fo" for "--flag=fo".
+	Last string
+}
+
+// A Predictor provides completion candidates for a value.
+//
+// Mappers and field types may implement this interface to provide dynamic candidates, eg. the names
+// of remote resources. Candidates are filtered by prefix, so predictors may return all values.
+type Predictor interface {
+	Predict(args CompletionArgs) []string
+}
+
+// PredictorFunc is a function that implements the Predictor interface.
+type PredictorFunc func(args CompletionArgs) []string
+
+// Predict calls the function.
+func (p PredictorFunc) Predict(args CompletionArgs) []string { return p(args) }
+
+// A CompletionDirective tells the shell how to treat completion candidates. Directives are bit flags.
+type CompletionDirective int
+
+// Completion directives, compatible with those of cobra.
+const (
+	// Candidates may be followed by a space, and the shell may fall back to completing files if there
+	// are none.
+	CompletionDirectiveDefault CompletionDirective = 0
+	// The command-line could not be completed, so no candidates should be offered.
+	CompletionDirectiveError CompletionDirective = 1 << 0
+	// No space should be added after the completed word, eg. after "--flag=" or a directory.
+	CompletionDirectiveNoSpace CompletionDirective = 1 << 1
+	// The shell should not fall back to completing files.
+	CompletionDirectiveNoFileComp CompletionDirective = 1 << 2
+	// The shell should complete directories only. 1 << 3 is reserved for cobra's file extension filter.
+	CompletionDirectiveFilterDirs CompletionDirective = 1 << 4
+)
+
+// CompletionProtocol adds a hidden "__complete" entry point that prints the completion candidates for
+// the command-line following it, so that shell completion scripts can delegate to the application.
+//
+// Each candidate is printed on its own line, followed by a tab and its help summary if any. The last
+// line is the CompletionDirective, prefixed with ":". eg.
+//
+//     $ myapp __complete deploy --
+//     --env	Environment to deploy to.
+//     --force	Skip confirmation.
+//     :4
+func CompletionProtocol() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.completionProtocol = true
+		return nil
+	})
+}
+
+// CompletionScriptFlag adds a hidden "--completion-script=SHELL" flag that prints a completion script for
+// SHELL, then exits. See Kong.WriteCompletionScript() for the supported shells.
+//
+//     myapp --completion-script=fish > ~/.config/fish/completions/myapp.fish
+func CompletionScriptFlag() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.completionScript = true
+		return nil
+	})
+}
+
+// NamedPredictor registers a Predictor that completes the values of flags and positional arguments that
+// reference it by name with the predictor:"<name>" tag, eg.
+//
+//     var cli struct {
+//         Branch string `predictor:"branches"`
+//     }
+//     kong.Parse(&cli, kong.NamedPredictor("branches", kong.PredictorFunc(listBranches)))
+func NamedPredictor(name string, predictor Predictor) Option {
+	return OptionFunc(func(k *Kong) error {
+		if k.namedPredictors == nil {
+			k.namedPredictors = map[string]Predictor{}
+		}
+		k.namedPredictors[name] = predictor
+		return nil
+	})
+}
+
+// TypePredictor registers a Predictor that completes values of type typ, for types that can't implement
+// Predictor themselves.
+func TypePredictor(typ reflect.Type, predictor Predictor) Option {
+	return OptionFunc(func(k *Kong) error {
+		if k.typePredictors == nil {
+			k.typePredictors = map[reflect.Type]Predictor{}
+		}
+		k.typePredictors[typ] = predictor
+		return nil
+	})
+}
+
+// CompletionHistory records the values of flags tagged with history:"" passed on the command-line in a
+// JSON file at path, and offers them as completion candidates.
+//
+// Only values given on the command-line are recorded, not those from environment variables or
+// resolvers, and hidden flags and those tagged with redact:"" are never recorded.
+//
+// eg.
+//
+//     kong.CompletionHistory("~/.cache/myapp/history.json")
+func CompletionHistory(path string) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.completionHistory = path
+		return nil
+	})
+}
+
+// Complete returns completion candidates for the last word in args, which may be empty.
+//
+// Commands, flags and flag values are completed according to the command-line preceding the last
+// word. Values are completed from the enum of the flag or positional argument, from its Predictor if
+// any, and from previously used values if CompletionHistory() is configured. Hidden flags and commands
+// are only completed if the HelpHidden() option is set, and nothing is completed if the preceding words
+// are invalid.
+func (k *Kong) Complete(args []string) []Completion {
+	completions, _ := k.complete(args)
+	return completions
+}
+
+func (k *Kong) complete(args []string) ([]Completion, CompletionDirective) {
+	last := ""
+	if len(args) > 0 {
+		last = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+	completed := CompletionArgs{Completed: args, Last: last}
+	ctx, err := Trace(k, args)
+	if err != nil {
+		return nil, CompletionDirectiveError
+	}
+	// A trailing flag waiting for its value fails to trace, so trace without it.
+	if ctx.Error != nil && len(args) > 0 {
+		if prev, err := Trace(k, args[:len(args)-1]); err == nil && prev.Error == nil {
+			if flag := findCompletionFlag(prev.Flags(), args[len(args)-1]); flag != nil && !flag.IsBool() && !flag.IsCounter() {
+				return valueCompletions(flag.Value, completionsFor(k.valueCandidates(flag.Value, completed), "", last))
+			}
+		}
+	}
+	if ctx.Error != nil {
+		return nil, CompletionDirectiveError
+	}
+
+	switch {
+	case strings.HasPrefix(last, "--") && strings.Contains(last, "="):
+		parts := strings.SplitN(last, "=", 2)
+		flag := findCompletionFlag(ctx.Flags(), parts[0])
+		if flag == nil {
+			return nil, CompletionDirectiveError
+		}
+		completed.Last = parts[1]
+		return valueCompletions(flag.Value, completionsFor(k.valueCandidates(flag.Value, completed), parts[0]+"=", parts[1]))
+
+	case strings.HasPrefix(last, "-"):
+		candidates := []string{}
+		help := map[string]string{}
+		for _, flag := range ctx.Flags() {
+			if flag.Hidden && (!k.helpHidden || flag.Tag.Internal) {
+				continue
+			}
+			names := []string{}
+			if !flag.Tag.ShortOnly {
+				names = append(names, "--"+flag.Name)
+			}
+			if flag.Tag.Negatable {
+				names = append(names, "--no-"+flag.Name)
+			}
+			if short := flag.shortName(); short != "" {
+				names = append(names, "-"+short)
+			}
+			for _, name := range names {
+				help[name] = flag.Help
+			}
+			candidates = append(candidates, names...)
+		}
+		return withCompletionHelp(completionsFor(candidates, "", last), help), CompletionDirectiveNoFileComp
+	}
+
+	node := ctx.Selected()
+	if node == nil {
+		node = ctx.Model.Node
+	}
+	candidates := []string{}
+	help := map[string]string{}
+	for _, child := range node.Children {
+		if child.Type == CommandNode && (!child.Hidden || k.helpHidden) {
+			candidates = append(candidates, child.Name)
+			help[child.Name] = child.Help
+		}
+	}
+	positional := nextPositional(ctx, node)
+	if positional != nil {
+		candidates = append(candidates, k.valueCandidates(positional, completed)...)
+	}
+	return valueCompletions(positional, withCompletionHelp(completionsFor(candidates, "", last), help))
+}
+
+// The directive for completions of value, if any, which fall back to files, or directories for directory
+// types, if there are none, and aren't followed by a space if they are all incomplete, eg. "--flag=" or a
+// directory.
+func valueCompletions(value *Value, completions []Completion) ([]Completion, CompletionDirective) {
+	if len(completions) == 0 {
+		if value != nil && completionFileKind(value) == "dir" {
+			return completions, CompletionDirectiveFilterDirs
+		}
+		return completions, CompletionDirectiveDefault
+	}
+	directive := CompletionDirectiveNoFileComp
+	incomplete := true
+	for _, completion := range completions {
+		if !strings.HasSuffix(completion.Value, "=") && !strings.HasSuffix(completion.Value, "/") {
+			incomplete = false
+		}
+	}
+	if incomplete {
+		directive |= CompletionDirectiveNoSpace
+	}
+	return completions, directive
+}
+
+// Print the completions for args in the format of the completion protocol, then exit.
+func (k *Kong) writeCompletionProtocol(args []string) error {
+	completions, directive := k.complete(args)
+	for _, completion := range completions {
+		line := completion.Value
+		if completion.Help != "" {
+			line += "\t" + completion.Help
+		}
+		if _, err := fmt.Fprintln(k.Stdout, line); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(k.Stdout, ":%d\n", directive); err != nil {
+		return err
+	}
+	// There is no Context to return, so the sentinel is returned even if Exit returns.
+	_ = k.exitOr(ErrHelpRequested)
+	return ErrHelpRequested
+}
+
+// WriteCompletions writes completions in the format expected by the completion function of shell, one
+// per line.
+//
+// "zsh" and "fish" completions include help summaries as descriptions, in the "value:description"
+// format of zsh's _describe and the "value<TAB>description" format of fish respectively. "bash"
+// completions are values only.
+func WriteCompletions(w io.Writer, shell string, completions []Completion) error {
+	for _, completion := range completions {
+		var line string
+		switch shell {
+		case "bash":
+			line = completion.Value
+		case "zsh":
+			line = strings.ReplaceAll(completion.Value, ":", "\\:")
+			if completion.Help != "" {
+				line += ":" + completion.Help
+			}
+		case "fish":
+			line = completion.Value
+			if completion.Help != "" {
+				line += "\t" + completion.Help
+			}
+		default:
+			return errors.Errorf("unsupported shell %q", shell)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCompletionScript writes a script for shell that completes the application's commands, flags and
+// values from its grammar, with descriptions taken from their help.
+//
+// "zsh", "fish" and "powershell" are supported, and "bash" if the CompletionProtocol() option is used, as
+// the bash script delegates to the application's "__complete" entry point. eg.
+//
+//     myapp completion zsh > "${fpath[1]}/_myapp"
+func (k *Kong) WriteCompletionScript(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		if !k.completionProtocol {
+			return errors.New("bash completion requires the kong.CompletionProtocol() option")
+		}
+		return writeBashCompletion(w, k.Model)
+	case "zsh":
+		return writeZshCompletion(w, k.Model)
+	case "fish":
+		return writeFishCompletion(w, k.Model)
+	case "powershell":
+		return writePowerShellCompletion(w, k.Model)
+	default:
+		return errors.Errorf("unsupported shell %q", shell)
+	}
+}
+
+// Visible children of node, with commands before branching arguments so they match first.
+func completionChildren(node *Node) []*Node {
+	commands, arguments := []*Node{}, []*Node{}
+	for _, child := range node.Children {
+		switch {
+		case child.Hidden:
+		case child.Type == ArgumentNode:
+			arguments = append(arguments, child)
+		default:
+			commands = append(commands, child)
+		}
+	}
+	return append(commands, arguments...)
+}
+
+// The element of the command path tracked by completion scripts for node, eg. "build" or "<name>".
+func completionPathElement(node *Node) string {
+	if node.Type == ArgumentNode {
+		return "<" + node.Name + ">"
+	}
+	return node.Name
+}
+
+// Shells supported by WriteCompletionScript().
+var completionScriptShells = []string{"bash", "zsh", "fish", "powershell"}
+
+func (k *Kong) completionScriptFlag() *Flag {
+	var target completionScriptValue
+	value := reflect.ValueOf(&target).Elem()
+	flag := &Flag{
+		Hidden:      true,
+		PlaceHolder: "SHELL",
+		Value: &Value{
+			Name:         "completion-script",
+			Help:         "Print a completion script for SHELL (" + strings.Join(completionScriptShells, ", ") + ").",
+			Target:       value,
+			Tag:          newEmptyTag(),
+			Mapper:       k.registry.ForValue(value),
+			DefaultValue: reflect.ValueOf(""),
+		},
+	}
+	flag.Flag = flag
+	return flag
+}
+
+type completionScriptValue string
+
+// BeforeApply prints the completion script for the shell given to the flag, and terminates with a 0
+// exit status.
+func (c completionScriptValue) BeforeApply(ctx *Context) error {
+	var shell string
+	for _, path := range ctx.Path {
+		if path.Flag != nil && path.Flag.Name == "completion-script" {
+			shell = string(ctx.FlagValue(path.Flag).(completionScriptValue))
+		}
+	}
+	if err := ctx.Kong.WriteCompletionScript(ctx.Stdout, shell); err != nil {
+		return err
+	}
+	return ctx.Kong.exitOr(ErrHelpRequested)
+}
+
+func withCompletionHelp(completions []Completion, help map[string]string) []Completion {
+	for i, completion := range completions {
+		completions[i].Help = completionHelp(help[completion.Value])
+	}
+	return completions
+}
+
+// Summarise help as its first line, truncated at a word boundary if it is too long for a completion menu.
+func completionHelp(help string) string {
+	help = strings.TrimSpace(strings.SplitN(help, "\n", 2)[0])
+	if len([]rune(help)) <= completionHelpWidth {
+		return help
+	}
+	runes := []rune(help)[:completionHelpWidth-3]
+	if space := strings.LastIndex(string(runes), " "); space > 0 {
+		return strings.TrimRight(string(runes)[:space], " ,.;:") + "..."
+	}
+	return string(runes) + "..."
+}
+
+// Find a flag by its "--long" or "-s" form.
+func findCompletionFlag(flags []*Flag, arg string) *Flag {
+	for _, flag := range flags {
+		if (arg == "--"+flag.Name && !flag.Tag.ShortOnly) || (flag.shortName() != "" && arg == "-"+flag.shortName()) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// The positional argument of node that the next word will be parsed into, if any.
+func nextPositional(ctx *Context, node *Node) *Positional {
+	if len(node.Positional) == 0 {
+		return nil
+	}
+	consumed := 0
+	for _, path := range ctx.Path {
+		if path.Positional != nil {
+			consumed++
+		}
+	}
+	if consumed < len(node.Positional) {
+		return node.Positional[consumed]
+	}
+	if last := node.Positional[len(node.Positional)-1]; last.IsCumulative() {
+		return last
+	}
+	return nil
+}
+
+// Candidates for a value, from its enum, Predictor and history.
+func (k *Kong) valueCandidates(value *Value, args CompletionArgs) []string {
+	candidates := enumCompletions(value, args.Last)
+	if predictor := k.predictorFor(value); predictor != nil {
+		candidates = append(candidates, predictor.Predict(args)...)
+	}
+	if k.completionHistory != "" && value.Tag.History {
+		history, _ := k.loadCompletionHistory()
+		candidates = append(candidates, history[value.Name]...)
+	}
+	return candidates
+}
+
+// The enum values of value as candidates for the partial word last.
+//
+// Elements of slices are completed after the elements preceding them, eg. "a,b" for "a,". Map values are
+// completed after their key, eg. "env=prod" for "env=p", and not at all until the key is complete.
+func enumCompletions(value *Value, last string) []string {
+	candidates := []string{}
+	if value.Enum == "" {
+		return candidates
+	}
+	prefix := ""
+	switch {
+	case value.IsMap():
+		entry := last
+		if sep := value.Tag.MapSep; sep != -1 {
+			if i := strings.LastIndex(last, string(sep)); i >= 0 {
+				prefix, entry = last[:i+1], last[i+1:]
+			}
+		}
+		eq := strings.Index(entry, "=")
+		if eq < 0 {
+			return candidates
+		}
+		prefix += entry[:eq+1]
+
+	case value.IsSlice() && value.Tag.Sep != -1:
+		if i := strings.LastIndex(last, string(value.Tag.Sep)); i >= 0 {
+			prefix = last[:i+1]
+		}
+	}
+	for _, enum := range strings.Split(value.Enum, ",") {
+		candidates = append(candidates, prefix+strings.TrimSpace(enum))
+	}
+	return candidates
+}
+
+// The Predictor of value, from its predictor:"" tag, a predictor registered for its type, its mapper, or
+// its type itself, in that order.
+func (k *Kong) predictorFor(value *Value) Predictor {
+	if value.Tag.Predictor != "" {
+		return k.namedPredictors[value.Tag.Predictor]
+	}
+	if value.Target.IsValid() {
+		if predictor, ok := k.typePredictors[value.Target.Type()]; ok {
+			return predictor
+		}
+	}
+	if predictor, ok := value.Mapper.(Predictor); ok {
+		return predictor
+	}
+	if value.Target.IsValid() && value.Target.CanAddr() {
+		if predictor, ok := value.Target.Addr().Interface().(Predictor); ok {
+			return predictor
+		}
+	}
+	return nil
+}
+
+// Check that every predictor referenced by a predictor:"" tag is registered.
+func (k *Kong) checkNamedPredictors(node *Node) error {
+	return Visit(node, func(node Visitable, next Next) error {
+		if value, ok := node.(*Value); ok && value.Tag.Predictor != "" {
+			if _, ok := k.namedPredictors[value.Tag.Predictor]; !ok {
+				return fmt.Errorf("unknown predictor %q, use kong.NamedPredictor(%q, predictor)", value.Tag.Predictor, value.Tag.Predictor)
+			}
+		}
+		return next(nil)
+	})
+}
+
+// Filter candidates by prefix, removing duplicates while preserving order.
+func completionsFor(candidates []string, prefix, partial string) []Completion {
+	seen := map[string]bool{}
+	completions := []Completion{}
+	for _, candidate := range candidates {
+		if seen[candidate] || !strings.HasPrefix(candidate, partial) {
+			continue
+		}
+		seen[candidate] = true
+		completions = append(completions, Completion{Value: prefix + candidate})
+	}
+	return completions
+}
+
+func (k *Kong) loadCompletionHistory() (map[string][]string, error) {
+	history := map[string][]string{}
+	data, err := ioutil.ReadFile(ExpandPath(k.completionHistory))
+	if os.IsNotExist(err) {
+		return history, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return history, json.Unmarshal(data, &history)
+}
+
+// Record the values of flags tagged with history:"" passed on the command-line, most recent first.
+func (k *Kong) recordCompletionHistory(ctx *Context) error {
+	history, err := k.loadCompletionHistory()
+	if err != nil {
+		return err
+	}
+	changed := false
+	for _, path := range ctx.Path {
+		flag := path.Flag
+		if flag == nil || path.Resolved || !flag.Tag.History || flag.Hidden || flag.Tag.Redact || flag.IsBool() || flag.IsCounter() {
+			continue
+		}
+		target := reflect.Indirect(flag.Target)
+		if !target.IsValid() || !isBasicType(target.Type()) {
+			continue
+		}
+		value := fmt.Sprint(target.Interface())
+		values := []string{value}
+		for _, previous := range history[flag.Name] {
+			if previous != value && len(values) < completionHistoryLimit {
+				values = append(values, previous)
+			}
+		}
+		history[flag.Name] = values
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := ExpandPath(k.completionHistory)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,62 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Write a bash completion script for app that delegates to its "__complete" entry point, so that candidates
+// come from the same engine as Kong.Complete(), including predictors.
+//
+// The command-line is split on whitespace only, rather than on COMP_WORDBREAKS, so that "--flag=value" is
+// passed as a single word; the part of each candidate before the word bash is completing is then removed.
+func writeBashCompletion(w io.Writer, app *Application) error {
+	b := &strings.Builder{}
+	name := zshFunctionName(app.Node)
+	fmt.Fprintf(b, "# bash completion for %s\n\n", app.Name)
+	fmt.Fprintf(b, "%s() {\n", name)
+	fmt.Fprintf(b, `    local line="${COMP_LINE:0:COMP_POINT}"
+    local -a words
+    read -r -a words <<< "$line"
+    if [[ -z $line || $line == *[[:space:]] ]]; then
+        words+=("")
+    fi
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local last="${words[${#words[@]}-1]}"
+    local prefix="${last%%"$cur"}"
+
+    local out
+    out=$("${words[0]}" %s "${words[@]:1}" 2>/dev/null) || return
+    local -a lines
+    mapfile -t lines <<< "$out"
+    local directive="${lines[${#lines[@]}-1]#:}"
+    unset 'lines[${#lines[@]}-1]'
+    if (( directive & %d )); then
+        return
+    fi
+
+    COMPREPLY=()
+    local candidate
+    for candidate in "${lines[@]}"; do
+        candidate="${candidate%%%%$'\t'*}"
+        COMPREPLY+=("${candidate#"$prefix"}")
+    done
+    if (( directive & %d )); then
+        compopt -o nospace
+    fi
+    if (( ${#COMPREPLY[@]} == 0 )); then
+        if (( directive & %d )); then
+            compopt -o filenames
+            mapfile -t COMPREPLY < <(compgen -d -- "$cur")
+        elif (( !(directive & %d) )); then
+            compopt -o filenames
+            mapfile -t COMPREPLY < <(compgen -f -- "$cur")
+        fi
+    fi
+}
+`, completeCommand, CompletionDirectiveError, CompletionDirectiveNoSpace, CompletionDirectiveFilterDirs, CompletionDirectiveNoFileComp)
+	fmt.Fprintf(b, "\ncomplete -F %s %s\n", name, app.Name)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,201 @@
+package kong
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// WriteCarapaceSpec writes a carapace (https://carapace.sh) spec for the application, from which
+// carapace-bin provides completion for all the shells it supports.
+//
+// As with WriteFigSpec(), flags of a command are persistent, and the commands and flags of branching
+// positional arguments are merged into those of the parent command.
+func (k *Kong) WriteCarapaceSpec(w io.Writer) error {
+	command := carapaceCommandFor(k.Model.Node)
+	command.name = k.Model.Name
+	lines := []string{"# yaml-language-server: $schema=https://carapace.sh/schemas/command.json"}
+	lines = append(lines, command.lines()...)
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+type carapaceCommand struct {
+	name          string
+	aliases       []string
+	description   string
+	flags         [][2]string // Flag specs, eg. "-o, --output=", and their descriptions.
+	persistent    bool
+	flagActions   []carapaceFlagActions
+	positional    [][]string
+	positionalAny []string
+	commands      []*carapaceCommand
+}
+
+// The actions that complete the value of a flag.
+type carapaceFlagActions struct {
+	name    string
+	actions []string
+}
+
+func carapaceCommandFor(node *Node) *carapaceCommand {
+	command := &carapaceCommand{name: node.Name, aliases: node.Aliases, description: completionHelp(node.Help)}
+	command.merge(node)
+	return command
+}
+
+// Add the flags, positional arguments and subcommands of node to c.
+func (c *carapaceCommand) merge(node *Node) {
+	children := completionChildren(node)
+	c.persistent = c.persistent || len(children) > 0
+	for _, flag := range node.Flags {
+		if !flag.Hidden {
+			c.addFlag(flag)
+		}
+	}
+	for _, positional := range node.Positional {
+		c.addPositional(positional)
+	}
+	for _, child := range children {
+		if child.Type == ArgumentNode {
+			c.addPositional(child.Argument)
+			c.merge(child)
+			continue
+		}
+		c.commands = append(c.commands, carapaceCommandFor(child))
+	}
+}
+
+func (c *carapaceCommand) addFlag(flag *Flag) {
+	names := []string{}
+	if short := flag.shortName(); short != "" {
+		names = append(names, "-"+short)
+	}
+	if !flag.Tag.ShortOnly {
+		names = append(names, "--"+flag.Name)
+	}
+	spec := strings.Join(names, ", ")
+	if !flag.IsBool() && !flag.IsCounter() {
+		spec += "="
+	}
+	if flag.IsCumulative() || flag.IsCounter() {
+		spec += "*"
+	}
+	if flag.Required {
+		spec += "!"
+	}
+	help := completionHelp(flag.Help)
+	c.flags = append(c.flags, [2]string{spec, help})
+	if flag.Tag.Negatable {
+		c.flags = append(c.flags, [2]string{"--no-" + flag.Name, help})
+	}
+	if actions := carapaceActions(flag.Value); len(actions) > 0 && !flag.IsBool() {
+		name := flag.Name
+		if flag.Tag.ShortOnly {
+			name = flag.shortName()
+		}
+		c.flagActions = append(c.flagActions, carapaceFlagActions{name, actions})
+	}
+}
+
+func (c *carapaceCommand) addPositional(value *Value) {
+	if value.IsCumulative() {
+		c.positionalAny = carapaceActions(value)
+		return
+	}
+	c.positional = append(c.positional, carapaceActions(value))
+}
+
+// The carapace actions that complete value, enum values or a macro for files or directories.
+func carapaceActions(value *Value) []string {
+	actions := []string{}
+	if value.Enum != "" && !value.IsMap() {
+		for _, enum := range strings.Split(value.Enum, ",") {
+			actions = append(actions, strings.TrimSpace(enum))
+		}
+		return actions
+	}
+	switch completionFileKind(value) {
+	case "dir":
+		actions = append(actions, "$directories")
+	case "file":
+		actions = append(actions, "$files")
+	}
+	return actions
+}
+
+// The command as lines of YAML.
+func (c *carapaceCommand) lines() []string {
+	lines := []string{"name: " + yamlQuote(c.name)}
+	if len(c.aliases) > 0 {
+		lines = append(lines, "aliases: "+yamlList(c.aliases))
+	}
+	if c.description != "" {
+		lines = append(lines, "description: "+yamlQuote(c.description))
+	}
+	if len(c.flags) > 0 {
+		if c.persistent {
+			lines = append(lines, "persistentflags:")
+		} else {
+			lines = append(lines, "flags:")
+		}
+		for _, flag := range c.flags {
+			lines = append(lines, "  "+yamlQuote(flag[0])+": "+yamlQuote(flag[1]))
+		}
+	}
+	// Positional arguments without actions are only needed to position those after them.
+	positional := c.positional
+	for len(positional) > 0 && len(positional[len(positional)-1]) == 0 {
+		positional = positional[:len(positional)-1]
+	}
+	if len(c.flagActions) > 0 || len(positional) > 0 || len(c.positionalAny) > 0 {
+		lines = append(lines, "completion:")
+		if len(c.flagActions) > 0 {
+			lines = append(lines, "  flag:")
+			for _, flag := range c.flagActions {
+				lines = append(lines, "    "+yamlQuote(flag.name)+": "+yamlList(flag.actions))
+			}
+		}
+		if len(positional) > 0 {
+			lines = append(lines, "  positional:")
+			for _, actions := range positional {
+				lines = append(lines, "    - "+yamlList(actions))
+			}
+		}
+		if len(c.positionalAny) > 0 {
+			lines = append(lines, "  positionalany: "+yamlList(c.positionalAny))
+		}
+	}
+	if len(c.commands) > 0 {
+		lines = append(lines, "commands:")
+		for _, command := range c.commands {
+			for i, line := range command.lines() {
+				if i == 0 {
+					lines = append(lines, "  - "+line)
+				} else {
+					lines = append(lines, "    "+line)
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// Double quote s for YAML, which accepts JSON strings.
+func yamlQuote(s string) string {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// A YAML flow sequence of strings.
+func yamlList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = yamlQuote(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,159 @@
+package kong
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteFigSpec writes a Fig completion spec for the application, as used by Fig and Amazon Q, as a
+// TypeScript module with the spec as its default export.
+//
+// Flags of a command are persistent, as Kong accepts them after its subcommands. Fig has no equivalent of
+// branching positional arguments, so their commands and flags are merged into those of the parent command.
+func (k *Kong) WriteFigSpec(w io.Writer) error {
+	spec := figCommand(k.Model.Node)
+	spec.Name = k.Model.Name
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(spec); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "const completionSpec: Fig.Spec = %s;\n\nexport default completionSpec;\n", strings.TrimSuffix(buf.String(), "\n"))
+	return err
+}
+
+// A Fig subcommand, or the spec itself.
+type figSubcommand struct {
+	Name        interface{}      `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Subcommands []*figSubcommand `json:"subcommands,omitempty"`
+	Options     []*figOption     `json:"options,omitempty"`
+	Args        []*figArg        `json:"args,omitempty"`
+}
+
+type figOption struct {
+	Name         interface{} `json:"name"`
+	Description  string      `json:"description,omitempty"`
+	Args         *figArg     `json:"args,omitempty"`
+	IsRequired   bool        `json:"isRequired,omitempty"`
+	IsRepeatable bool        `json:"isRepeatable,omitempty"`
+	IsPersistent bool        `json:"isPersistent,omitempty"`
+	ExclusiveOn  []string    `json:"exclusiveOn,omitempty"`
+}
+
+type figArg struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	IsOptional  bool     `json:"isOptional,omitempty"`
+	IsVariadic  bool     `json:"isVariadic,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Template    string   `json:"template,omitempty"`
+	Default     string   `json:"default,omitempty"`
+}
+
+func figCommand(node *Node) *figSubcommand {
+	command := &figSubcommand{Name: figName(append([]string{node.Name}, node.Aliases...)), Description: figDescription(node.Help)}
+	command.merge(node)
+	return command
+}
+
+// Add the flags, positional arguments and subcommands of node to command.
+func (c *figSubcommand) merge(node *Node) {
+	persistent := len(completionChildren(node)) > 0
+	for _, flag := range node.Flags {
+		if flag.Hidden {
+			continue
+		}
+		c.Options = append(c.Options, figOptions(flag, persistent)...)
+	}
+	for _, positional := range node.Positional {
+		c.Args = append(c.Args, figValueArg(positional, positional.Name))
+	}
+	for _, child := range completionChildren(node) {
+		if child.Type == ArgumentNode {
+			c.Args = append(c.Args, figValueArg(child.Argument, child.Name))
+			c.merge(child)
+			continue
+		}
+		c.Subcommands = append(c.Subcommands, figCommand(child))
+	}
+}
+
+func figOptions(flag *Flag, persistent bool) []*figOption {
+	names := []string{}
+	if !flag.Tag.ShortOnly {
+		names = append(names, "--"+flag.Name)
+	}
+	if short := flag.shortName(); short != "" {
+		names = append(names, "-"+short)
+	}
+	option := &figOption{
+		Name:         figName(names),
+		Description:  figDescription(flag.Help),
+		IsRequired:   flag.Required,
+		IsRepeatable: flag.IsCumulative() || flag.IsCounter(),
+		IsPersistent: persistent,
+	}
+	if !flag.IsBool() && !flag.IsCounter() {
+		name := flag.PlaceHolder
+		if name == "" {
+			name = flag.Name
+		}
+		option.Args = figValueArg(flag.Value, strings.ToLower(name))
+		option.Args.Description = ""
+		option.Args.IsOptional = false
+		option.Args.IsVariadic = false
+	}
+	options := []*figOption{option}
+	if flag.Tag.Negatable {
+		options = append(options, &figOption{
+			Name:         "--no-" + flag.Name,
+			Description:  option.Description,
+			IsPersistent: persistent,
+			ExclusiveOn:  []string{"--" + flag.Name},
+		})
+		option.ExclusiveOn = []string{"--no-" + flag.Name}
+	}
+	return options
+}
+
+// A Fig argument for a positional argument or the value of a flag.
+func figValueArg(value *Value, name string) *figArg {
+	arg := &figArg{
+		Name:        name,
+		Description: figDescription(value.Help),
+		IsOptional:  !value.Required,
+		IsVariadic:  value.IsCumulative(),
+		Default:     value.Default,
+	}
+	if value.Enum != "" && !value.IsMap() {
+		for _, enum := range strings.Split(value.Enum, ",") {
+			arg.Suggestions = append(arg.Suggestions, strings.TrimSpace(enum))
+		}
+	}
+	switch completionFileKind(value) {
+	case "dir":
+		arg.Template = "folders"
+	case "file":
+		arg.Template = "filepaths"
+	}
+	return arg
+}
+
+// A single name as a string, or several as an array.
+func figName(names []string) interface{} {
+	if len(names) == 1 {
+		return names[0]
+	}
+	return names
+}
+
+// The first line of help, which Fig shows beside a suggestion.
+func figDescription(help string) string {
+	return strings.TrimSpace(strings.SplitN(help, "\n", 2)[0])
+}
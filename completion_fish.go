@@ -0,0 +1,175 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Write a fish completion script for app.
+//
+// The script tracks the command path of the command-line being completed, so that commands, flags and
+// positional arguments are only completed under the command they belong to.
+func writeFishCompletion(w io.Writer, app *Application) error {
+	f := &fishWriter{w: &strings.Builder{}, name: app.Name, prefix: "__fish_" + zshFunctionName(app.Node)[1:]}
+	f.printf("# fish completion for %s\n\n", app.Name)
+	f.printf("function %s_command_path\n", f.prefix)
+	f.printf("    set -l words (commandline -opc)\n")
+	f.printf("    set -e words[1]\n")
+	f.printf("    set -l path\n")
+	f.printf("    for word in $words\n")
+	f.printf("        switch \"$path/$word\"\n")
+	f.printf("            case '*/-*'\n")
+	f.commandPathCases(app.Node, "")
+	f.printf("        end\n")
+	f.printf("    end\n")
+	f.printf("    echo $path\n")
+	f.printf("end\n\n")
+	f.printf("function %s_using_command\n", f.prefix)
+	f.printf("    set -l path (%s_command_path)\n", f.prefix)
+	f.printf("    test \"$path\" = \"$argv\"\n")
+	f.printf("end\n\n")
+	f.printf("function %s_seen_command\n", f.prefix)
+	f.printf("    set -l path (%s_command_path)\n", f.prefix)
+	f.printf("    test \"$path\" = \"$argv\"; or string match -q -- \"$argv *\" \"$path\"\n")
+	f.printf("end\n\n")
+	f.printf("complete -c %s -f\n", fishQuote(app.Name))
+	f.node(app.Node, "")
+	_, err := io.WriteString(w, f.w.String())
+	return err
+}
+
+type fishWriter struct {
+	w      *strings.Builder
+	name   string
+	prefix string
+}
+
+func (f *fishWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(f.w, format, args...)
+}
+
+// Write the cases of the switch that tracks the command path, for the children of node at path.
+func (f *fishWriter) commandPathCases(node *Node, path string) {
+	children := completionChildren(node)
+	for _, child := range children {
+		childPath := strings.TrimSpace(path + " " + completionPathElement(child))
+		patterns := []string{}
+		if child.Type == ArgumentNode {
+			patterns = append(patterns, fishQuote(path+"/*"))
+		} else {
+			for _, name := range append([]string{child.Name}, child.Aliases...) {
+				patterns = append(patterns, fishQuote(path+"/"+name))
+			}
+		}
+		f.printf("            case %s\n", strings.Join(patterns, " "))
+		f.printf("                set path %s\n", fishQuote(childPath))
+	}
+	for _, child := range children {
+		f.commandPathCases(child, strings.TrimSpace(path+" "+completionPathElement(child)))
+	}
+}
+
+func (f *fishWriter) node(node *Node, path string) {
+	using := fishQuote(f.prefix + "_using_command " + fishQuote(path))
+	f.printf("\n")
+	for _, flag := range node.Flags {
+		if flag.Hidden {
+			continue
+		}
+		args := []string{"-c", fishQuote(f.name)}
+		if path != "" {
+			args = append(args, "-n", fishQuote(f.prefix+"_seen_command "+fishQuote(path)))
+		}
+		if flag.Tag.ShortName != "" {
+			// fish calls multi-character options with a single dash old-style options.
+			args = append(args, "-o", fishQuote(flag.Tag.ShortName))
+		} else if flag.Short != 0 {
+			args = append(args, "-s", fishQuote(string(flag.Short)))
+		}
+		if !flag.Tag.ShortOnly {
+			args = append(args, "-l", fishQuote(flag.Name))
+		}
+		if !flag.IsBool() && !flag.IsCounter() {
+			args = append(args, fishValueArgs(flag.Value)...)
+		}
+		if help := completionHelp(flag.Help); help != "" {
+			args = append(args, "-d", fishQuote(help))
+		}
+		f.printf("complete %s\n", strings.Join(args, " "))
+		if flag.Tag.Negatable {
+			args := []string{"-c", fishQuote(f.name)}
+			if path != "" {
+				args = append(args, "-n", fishQuote(f.prefix+"_seen_command "+fishQuote(path)))
+			}
+			args = append(args, "-l", fishQuote("no-"+flag.Name))
+			if help := completionHelp(flag.Help); help != "" {
+				args = append(args, "-d", fishQuote(help))
+			}
+			f.printf("complete %s\n", strings.Join(args, " "))
+		}
+	}
+	for _, positional := range node.Positional {
+		args := append([]string{"-c", fishQuote(f.name), "-n", using}, fishPositionalArgs(positional)...)
+		if len(args) > 4 {
+			f.printf("complete %s\n", strings.Join(args, " "))
+		}
+	}
+	for _, child := range completionChildren(node) {
+		switch child.Type {
+		case ArgumentNode:
+			args := append([]string{"-c", fishQuote(f.name), "-n", using}, fishPositionalArgs(child.Argument)...)
+			if len(args) > 4 {
+				f.printf("complete %s\n", strings.Join(args, " "))
+			}
+		default:
+			for _, name := range append([]string{child.Name}, child.Aliases...) {
+				args := []string{"-c", fishQuote(f.name), "-n", using, "-a", fishQuote(name)}
+				if help := completionHelp(child.Help); help != "" {
+					args = append(args, "-d", fishQuote(help))
+				}
+				f.printf("complete %s\n", strings.Join(args, " "))
+			}
+		}
+	}
+	for _, child := range completionChildren(node) {
+		f.node(child, strings.TrimSpace(path+" "+completionPathElement(child)))
+	}
+}
+
+// Arguments to complete a flag's value.
+func fishValueArgs(value *Value) []string {
+	if value.Enum != "" && !value.IsMap() {
+		enums := []string{}
+		for _, enum := range strings.Split(value.Enum, ",") {
+			enums = append(enums, strings.TrimSpace(enum))
+		}
+		return []string{"-x", "-a", fishQuote(strings.Join(enums, " "))}
+	}
+	switch completionFileKind(value) {
+	case "dir":
+		return []string{"-x", "-a", fishQuote("(__fish_complete_directories)")}
+	case "file":
+		return []string{"-r", "-F"}
+	}
+	return []string{"-x"}
+}
+
+// Arguments to complete a positional argument, if it has values to complete.
+func fishPositionalArgs(value *Value) []string {
+	if value.Enum != "" && !value.IsMap() {
+		return fishValueArgs(value)[1:]
+	}
+	switch completionFileKind(value) {
+	case "dir":
+		return []string{"-a", fishQuote("(__fish_complete_directories)")}
+	case "file":
+		return []string{"-F"}
+	}
+	return nil
+}
+
+// Single quote s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,169 @@
+package kong
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// CompletionCommands adds a "completion" command with "install" and "uninstall" subcommands, that install
+// a completion script for the current user's shell, or remove it.
+//
+// The shell is detected from $SHELL unless given, eg. "myapp completion install fish". Scripts are written
+// to the completion directory of the shell where it has one that is loaded automatically, and otherwise
+// sourced from its rc file:
+//
+//     bash  $XDG_DATA_HOME/bash-completion/completions/<app>
+//     zsh   $XDG_DATA_HOME/zsh/completions/_<app>, sourced from ${ZDOTDIR:-~}/.zshrc
+//     fish  $XDG_CONFIG_HOME/fish/completions/<app>.fish
+//
+// This option implies CompletionProtocol(), which the bash script relies on.
+func CompletionCommands() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.completionProtocol = true
+		k.dynamicCommands = append(k.dynamicCommands, &dynamicCommand{
+			name: "completion",
+			help: "Manage shell completion.",
+			cmd:  &completionCmd{},
+		})
+		return nil
+	})
+}
+
+type completionCmd struct {
+	Install   completionInstallCmd   `cmd:"" help:"Install shell completion for the current user."`
+	Uninstall completionUninstallCmd `cmd:"" help:"Uninstall shell completion for the current user."`
+}
+
+type completionInstallCmd struct {
+	Shell string `arg:"" optional:"" help:"Shell to install completion for: bash, zsh or fish. Detected from $SHELL by default."`
+}
+
+func (c *completionInstallCmd) Run(ctx *Context) error {
+	install, err := completionInstallFor(ctx.Kong, c.Shell)
+	if err != nil {
+		return err
+	}
+	script := &bytes.Buffer{}
+	if err := ctx.Kong.WriteCompletionScript(script, install.shell); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(install.script), 0700); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(install.script, script.Bytes(), 0600); err != nil {
+		return err
+	}
+	if install.rc != "" {
+		if err := install.updateRC(fmt.Sprintf("[ -f %q ] && source %q", install.script, install.script)); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(ctx.Stdout, "Installed %s completion in %s, which takes effect in new shells.\n", install.shell, install.script)
+	return nil
+}
+
+type completionUninstallCmd struct {
+	Shell string `arg:"" optional:"" help:"Shell to uninstall completion for: bash, zsh or fish. Detected from $SHELL by default."`
+}
+
+func (c *completionUninstallCmd) Run(ctx *Context) error {
+	install, err := completionInstallFor(ctx.Kong, c.Shell)
+	if err != nil {
+		return err
+	}
+	if install.rc != "" {
+		if err := install.updateRC(""); err != nil {
+			return err
+		}
+	}
+	err = os.Remove(install.script)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(ctx.Stdout, "No %s completion is installed.\n", install.shell)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "Uninstalled %s completion from %s.\n", install.shell, install.script)
+	return nil
+}
+
+// Where the completion script for a shell is installed.
+type completionInstall struct {
+	name   string
+	shell  string
+	script string
+	// The rc file that sources script, if the shell doesn't load it automatically.
+	rc string
+}
+
+func completionInstallFor(k *Kong, shell string) (*completionInstall, error) {
+	if shell == "" {
+		shell = filepath.Base(os.Getenv("SHELL"))
+		if shell == "." {
+			return nil, errors.New("can't detect the shell as $SHELL is not set, give it as an argument")
+		}
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	data := os.Getenv("XDG_DATA_HOME")
+	if data == "" {
+		data = filepath.Join(home, ".local", "share")
+	}
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		config = filepath.Join(home, ".config")
+	}
+	name := k.Model.Name
+	install := &completionInstall{name: name, shell: shell}
+	switch shell {
+	case "bash":
+		install.script = filepath.Join(data, "bash-completion", "completions", name)
+	case "zsh":
+		install.script = filepath.Join(data, "zsh", "completions", "_"+name)
+		rcDir := os.Getenv("ZDOTDIR")
+		if rcDir == "" {
+			rcDir = home
+		}
+		install.rc = filepath.Join(rcDir, ".zshrc")
+	case "fish":
+		install.script = filepath.Join(config, "fish", "completions", name+".fish")
+	default:
+		return nil, errors.Errorf("can't install completion for shell %q, only bash, zsh and fish are supported", shell)
+	}
+	return install, nil
+}
+
+// Replace the block of the rc file that sources the completion script with one containing line, or remove
+// the block if line is empty.
+func (c *completionInstall) updateRC(line string) error {
+	data, err := ioutil.ReadFile(c.rc)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	begin := "# BEGIN " + c.name + " completion"
+	end := "# END " + c.name + " completion"
+	content := string(data)
+	if start := strings.Index(content, begin+"\n"); start >= 0 {
+		if stop := strings.Index(content[start:], end+"\n"); stop >= 0 {
+			content = content[:start] + content[start+stop+len(end)+1:]
+		}
+	}
+	if line != "" {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += begin + "\n" + line + "\n" + end + "\n"
+	}
+	if content == string(data) {
+		return nil
+	}
+	return ioutil.WriteFile(c.rc, []byte(content), 0600)
+}
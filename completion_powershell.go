@@ -0,0 +1,160 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Write a PowerShell completion script for app, registered with Register-ArgumentCompleter.
+//
+// The script embeds tables of the commands, flags and enum values of each command path, and tracks the
+// command path of the command-line being completed to choose between them.
+func writePowerShellCompletion(w io.Writer, app *Application) error {
+	p := &powerShellWriter{w: &strings.Builder{}}
+	p.printf("Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", powerShellQuote(app.Name))
+	p.printf("    param($wordToComplete, $commandAst, $cursorPosition)\n\n")
+	for _, table := range []string{"commands", "flags", "arguments", "values"} {
+		p.printf("    $%s = New-Object System.Collections.Hashtable ([StringComparer]::Ordinal)\n", table)
+	}
+	p.node(app.Node, "")
+	p.printf(`
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.EndOffset -lt $cursorPosition } |
+        ForEach-Object { $_.ToString() })
+    $path = ''
+    foreach ($word in ($words | Select-Object -Skip 1)) {
+        if ($word.StartsWith('-')) {
+            continue
+        } elseif ($commands.ContainsKey("$path/$word")) {
+            $path = $commands["$path/$word"]
+        } elseif ($commands.ContainsKey("$path/*")) {
+            $path = $commands["$path/*"]
+        }
+    }
+
+    $prefix = ''
+    $word = $wordToComplete
+    $type = 'ParameterValue'
+    if ($wordToComplete -match '^(--[^=]+)=(.*)$') {
+        $prefix = $Matches[1] + '='
+        $word = $Matches[2]
+        $candidates = $values["$path|$($Matches[1])"]
+    } elseif ($words.Count -gt 1 -and -not $wordToComplete.StartsWith('-') -and $values.ContainsKey("$path|$($words[-1])")) {
+        $candidates = $values["$path|$($words[-1])"]
+    } elseif ($wordToComplete.StartsWith('-')) {
+        $candidates = $flags[$path]
+        $type = 'ParameterName'
+    } else {
+        $candidates = $arguments[$path]
+    }
+
+    foreach ($candidate in $candidates) {
+        $text = $candidate[0]
+        if ($text.StartsWith($word, [StringComparison]::Ordinal)) {
+            $tooltip = if ($candidate[1]) { $candidate[1] } else { $text }
+            [System.Management.Automation.CompletionResult]::new($prefix + $text, $text, $type, $tooltip)
+        }
+    }
+}
+`)
+	_, err := io.WriteString(w, p.w.String())
+	return err
+}
+
+type powerShellWriter struct {
+	w *strings.Builder
+}
+
+func (p *powerShellWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(p.w, format, args...)
+}
+
+func (p *powerShellWriter) node(node *Node, path string) {
+	key := powerShellQuote(path)
+	p.printf("\n")
+	for _, child := range completionChildren(node) {
+		childPath := powerShellQuote(strings.TrimSpace(path + " " + completionPathElement(child)))
+		if child.Type == ArgumentNode {
+			p.printf("    $commands[%s] = %s\n", powerShellQuote(path+"/*"), childPath)
+			continue
+		}
+		for _, name := range append([]string{child.Name}, child.Aliases...) {
+			p.printf("    $commands[%s] = %s\n", powerShellQuote(path+"/"+name), childPath)
+		}
+	}
+
+	flags := [][2]string{}
+	for _, group := range node.AllFlags(true) {
+		for _, flag := range group {
+			help := completionHelp(flag.Help)
+			names := []string{}
+			if !flag.Tag.ShortOnly {
+				names = append(names, "--"+flag.Name)
+			}
+			if flag.Tag.Negatable {
+				names = append(names, "--no-"+flag.Name)
+			}
+			if short := flag.shortName(); short != "" {
+				names = append(names, "-"+short)
+			}
+			for _, name := range names {
+				flags = append(flags, [2]string{name, help})
+				if flag.Enum != "" && !flag.IsMap() && !strings.HasPrefix(name, "--no-") {
+					p.printf("    $values[%s] = %s\n", powerShellQuote(path+"|"+name), powerShellCandidates(enumCandidates(flag.Value)))
+				}
+			}
+		}
+	}
+	p.printf("    $flags[%s] = %s\n", key, powerShellCandidates(flags))
+
+	arguments := [][2]string{}
+	for _, child := range completionChildren(node) {
+		if child.Type == ArgumentNode {
+			arguments = append(arguments, enumCandidates(child.Argument)...)
+			continue
+		}
+		for _, name := range append([]string{child.Name}, child.Aliases...) {
+			arguments = append(arguments, [2]string{name, completionHelp(child.Help)})
+		}
+	}
+	for _, positional := range node.Positional {
+		arguments = append(arguments, enumCandidates(positional)...)
+	}
+	p.printf("    $arguments[%s] = %s\n", key, powerShellCandidates(arguments))
+
+	for _, child := range completionChildren(node) {
+		p.node(child, strings.TrimSpace(path+" "+completionPathElement(child)))
+	}
+}
+
+// The enum values of value as completion candidates without help.
+func enumCandidates(value *Value) [][2]string {
+	candidates := [][2]string{}
+	if value.Enum == "" || value.IsMap() {
+		return candidates
+	}
+	for _, enum := range strings.Split(value.Enum, ",") {
+		candidates = append(candidates, [2]string{strings.TrimSpace(enum), ""})
+	}
+	return candidates
+}
+
+// An array of (text, tooltip) arrays, one per line.
+func powerShellCandidates(candidates [][2]string) string {
+	if len(candidates) == 0 {
+		return "@()"
+	}
+	lines := []string{"@("}
+	for _, candidate := range candidates {
+		// The unary comma stops PowerShell from flattening each pair into the outer array.
+		lines = append(lines, fmt.Sprintf("        ,@(%s, %s)", powerShellQuote(candidate[0]), powerShellQuote(candidate[1])))
+	}
+	lines = append(lines, "    )")
+	return strings.Join(lines, "\n")
+}
+
+// Single quote s for PowerShell, which also treats typographic single quotes as quotes.
+func powerShellQuote(s string) string {
+	return "'" + strings.NewReplacer("'", "''", "\u2018", "\u2018\u2018", "\u2019", "\u2019\u2019").Replace(s) + "'"
+}
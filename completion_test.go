@@ -0,0 +1,617 @@
+package kong_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type branchName string
+
+func (b *branchName) Predict(args kong.CompletionArgs) []string {
+	return []string{"main", "master", "feature"}
+}
+
+type completionCLI struct {
+	Debug bool   `short:"d" help:"Enable debug mode."`
+	Level string `enum:"debug,info,warn" default:"info"`
+
+	Checkout struct {
+		Branch branchName `arg:""`
+		Remote string     `enum:"origin,upstream" default:"origin"`
+	} `cmd:"" help:"Switch branches."`
+
+	Commit struct {
+		Message string `short:"m" help:"Use the given message as the commit message, rather than opening an editor to write one."`
+	} `cmd:"" help:"Record changes: to the repository."`
+
+	Internal struct{} `cmd:"" hidden:""`
+}
+
+func completionValues(completions []kong.Completion) []string {
+	values := []string{}
+	for _, completion := range completions {
+		values = append(values, completion.Value)
+	}
+	return values
+}
+
+func TestComplete(t *testing.T) {
+	var cli completionCLI
+	p := mustNew(t, &cli)
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{""}, []string{"checkout", "commit"}},
+		{[]string{"ch"}, []string{"checkout"}},
+		{[]string{"--"}, []string{"--help", "--debug", "--level"}},
+		{[]string{"-"}, []string{"--help", "-h", "--debug", "-d", "--level"}},
+		{[]string{"--level="}, []string{"--level=debug", "--level=info", "--level=warn"}},
+		{[]string{"--level", "w"}, []string{"warn"}},
+		{[]string{"-d", "checkout", "--remote=u"}, []string{"--remote=upstream"}},
+		{[]string{"checkout", "ma"}, []string{"main", "master"}},
+		{[]string{"checkout", "main", ""}, []string{}},
+		{[]string{"commit", "--"}, []string{"--help", "--debug", "--level", "--message"}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, completionValues(p.Complete(test.args)), "%q", test.args)
+	}
+}
+
+func TestCompleteHidden(t *testing.T) {
+	var cli struct {
+		completionCLI
+		Trace  bool `hidden:""`
+		Secret bool `internal:""`
+	}
+	p := mustNew(t, &cli, kong.HelpHidden())
+	require.Equal(t, []string{"checkout", "commit", "internal"}, completionValues(p.Complete([]string{""})))
+	require.Equal(t, []string{"--help", "--help-hidden", "--debug", "--level", "--trace"}, completionValues(p.Complete([]string{"--"})))
+}
+
+func TestCompleteEnumCollections(t *testing.T) {
+	var cli struct {
+		Tags   []string          `enum:"red,green,blue" default:"red"`
+		Levels map[string]string `enum:"debug,info" default:"app=info"`
+	}
+	p := mustNew(t, &cli)
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"--tags="}, []string{"--tags=red", "--tags=green", "--tags=blue"}},
+		{[]string{"--tags=red,g"}, []string{"--tags=red,green"}},
+		{[]string{"--levels", "db"}, []string{}},
+		{[]string{"--levels", "db="}, []string{"db=debug", "db=info"}},
+		{[]string{"--levels=db=debug;web=i"}, []string{"--levels=db=debug;web=info"}},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, completionValues(p.Complete(test.args)), "%q", test.args)
+	}
+}
+
+func TestNamedPredictor(t *testing.T) {
+	var cli struct {
+		Branch string   `predictor:"branches"`
+		Files  []string `arg:"" optional:"" predictor:"branches"`
+	}
+	var completed kong.CompletionArgs
+	branches := kong.PredictorFunc(func(args kong.CompletionArgs) []string {
+		completed = args
+		return []string{"main", "master", "feature"}
+	})
+	p := mustNew(t, &cli, kong.NamedPredictor("branches", branches))
+	require.Equal(t, []string{"--branch=main", "--branch=master"}, completionValues(p.Complete([]string{"--branch=ma"})))
+	require.Equal(t, kong.CompletionArgs{Completed: []string{}, Last: "ma"}, completed)
+	require.Equal(t, []string{"feature"}, completionValues(p.Complete([]string{"a", "f"})))
+
+	_, err := kong.New(&cli)
+	require.EqualError(t, err, `unknown predictor "branches", use kong.NamedPredictor("branches", predictor)`)
+}
+
+func TestTypePredictor(t *testing.T) {
+	var cli struct {
+		Timeout time.Duration
+	}
+	durations := kong.PredictorFunc(func(args kong.CompletionArgs) []string { return []string{"1s", "1m", "1h"} })
+	p := mustNew(t, &cli, kong.TypePredictor(reflect.TypeOf(time.Duration(0)), durations))
+	require.Equal(t, []string{"1s", "1m", "1h"}, completionValues(p.Complete([]string{"--timeout", ""})))
+}
+
+func TestCompletionHistory(t *testing.T) {
+	history := filepath.Join(t.TempDir(), "app", "history.json")
+	var cli struct {
+		Level  string `enum:"debug,info,warn" default:"info" history:""`
+		Token  string `history:"" redact:""`
+		Secret string `history:"" hidden:""`
+		Region string `history:"" env:"TEST_REGION"`
+		Name   string
+
+		Commit struct {
+			Message string `short:"m" history:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli, kong.CompletionHistory(history),
+		kong.Resolvers(kong.ResolverFunc(func(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+			if flag.Name == "message" {
+				return "resolved", nil
+			}
+			return nil, nil
+		})))
+	for _, message := range []string{"first", "second", "first"} {
+		_, err := p.Parse([]string{"commit", "-m", message})
+		require.NoError(t, err)
+	}
+	_, err := p.Parse([]string{"--level=warn", "commit"})
+	require.NoError(t, err)
+	t.Setenv("TEST_REGION", "eu")
+	_, err = p.Parse([]string{"--token=hunter2", "--secret=s3cret", "--name=bob", "commit"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"first", "second"}, completionValues(p.Complete([]string{"commit", "--message", ""})))
+	// History is merged with, and deduplicated against, enum values.
+	require.Equal(t, []string{"--level=debug", "--level=info", "--level=warn"}, completionValues(p.Complete([]string{"--level="})))
+	data, err := ioutil.ReadFile(history)
+	require.NoError(t, err)
+	for _, unrecorded := range []string{"resolved", "hunter2", "s3cret", "bob", "eu"} {
+		require.NotContains(t, string(data), unrecorded)
+	}
+}
+
+func TestWriteCompletions(t *testing.T) {
+	var cli completionCLI
+	p := mustNew(t, &cli)
+	commands := p.Complete([]string{""})
+	flags := p.Complete([]string{"commit", "--m"})
+
+	w := &bytes.Buffer{}
+	require.NoError(t, kong.WriteCompletions(w, "zsh", commands))
+	require.NoError(t, kong.WriteCompletions(w, "zsh", flags))
+	require.Equal(t, `checkout:Switch branches.
+commit:Record changes: to the repository.
+--message:Use the given message as the commit message, rather than...
+`, w.String())
+
+	w.Reset()
+	require.NoError(t, kong.WriteCompletions(w, "fish", commands))
+	require.Equal(t, "checkout\tSwitch branches.\ncommit\tRecord changes: to the repository.\n", w.String())
+
+	w.Reset()
+	require.NoError(t, kong.WriteCompletions(w, "bash", commands))
+	require.Equal(t, "checkout\ncommit\n", w.String())
+
+	require.Error(t, kong.WriteCompletions(w, "tcsh", commands))
+}
+
+func TestCompletionProtocol(t *testing.T) {
+	var cli completionCLI
+	w := &bytes.Buffer{}
+	p := mustNew(t, &cli, kong.CompletionProtocol(), kong.NoExit(), kong.Writers(w, w))
+	complete := func(args ...string) string {
+		w.Reset()
+		_, err := p.Parse(append([]string{"__complete"}, args...))
+		require.True(t, errors.Is(err, kong.ErrHelpRequested), "%v", err)
+		return w.String()
+	}
+	require.Equal(t, "checkout\tSwitch branches.\ncommit\tRecord changes: to the repository.\n:4\n", complete(""))
+	require.Equal(t, "--level=debug\n--level=info\n--level=warn\n:4\n", complete("--level="))
+	require.Equal(t, "main\nmaster\n:4\n", complete("checkout", "ma"))
+	require.Equal(t, ":0\n", complete("checkout", "main", ""))
+	require.Equal(t, ":1\n", complete("nope", ""))
+
+	// Without the option, __complete is an ordinary argument.
+	p = mustNew(t, &cli, kong.NoExit(), kong.Writers(w, w))
+	_, err := p.Parse([]string{"__complete", ""})
+	require.Error(t, err)
+	require.False(t, errors.Is(err, kong.ErrHelpRequested))
+}
+
+func TestWriteZshCompletionScript(t *testing.T) {
+	var cli struct {
+		Verbose int      `short:"v" type:"counter" help:"Increase verbosity."`
+		Color   bool     `negatable:"" help:"Colourise [output]."`
+		Include []string `short:"I" type:"existingdir" help:"Directories to search."`
+
+		Build struct {
+			Target string   `arg:"" enum:"debug,release" help:"Build target."`
+			Files  []string `arg:"" optional:"" type:"path"`
+		} `cmd:"" aliases:"b" help:"Build the project's sources."`
+	}
+	p := mustNew(t, &cli)
+	w := &bytes.Buffer{}
+	err := p.WriteCompletionScript(w, "zsh")
+	require.NoError(t, err)
+	require.Equal(t, `#compdef test
+
+_test() {
+  local context state state_descr line
+  typeset -A opt_args
+  _arguments -s -S -C \
+    '(-h --help)'{-h,--help}'[Show context-sensitive help.]' \
+    '*'{-v,--verbose}'[Increase verbosity.]' \
+    '--color[Colourise \[output\].]' \
+    '--no-color[Colourise \[output\].]' \
+    '*'{-I+,--include=}'[Directories to search.]:include:_files -/' \
+    '1: :->cmds' \
+    '*:: :->args'
+  case $state in
+  cmds)
+    local -a commands
+    commands=(
+      'build:Build the project'\''s sources.'
+      'b:Build the project'\''s sources.'
+    )
+    _describe -t commands 'command' commands
+    ;;
+  args)
+    case $words[1] in
+    'build'|'b')
+      _test_build
+      ;;
+    esac
+    ;;
+  esac
+}
+
+_test_build() {
+  local context state state_descr line
+  typeset -A opt_args
+  _arguments -s -S -C \
+    '(-h --help)'{-h,--help}'[Show context-sensitive help.]' \
+    '*'{-v,--verbose}'[Increase verbosity.]' \
+    '--color[Colourise \[output\].]' \
+    '--no-color[Colourise \[output\].]' \
+    '*'{-I+,--include=}'[Directories to search.]:include:_files -/' \
+    '1:target:(debug release)' \
+    '*:files:_files'
+}
+
+if [ "$funcstack[1]" = "_test" ]; then
+  _test "$@"
+else
+  compdef _test 'test'
+fi
+`, w.String())
+
+	err = p.WriteCompletionScript(w, "tcsh")
+	require.EqualError(t, err, `unsupported shell "tcsh"`)
+}
+
+func TestWriteFishCompletionScript(t *testing.T) {
+	var cli struct {
+		Debug bool `short:"d" help:"Enable debug mode."`
+
+		Build struct {
+			Target string   `enum:"debug,release" default:"debug" help:"Build target."`
+			Output string   `type:"path" help:"Don't write to stdout."`
+			Files  []string `arg:"" optional:"" type:"path"`
+		} `cmd:"" aliases:"b" help:"Build the project."`
+	}
+	w := &bytes.Buffer{}
+	p := mustNew(t, &cli,
+		kong.CompletionScriptFlag(),
+		kong.Writers(w, w),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.PanicsWithValue(t, true, func() {
+		_, err := p.Parse([]string{"--completion-script=fish"})
+		require.NoError(t, err)
+	})
+	require.Equal(t, `# fish completion for test
+
+function __fish_test_command_path
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l path
+    for word in $words
+        switch "$path/$word"
+            case '*/-*'
+            case '/build' '/b'
+                set path 'build'
+        end
+    end
+    echo $path
+end
+
+function __fish_test_using_command
+    set -l path (__fish_test_command_path)
+    test "$path" = "$argv"
+end
+
+function __fish_test_seen_command
+    set -l path (__fish_test_command_path)
+    test "$path" = "$argv"; or string match -q -- "$argv *" "$path"
+end
+
+complete -c 'test' -f
+
+complete -c 'test' -s 'h' -l 'help' -d 'Show context-sensitive help.'
+complete -c 'test' -s 'd' -l 'debug' -d 'Enable debug mode.'
+complete -c 'test' -n '__fish_test_using_command \'\'' -a 'build' -d 'Build the project.'
+complete -c 'test' -n '__fish_test_using_command \'\'' -a 'b' -d 'Build the project.'
+
+complete -c 'test' -n '__fish_test_seen_command \'build\'' -l 'target' -x -a 'debug release' -d 'Build target.'
+complete -c 'test' -n '__fish_test_seen_command \'build\'' -l 'output' -r -F -d 'Don\'t write to stdout.'
+complete -c 'test' -n '__fish_test_using_command \'build\'' -F
+`, w.String())
+}
+
+func TestWritePowerShellCompletionScript(t *testing.T) {
+	var cli struct {
+		Level string `short:"l" enum:"debug,info" default:"info" help:"Log level."`
+
+		Build struct {
+			Mode string `arg:"" enum:"debug,release"`
+		} `cmd:"" aliases:"b" help:"Build the project's sources."`
+	}
+	p := mustNew(t, &cli)
+	w := &bytes.Buffer{}
+	err := p.WriteCompletionScript(w, "powershell")
+	require.NoError(t, err)
+	require.Equal(t, `Register-ArgumentCompleter -Native -CommandName 'test' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+
+    $commands = New-Object System.Collections.Hashtable ([StringComparer]::Ordinal)
+    $flags = New-Object System.Collections.Hashtable ([StringComparer]::Ordinal)
+    $arguments = New-Object System.Collections.Hashtable ([StringComparer]::Ordinal)
+    $values = New-Object System.Collections.Hashtable ([StringComparer]::Ordinal)
+
+    $commands['/build'] = 'build'
+    $commands['/b'] = 'build'
+    $values['|--level'] = @(
+        ,@('debug', '')
+        ,@('info', '')
+    )
+    $values['|-l'] = @(
+        ,@('debug', '')
+        ,@('info', '')
+    )
+    $flags[''] = @(
+        ,@('--help', 'Show context-sensitive help.')
+        ,@('-h', 'Show context-sensitive help.')
+        ,@('--level', 'Log level.')
+        ,@('-l', 'Log level.')
+    )
+    $arguments[''] = @(
+        ,@('build', 'Build the project''s sources.')
+        ,@('b', 'Build the project''s sources.')
+    )
+
+    $values['build|--level'] = @(
+        ,@('debug', '')
+        ,@('info', '')
+    )
+    $values['build|-l'] = @(
+        ,@('debug', '')
+        ,@('info', '')
+    )
+    $flags['build'] = @(
+        ,@('--help', 'Show context-sensitive help.')
+        ,@('-h', 'Show context-sensitive help.')
+        ,@('--level', 'Log level.')
+        ,@('-l', 'Log level.')
+    )
+    $arguments['build'] = @(
+        ,@('debug', '')
+        ,@('release', '')
+    )
+
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.EndOffset -lt $cursorPosition } |
+        ForEach-Object { $_.ToString() })
+    $path = ''
+    foreach ($word in ($words | Select-Object -Skip 1)) {
+        if ($word.StartsWith('-')) {
+            continue
+        } elseif ($commands.ContainsKey("$path/$word")) {
+            $path = $commands["$path/$word"]
+        } elseif ($commands.ContainsKey("$path/*")) {
+            $path = $commands["$path/*"]
+        }
+    }
+
+    $prefix = ''
+    $word = $wordToComplete
+    $type = 'ParameterValue'
+    if ($wordToComplete -match '^(--[^=]+)=(.*)$') {
+        $prefix = $Matches[1] + '='
+        $word = $Matches[2]
+        $candidates = $values["$path|$($Matches[1])"]
+    } elseif ($words.Count -gt 1 -and -not $wordToComplete.StartsWith('-') -and $values.ContainsKey("$path|$($words[-1])")) {
+        $candidates = $values["$path|$($words[-1])"]
+    } elseif ($wordToComplete.StartsWith('-')) {
+        $candidates = $flags[$path]
+        $type = 'ParameterName'
+    } else {
+        $candidates = $arguments[$path]
+    }
+
+    foreach ($candidate in $candidates) {
+        $text = $candidate[0]
+        if ($text.StartsWith($word, [StringComparison]::Ordinal)) {
+            $tooltip = if ($candidate[1]) { $candidate[1] } else { $text }
+            [System.Management.Automation.CompletionResult]::new($prefix + $text, $text, $type, $tooltip)
+        }
+    }
+}
+`, w.String())
+}
+
+func TestWriteBashCompletionScript(t *testing.T) {
+	var cli completionCLI
+	w := &bytes.Buffer{}
+	err := mustNew(t, &cli, kong.CompletionProtocol()).WriteCompletionScript(w, "bash")
+	require.NoError(t, err)
+	require.Contains(t, w.String(), "# bash completion for test\n\n_test() {\n")
+	require.Contains(t, w.String(), `out=$("${words[0]}" __complete "${words[@]:1}" 2>/dev/null) || return`)
+	require.Contains(t, w.String(), "    if (( directive & 1 )); then\n")
+	require.True(t, strings.HasSuffix(w.String(), "\ncomplete -F _test test\n"))
+
+	err = mustNew(t, &cli).WriteCompletionScript(w, "bash")
+	require.EqualError(t, err, "bash completion requires the kong.CompletionProtocol() option")
+}
+
+func TestCompleteDirectories(t *testing.T) {
+	var cli struct {
+		Dir  string `type:"existingdir"`
+		File string `type:"existingfile"`
+	}
+	p := mustNew(t, &cli, kong.CompletionProtocol(), kong.NoExit(), kong.Writers(&bytes.Buffer{}, &bytes.Buffer{}))
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--dir", ""}, ":16\n"},
+		{[]string{"--file", ""}, ":0\n"},
+	} {
+		w := &bytes.Buffer{}
+		p.Stdout = w
+		_, err := p.Parse(append([]string{"__complete"}, test.args...))
+		require.True(t, errors.Is(err, kong.ErrHelpRequested), "%v", err)
+		require.Equal(t, test.expected, w.String(), "%q", test.args)
+	}
+}
+
+func TestCompletionCommands(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("SHELL", "/bin/zsh")
+	rc := filepath.Join(home, ".zshrc")
+	require.NoError(t, ioutil.WriteFile(rc, []byte("export PATH=/opt/bin:$PATH"), 0600))
+	script := filepath.Join(home, ".local", "share", "zsh", "completions", "_test")
+	run := func(args ...string) string {
+		t.Helper()
+		var cli completionCLI
+		w := &bytes.Buffer{}
+		ctx, err := mustNew(t, &cli, kong.CompletionCommands(), kong.Writers(w, w)).Parse(args)
+		require.NoError(t, err)
+		require.NoError(t, ctx.Run())
+		return w.String()
+	}
+
+	require.Equal(t, "Installed zsh completion in "+script+", which takes effect in new shells.\n", run("completion", "install"))
+	run("completion", "install")
+	data, err := ioutil.ReadFile(script)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "#compdef test\n"))
+	data, err = ioutil.ReadFile(rc)
+	require.NoError(t, err)
+	require.Equal(t, "export PATH=/opt/bin:$PATH\n"+
+		"# BEGIN test completion\n"+
+		"[ -f \""+script+"\" ] && source \""+script+"\"\n"+
+		"# END test completion\n", string(data))
+
+	require.Equal(t, "Uninstalled zsh completion from "+script+".\n", run("completion", "uninstall"))
+	require.NoFileExists(t, script)
+	data, err = ioutil.ReadFile(rc)
+	require.NoError(t, err)
+	require.Equal(t, "export PATH=/opt/bin:$PATH\n", string(data))
+	require.Equal(t, "No zsh completion is installed.\n", run("completion", "uninstall"))
+
+	run("completion", "install", "fish")
+	require.FileExists(t, filepath.Join(home, ".config", "fish", "completions", "test.fish"))
+	run("completion", "install", "bash")
+	data, err = ioutil.ReadFile(filepath.Join(home, ".local", "share", "bash-completion", "completions", "test"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "complete -F _test test\n")
+
+	var cli completionCLI
+	ctx, err := mustNew(t, &cli, kong.CompletionCommands()).Parse([]string{"completion", "install", "tcsh"})
+	require.NoError(t, err)
+	require.EqualError(t, ctx.Run(), `can't install completion for shell "tcsh", only bash, zsh and fish are supported`)
+}
+
+func TestWriteFigSpec(t *testing.T) {
+	var cli struct {
+		Color bool `negatable:"" help:"Colorize output."`
+
+		Build struct {
+			Dir   string   `short:"C" type:"existingdir" placeholder:"DIR" help:"Change to DIR first."`
+			Files []string `arg:"" optional:"" type:"path" help:"Files to build."`
+		} `cmd:"" aliases:"b" help:"Build the project."`
+	}
+	w := &bytes.Buffer{}
+	require.NoError(t, mustNew(t, &cli).WriteFigSpec(w))
+	require.True(t, strings.HasPrefix(w.String(), "const completionSpec: Fig.Spec = {\n  \"name\": \"test\",\n"))
+	require.True(t, strings.HasSuffix(w.String(), "\n};\n\nexport default completionSpec;\n"))
+
+	var spec map[string]interface{}
+	body := strings.TrimSuffix(strings.TrimPrefix(w.String(), "const completionSpec: Fig.Spec = "), ";\n\nexport default completionSpec;\n")
+	require.NoError(t, json.Unmarshal([]byte(body), &spec))
+	require.Equal(t, map[string]interface{}{
+		"name":         "--color",
+		"description":  "Colorize output.",
+		"exclusiveOn":  []interface{}{"--no-color"},
+		"isPersistent": true,
+	}, spec["options"].([]interface{})[1])
+	require.Equal(t, map[string]interface{}{
+		"name":        []interface{}{"build", "b"},
+		"description": "Build the project.",
+		"options": []interface{}{
+			map[string]interface{}{
+				"name":        []interface{}{"--dir", "-C"},
+				"description": "Change to DIR first.",
+				"args":        map[string]interface{}{"name": "dir", "template": "folders"},
+			},
+		},
+		"args": []interface{}{
+			map[string]interface{}{
+				"name":        "files",
+				"description": "Files to build.",
+				"isOptional":  true,
+				"isVariadic":  true,
+				"template":    "filepaths",
+			},
+		},
+	}, spec["subcommands"].([]interface{})[0])
+}
+
+func TestWriteCarapaceSpec(t *testing.T) {
+	var cli struct {
+		Debug bool `short:"d" help:"Enable debug mode."`
+
+		Build struct {
+			Target string   `enum:"debug,release" default:"debug" help:"Build target."`
+			Output string   `short:"o" type:"path" required:"" help:"Output \"file\"."`
+			Define []string `short:"D" help:"Define a variable."`
+			Files  []string `arg:"" optional:"" type:"path"`
+		} `cmd:"" aliases:"b" help:"Build the project."`
+	}
+	w := &bytes.Buffer{}
+	require.NoError(t, mustNew(t, &cli).WriteCarapaceSpec(w))
+	require.Equal(t, `# yaml-language-server: $schema=https://carapace.sh/schemas/command.json
+name: "test"
+persistentflags:
+  "-h, --help": "Show context-sensitive help."
+  "-d, --debug": "Enable debug mode."
+commands:
+  - name: "build"
+    aliases: ["b"]
+    description: "Build the project."
+    flags:
+      "--target=": "Build target."
+      "-o, --output=!": "Output \"file\"."
+      "-D, --define=*": "Define a variable."
+    completion:
+      flag:
+        "target": ["debug", "release"]
+        "output": ["$files"]
+      positionalany: ["$files"]
+`, w.String())
+}
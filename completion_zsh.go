@@ -0,0 +1,257 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Write a zsh completion script for app, with a function per command built on _arguments.
+func writeZshCompletion(w io.Writer, app *Application) error {
+	z := &zshWriter{w: &strings.Builder{}}
+	name := zshFunctionName(app.Node)
+	z.printf("#compdef %s\n", app.Name)
+	z.node(app.Node)
+	z.printf("\nif [ \"$funcstack[1]\" = %q ]; then\n", name)
+	z.printf("  %s \"$@\"\n", name)
+	z.printf("else\n")
+	z.printf("  compdef %s %s\n", name, zshQuote(app.Name))
+	z.printf("fi\n")
+	_, err := io.WriteString(w, z.w.String())
+	return err
+}
+
+type zshWriter struct {
+	w *strings.Builder
+}
+
+func (z *zshWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(z.w, format, args...)
+}
+
+func (z *zshWriter) node(node *Node) {
+	commands := []*Node{}
+	var argument *Node
+	for _, child := range node.Children {
+		switch {
+		case child.Hidden:
+		case child.Type == ArgumentNode:
+			argument = child
+		default:
+			commands = append(commands, child)
+		}
+	}
+	specs := []string{}
+	for _, group := range node.AllFlags(true) {
+		for _, flag := range group {
+			specs = append(specs, zshFlagSpecs(flag)...)
+		}
+	}
+	for i, positional := range node.Positional {
+		specs = append(specs, zshPositionalSpec(i+1, positional))
+	}
+	if len(commands) > 0 || argument != nil {
+		specs = append(specs, zshQuote("1: :->cmds"), zshQuote("*:: :->args"))
+	}
+
+	z.printf("\n%s() {\n", zshFunctionName(node))
+	z.printf("  local context state state_descr line\n")
+	z.printf("  typeset -A opt_args\n")
+	z.printf("  _arguments -s -S -C")
+	for _, spec := range specs {
+		z.printf(" \\\n    %s", spec)
+	}
+	z.printf("\n")
+	if len(commands) > 0 || argument != nil {
+		z.printf("  case $state in\n")
+		z.printf("  cmds)\n")
+		if len(commands) > 0 {
+			z.printf("    local -a commands\n")
+			z.printf("    commands=(\n")
+			for _, command := range commands {
+				for _, name := range append([]string{command.Name}, command.Aliases...) {
+					z.printf("      %s\n", zshQuote(zshDescription(name, command.Help)))
+				}
+			}
+			z.printf("    )\n")
+			z.printf("    _describe -t commands 'command' commands\n")
+		}
+		if argument != nil {
+			z.printf("    _message %s\n", zshQuote(argument.Name))
+		}
+		z.printf("    ;;\n")
+		z.printf("  args)\n")
+		z.printf("    case $words[1] in\n")
+		for _, command := range commands {
+			z.printf("    %s)\n", strings.Join(append([]string{zshQuote(command.Name)}, zshQuoteAll(command.Aliases)...), "|"))
+			z.printf("      %s\n", zshFunctionName(command))
+			z.printf("      ;;\n")
+		}
+		if argument != nil {
+			z.printf("    *)\n")
+			z.printf("      %s\n", zshFunctionName(argument))
+			z.printf("      ;;\n")
+		}
+		z.printf("    esac\n")
+		z.printf("    ;;\n")
+		z.printf("  esac\n")
+	}
+	z.printf("}\n")
+	for _, command := range commands {
+		z.node(command)
+	}
+	if argument != nil {
+		z.node(argument)
+	}
+}
+
+// _arguments specs for flag, eg. '(-d --debug)'{-d,--debug}'[Enable debug mode.]'.
+func zshFlagSpecs(flag *Flag) []string {
+	help := ""
+	if summary := completionHelp(flag.Help); summary != "" {
+		help = "[" + zshEscapeHelp(summary) + "]"
+	}
+	value := ""
+	long, short := "--"+flag.Name, ""
+	if !flag.IsBool() && !flag.IsCounter() {
+		placeholder := flag.PlaceHolder
+		if placeholder == "" {
+			placeholder = flag.Name
+		}
+		value = ":" + zshEscapeMessage(strings.ToLower(placeholder)) + ":" + zshValueAction(flag.Value)
+		long += "="
+		if flag.shortName() != "" {
+			short = "-" + flag.shortName() + "+"
+		}
+	} else if flag.shortName() != "" {
+		short = "-" + flag.shortName()
+	}
+	repeatable := flag.IsCumulative() || flag.IsCounter()
+	exclusions := ""
+	switch {
+	case repeatable:
+		exclusions = "*"
+	case flag.shortName() != "":
+		exclusions = fmt.Sprintf("(-%s --%s)", flag.shortName(), flag.Name)
+	}
+	if flag.Tag.ShortOnly {
+		// _arguments doesn't repeat options without "*", so a single spelling needs no exclusions.
+		if !repeatable {
+			exclusions = ""
+		}
+		return []string{zshQuote(exclusions + short + help + value)}
+	}
+	var specs []string
+	if short != "" {
+		specs = append(specs, zshQuote(exclusions)+"{"+short+","+long+"}"+zshQuote(help+value))
+	} else {
+		specs = append(specs, zshQuote(exclusions+long+help+value))
+	}
+	if flag.Tag.Negatable {
+		specs = append(specs, zshQuote("--no-"+flag.Name+help))
+	}
+	return specs
+}
+
+// _arguments spec for the position'th positional argument, eg. '1:path:_files'.
+func zshPositionalSpec(position int, positional *Positional) string {
+	spec := fmt.Sprintf("%d:", position)
+	switch {
+	case positional.IsCumulative():
+		// "*::" would have another meaning, and cumulative arguments are always optional to zsh.
+		spec = "*:"
+	case !positional.Required:
+		spec += ":"
+	}
+	return zshQuote(spec + zshEscapeMessage(positional.Name) + ":" + zshValueAction(positional))
+}
+
+// The _arguments action that completes values, from an enum, or files for file and path types.
+func zshValueAction(value *Value) string {
+	if value.Enum != "" && !value.IsMap() {
+		enums := []string{}
+		for _, enum := range strings.Split(value.Enum, ",") {
+			enums = append(enums, zshEscapeValue(strings.TrimSpace(enum)))
+		}
+		return "(" + strings.Join(enums, " ") + ")"
+	}
+	switch completionFileKind(value) {
+	case "dir":
+		return "_files -/"
+	case "file":
+		return "_files"
+	}
+	return " "
+}
+
+// Whether values are completed as files ("file") or directories ("dir"), or neither ("").
+func completionFileKind(value *Value) string {
+	switch value.Tag.Type {
+	case "existingdir":
+		return "dir"
+	case "path", "existingfile", "linesfile", "csvfile":
+		return "file"
+	}
+	switch value.Target.Interface().(type) {
+	case *os.File, FileContentFlag, NamedFileContentFlag, ExistingFile, FilePath:
+		return "file"
+	case ExistingDir:
+		return "dir"
+	}
+	return ""
+}
+
+func zshFunctionName(node *Node) string {
+	parts := []string{}
+	for n := node; n != nil; n = n.Parent {
+		parts = append([]string{n.Name}, parts...)
+	}
+	name := "_" + strings.Join(parts, "_")
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// An entry for _describe, "value:description", with colons in value escaped.
+func zshDescription(value, help string) string {
+	value = strings.ReplaceAll(value, ":", `\:`)
+	if help = completionHelp(help); help != "" {
+		return value + ":" + help
+	}
+	return value
+}
+
+// Single quote s for zsh.
+func zshQuote(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func zshQuoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = zshQuote(value)
+	}
+	return quoted
+}
+
+// Escape characters that are special in the bracketed descriptions of _arguments specs.
+func zshEscapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// Escape characters that are special in the messages of _arguments specs.
+func zshEscapeMessage(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ":", `\:`).Replace(s)
+}
+
+// Escape characters that are special in the value lists of _arguments actions.
+func zshEscapeValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, " ", `\ `, "(", `\(`, ")", `\)`, ":", `\:`).Replace(s)
+}
@@ -2,9 +2,13 @@ package kong_test
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -53,3 +57,166 @@ func makeConfig(t *testing.T, config interface{}) (path string, cleanup func())
 	require.NoError(t, err)
 	return w.Name(), func() { os.Remove(w.Name()) }
 }
+
+func TestConfigSchemaFlag(t *testing.T) {
+	var cli struct {
+		Config   kong.ConfigFlag
+		LogLevel string `default:"info" help:"Log level."`
+		Server   struct {
+			Port int `default:"8080" help:"Port to listen on."`
+		} `cmd:""`
+	}
+	w := &strings.Builder{}
+	p := mustNew(t, &cli,
+		kong.Configuration(kong.JSON),
+		kong.Writers(w, w),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.PanicsWithValue(t, true, func() {
+		_, err := p.Parse([]string{"--config-schema"})
+		require.NoError(t, err)
+	})
+	expected := `KEY        TYPE    DEFAULT  HELP
+log_level  string  info     Log level.
+port       int     8080     Port to listen on.
+`
+	require.Equal(t, expected, w.String())
+
+	var conflict struct {
+		ConfigSchema string
+	}
+	_, err := kong.New(&conflict, kong.Configuration(kong.JSON))
+	require.NoError(t, err)
+}
+
+func TestDecryptingLoader(t *testing.T) {
+	var cli struct {
+		Token string `json:"token"`
+	}
+	cli.Token = "secret"
+	plain, cleanPlain := makeConfig(t, &cli)
+	defer cleanPlain()
+	data, err := ioutil.ReadFile(plain)
+	require.NoError(t, err)
+
+	// A toy "encryption" that reverses the file, marked with a header.
+	reverse := func(data []byte) []byte {
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[len(data)-1-i] = b
+		}
+		return out
+	}
+	w, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(w.Name())
+	_, err = w.Write(append([]byte("ENC:"), reverse(data)...))
+	require.NoError(t, err)
+	w.Close() // nolint: gosec
+
+	decryptor := kong.DecryptorFunc(func(data []byte) ([]byte, error) {
+		if !strings.HasPrefix(string(data), "ENC:") {
+			return data, nil
+		}
+		return reverse(data[4:]), nil
+	})
+
+	for _, path := range []string{plain, w.Name()} {
+		cli.Token = ""
+		p := mustNew(t, &cli, kong.Configuration(kong.DecryptingLoader(kong.JSON, decryptor), path))
+		_, err = p.Parse(nil)
+		require.NoError(t, err)
+		require.Equal(t, "secret", cli.Token)
+	}
+
+	failing := kong.DecryptorFunc(func(data []byte) ([]byte, error) { return nil, errors.New("no key") })
+	_, err = kong.New(&cli, kong.Configuration(kong.DecryptingLoader(kong.JSON, failing), plain))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to decrypt configuration: no key")
+}
+
+func TestConfigValueError(t *testing.T) {
+	var cli struct {
+		Replicas int
+		Hosts    []string
+		LogLevel string
+		Timeout  time.Duration
+		Small    int8
+	}
+	path, clean := makeConfig(t, map[string]interface{}{
+		"replicas": "three", "hosts": 3, "log_level": []string{"debug"}, "timeout": "5 minutes", "small": 300,
+	})
+	defer clean()
+
+	_, err := mustNew(t, &cli, kong.Configuration(kong.JSON, path), kong.AggregateErrors()).Parse(nil)
+	require.EqualError(t, err, `5 validation errors:
+  - config key "replicas" in `+path+`: expected a valid 64 bit int but got "three"
+  - config key "hosts" in `+path+`: expected []string but got number 3
+  - config key "log_level" in `+path+`: expected string but got list ["debug"]
+  - config key "timeout" in `+path+`: expected duration but got "5 minutes": time: unknown unit " minutes" in duration "5 minutes"
+  - config key "small" in `+path+`: expected a valid 8 bit int but got "300"`)
+
+	_, err = mustNew(t, &cli, kong.Configuration(kong.JSON, path)).Parse(nil)
+	var valueErr *kong.ConfigValueError
+	require.True(t, errors.As(err, &valueErr))
+	require.Equal(t, "replicas", valueErr.Key)
+	require.Equal(t, path, valueErr.File)
+	require.Equal(t, "three", valueErr.Value)
+	require.Error(t, valueErr.Err)
+}
+
+func TestConfigValueErrorFromResolver(t *testing.T) {
+	var cli struct {
+		Replicas int
+		Token    int `redact:""`
+	}
+	resolver := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name == "help" {
+			return nil, nil
+		}
+		return "s3cr3t", nil
+	})
+	_, err := mustNew(t, &cli, kong.Resolvers(resolver), kong.AggregateErrors()).Parse(nil)
+	require.EqualError(t, err, `2 validation errors:
+  - --replicas: expected a valid 64 bit int but got "s3cr3t"
+  - --token: expected a valid 64 bit int but got "[REDACTED]"`)
+}
+
+func TestConfigurationKeepsResolverType(t *testing.T) {
+	var cli struct {
+		Region   string
+		Replicas int
+	}
+	path, clean := makeConfig(t, map[string]interface{}{})
+	defer clean()
+	resolver := &batchResolver{
+		ResolverFunc: func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+			panic("Resolve() should not be called")
+		},
+		values: map[string]interface{}{"region": "eu-west-1", "replicas": 3},
+	}
+	loader := func(r io.Reader) (kong.Resolver, error) { return resolver, nil }
+	p := mustNew(t, &cli, kong.Configuration(loader, path),
+		kong.ResolverErrorHandler(func(ctx *kong.Context, r kong.Resolver, flag *kong.Flag, err error) error {
+			if _, ok := r.(*batchResolver); ok {
+				return nil
+			}
+			return err
+		}))
+	ctx, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"help", "config-schema", "region", "replicas"}}, resolver.batches)
+	require.Equal(t, "eu-west-1", cli.Region)
+	require.Equal(t, kong.Resolver(resolver), ctx.Source("region").Resolver)
+
+	// Failures of the loaded resolver reach the handler as that resolver.
+	resolver.err = errors.New("unavailable")
+	_, err = p.Parse(nil)
+	require.NoError(t, err)
+
+	resolver.values, resolver.err = map[string]interface{}{"replicas": "three"}, nil
+	_, err = p.Parse(nil)
+	require.EqualError(t, err, `config key "replicas" in `+path+`: expected a valid 64 bit int but got "three"`)
+}
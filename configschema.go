@@ -0,0 +1,48 @@
+package kong
+
+import (
+	"fmt"
+	"reflect"
+	"text/tabwriter"
+)
+
+func (k *Kong) configSchemaFlag() *Flag {
+	var target configSchemaValue
+	value := reflect.ValueOf(&target).Elem()
+	flag := &Flag{
+		Hidden: true,
+		Value: &Value{
+			Name:         "config-schema",
+			Help:         "Show the configuration file key for every flag.",
+			Target:       value,
+			Tag:          newEmptyTag(),
+			Mapper:       k.registry.ForValue(value),
+			DefaultValue: reflect.ValueOf(false),
+		},
+	}
+	flag.Flag = flag
+	return flag
+}
+
+type configSchemaValue bool
+
+// BeforeApply prints the configuration key, type and default of every flag and terminates with a 0
+// exit status.
+func (c configSchemaValue) BeforeApply(app *Kong) error {
+	w := tabwriter.NewWriter(app.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tTYPE\tDEFAULT\tHELP")
+	_ = Visit(app.Model, func(node Visitable, next Next) error {
+		if flag, ok := node.(*Flag); ok && !isActionFlag(flag) && !flag.Tag.Internal {
+			typ := flag.Target.Type().String()
+			if flag.Unit != "" {
+				typ += " (" + flag.Unit + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", configKey(flag), typ, flag.Default, flag.Help)
+		}
+		return next(nil)
+	})
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return app.exitOr(ErrHelpRequested)
+}
@@ -1,12 +1,16 @@
 package kong
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -27,6 +31,11 @@ type Path struct {
 
 	// True if this Path element was created as the result of a resolver.
 	Resolved bool
+	// The Resolver that provided the value, if Resolved is true.
+	Resolver Resolver
+
+	// Values parsed by this invocation of a repeated command, if it was followed by another.
+	invocation map[*Value]reflect.Value
 }
 
 // Node returns the Node associated with this Path, or nil if Path is a non-Node.
@@ -72,13 +81,26 @@ type Context struct {
 	Path []*Path
 	// Original command-line arguments.
 	Args []string
+	// Command-line arguments after any expansion, such as of aliases. Identical to Args if nothing was
+	// expanded.
+	ExpandedArgs []string
 	// Error that occurred during trace, if any.
 	Error error
+	// Set if help was printed while parsing, eg. by --help, so that wrappers can redirect or post-process it.
+	HelpPrinted *HelpOutcome
+	// Value returned by Run(), from the most specific Run() method with the signature "(T, error)".
+	Result interface{}
 
 	values    map[*Value]reflect.Value // Temporary values during tracing.
 	bindings  bindings
-	resolvers []Resolver // Extra context-specific resolvers.
+	resolvers []Resolver             // Extra context-specific resolvers.
+	sources   map[*Value]ValueSource // Sources of values applied by Reset().
 	scan      *Scanner
+
+	// Targets of repeated commands before their invocations were applied.
+	repeatBaselines map[*Node]reflect.Value
+	// Flag values of the active context, loaded once per parse. See Contexts().
+	activeContext map[string]string
 }
 
 // Trace path of "args" through the grammar tree.
@@ -88,15 +110,22 @@ type Context struct {
 // This just constructs a new trace. To fully apply the trace you must call Reset(), Resolve(),
 // Validate() and Apply().
 func Trace(k *Kong, args []string) (*Context, error) {
+	expanded, err := k.expandUserAliases(args)
+	if err != nil {
+		return nil, err
+	}
 	c := &Context{
-		Kong: k,
-		Args: args,
+		Kong:         k,
+		Args:         args,
+		ExpandedArgs: expanded,
 		Path: []*Path{
 			{App: k.Model, Flags: k.Model.Flags},
 		},
 		values:   map[*Value]reflect.Value{},
-		scan:     Scan(args...),
+		scan:     Scan(expanded...),
 		bindings: bindings{},
+
+		repeatBaselines: map[*Node]reflect.Value{},
 	}
 	c.Error = c.trace(c.Model.Node)
 	return c, nil
@@ -163,13 +192,27 @@ func (c *Context) Empty() bool {
 
 // Validate the current context.
 func (c *Context) Validate() error { // nolint: gocyclo
+	// With AggregateErrors(), failures are collected rather than returned immediately.
+	errs := ValidationErrors{}
+	fail := func(err error) error {
+		if !c.aggregateErrors {
+			return err
+		}
+		errs = append(errs, err)
+		return nil
+	}
+	// Values that have already failed enum checks, so that they are not reported twice.
+	enumFailed := map[*Value]bool{}
 	err := Visit(c.Model, func(node Visitable, next Next) error {
 		switch node := node.(type) {
 		case *Value:
 			_, ok := os.LookupEnv(node.Tag.Env)
-			if node.Enum != "" && (!node.Required || node.Default != "" || (node.Tag.Env != "" && ok)) {
+			if node.Enum != "" && !enumFailed[node] && (!node.Required || node.Default != "" || (node.Tag.Env != "" && ok)) {
 				if err := checkEnum(node, node.Target); err != nil {
-					return err
+					enumFailed[node] = true
+					if err := fail(err); err != nil {
+						return err
+					}
 				}
 			}
 
@@ -177,7 +220,10 @@ func (c *Context) Validate() error { // nolint: gocyclo
 			_, ok := os.LookupEnv(node.Tag.Env)
 			if node.Enum != "" && (!node.Required || node.Default != "" || (node.Tag.Env != "" && ok)) {
 				if err := checkEnum(node.Value, node.Target); err != nil {
-					return err
+					enumFailed[node.Value] = true
+					if err := fail(err); err != nil {
+						return err
+					}
 				}
 			}
 		}
@@ -187,37 +233,40 @@ func (c *Context) Validate() error { // nolint: gocyclo
 		return err
 	}
 	for _, el := range c.Path {
-		var (
-			value reflect.Value
-			desc  string
-		)
+		var value reflect.Value
+		// The description is only computed on failure, as the Path may be very long.
+		var desc func() string
 		switch node := el.Visitable().(type) {
 		case *Value:
 			value = node.Target
-			desc = node.ShortSummary()
+			desc = node.ShortSummary
 
 		case *Flag:
 			value = node.Target
-			desc = node.ShortSummary()
+			desc = node.ShortSummary
 
 		case *Application:
 			value = node.Target
-			desc = node.Name
+			desc = func() string { return node.Name }
 
 		case *Node:
 			value = node.Target
-			desc = node.Path()
+			desc = node.Path
 		}
 		if validate := isValidatable(value); validate != nil {
 			err := validate.Validate()
 			if err != nil {
-				return errors.Wrap(err, desc)
+				if err := fail(errors.Wrap(err, desc())); err != nil {
+					return err
+				}
 			}
 		}
 	}
-	for _, resolver := range c.combineResolvers() {
+	for _, resolver := range append(c.combineResolvers(), c.Kong.sortedNamedResolvers()...) {
 		if err := resolver.Validate(c.Model); err != nil {
-			return err
+			if err := fail(err); err != nil {
+				return err
+			}
 		}
 	}
 	for _, path := range c.Path {
@@ -229,13 +278,26 @@ func (c *Context) Validate() error { // nolint: gocyclo
 		case path.Positional != nil:
 			value = path.Positional
 		}
-		if value != nil && value.Tag.Enum != "" {
+		if value != nil && value.Tag.Enum != "" && !enumFailed[value] {
 			if err := checkEnum(value, value.Target); err != nil {
+				if err := fail(err); err != nil {
+					return err
+				}
+			}
+		}
+		if err := checkMissingFlags(path.Flags, c.Kong.envOnly); err != nil {
+			if err := fail(err); err != nil {
 				return err
 			}
 		}
-		if err := checkMissingFlags(path.Flags); err != nil {
-			return err
+	}
+	for _, path := range c.Path {
+		if node := path.Node(); node != nil {
+			for _, err := range checkExpressions(node) {
+				if err := fail(err); err != nil {
+					return err
+				}
+			}
 		}
 	}
 	// Check the terminal node.
@@ -252,23 +314,52 @@ func (c *Context) Validate() error { // nolint: gocyclo
 		}
 	}
 
-	if err := checkMissingChildren(node); err != nil {
-		return err
+	checks := []func() error{
+		func() error {
+			if err := checkMissingChildren(node); err != nil {
+				return err
+			}
+			return checkMissingPositionals(positionals, node.Positional)
+		},
+		func() error { return checkXorDuplicates(c.Path) },
+		func() error { return checkAndMissing(c.Path) },
+		func() error {
+			if node.Type == ArgumentNode {
+				value := node.Argument
+				if value.Required && !value.Set {
+					return fmt.Errorf("%s is required", node.Summary())
+				}
+			}
+			return nil
+		},
 	}
-	if err := checkMissingPositionals(positionals, node.Positional); err != nil {
-		return err
+	for _, check := range checks {
+		if err := check(); err != nil {
+			if err := fail(err); err != nil {
+				return err
+			}
+		}
 	}
-	if err := checkXorDuplicates(c.Path); err != nil {
-		return err
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errs
 	}
+}
 
-	if node.Type == ArgumentNode {
-		value := node.Argument
-		if value.Required && !value.Set {
-			return fmt.Errorf("%s is required", node.Summary())
-		}
+// ValidationErrors is returned by Context.Validate() and Context.Resolve() when the AggregateErrors()
+// option is set and validation or resolution fails in more than one way.
+type ValidationErrors []error
+
+func (v ValidationErrors) Error() string {
+	lines := []string{fmt.Sprintf("%d validation errors:", len(v))}
+	for _, err := range v {
+		lines = append(lines, "  - "+strings.ReplaceAll(err.Error(), "\n", "\n    "))
 	}
-	return nil
+	return strings.Join(lines, "\n")
 }
 
 // Flags returns the accumulated available flags.
@@ -321,14 +412,155 @@ func (c *Context) FlagValue(flag *Flag) interface{} {
 	return flag.DefaultValue.Interface()
 }
 
+// FlagAs returns the value of the flag "name" in the selected command path, as type T.
+//
+// This is useful for code that operates over many different grammars, such as middleware:
+//
+//     verbose, err := kong.FlagAs[bool](ctx, "verbose")
+//
+// An error is returned if the flag does not exist or its value is not assignable to T.
+func FlagAs[T any](ctx *Context, name string) (T, error) {
+	var out T
+	for _, flag := range ctx.Flags() {
+		if flag.Name != name {
+			continue
+		}
+		value := ctx.FlagValue(flag)
+		if value == nil {
+			return out, nil
+		}
+		typed, ok := value.(T)
+		if !ok {
+			return out, errors.Errorf("%s is of type %T, not %T", flag.ShortSummary(), value, out)
+		}
+		return typed, nil
+	}
+	return out, errors.Errorf("unknown flag --%s", name)
+}
+
 // Reset recursively resets values to defaults (as specified in the grammar) or the zero value.
 func (c *Context) Reset() error {
-	return Visit(c.Model.Node, func(node Visitable, next Next) error {
+	if c.Model.resetPlan == nil {
+		c.Model.resetPlan = buildResetPlan(c.Model)
+	}
+	c.sources = make(map[*Value]ValueSource, len(c.Model.resetPlan))
+	for i := range c.Model.resetPlan {
+		step := &c.Model.resetPlan[i]
+		if err := step.apply(); err != nil {
+			return err
+		}
+		if source := step.source(); source.Kind != SourceUnset {
+			c.sources[step.value] = source
+		}
+	}
+	return nil
+}
+
+// A resetStep resets a single Value to its default.
+//
+// Defaults of basic types, and slices and maps of basic types, are decoded once and copied into the
+// target on each reset, avoiding repeated scanning and decoding. Values with an environment variable,
+// transforms or indirections are always reset from scratch, as their sources may change between parses.
+type resetStep struct {
+	value *Value
+	zero  reflect.Value
+	// Decoded default, if it could be cached.
+	def reflect.Value
+}
+
+func buildResetPlan(app *Application) []resetStep {
+	plan := []resetStep{}
+	_ = Visit(app.Node, func(node Visitable, next Next) error {
 		if value, ok := node.(*Value); ok {
-			return next(value.Reset())
+			step := resetStep{value: value, zero: reflect.Zero(value.Target.Type())}
+			if value.Tag.Env == "" && value.Default != "" && value.Tag.Type == "" && value.Transform == nil && value.indirections == nil && isCacheableDefault(value.Target.Type()) {
+				def := reflect.New(value.Target.Type()).Elem()
+				scan := ScanFromTokens(Token{Type: FlagValueToken, Value: value.Default})
+				// Errors are left to be reported by Value.Reset().
+				if err := value.Mapper.Decode(&DecodeContext{Value: value, Scan: scan}, def); err == nil {
+					step.def = def
+				}
+			}
+			plan = append(plan, step)
 		}
 		return next(nil)
 	})
+	return plan
+}
+
+func (r *resetStep) apply() error {
+	switch {
+	case r.def.IsValid():
+		r.value.Target.Set(copyDefault(r.def))
+		r.value.Set = true
+		return nil
+
+	case r.value.Tag.Env == "" && r.value.Default == "":
+		r.value.Target.Set(r.zero)
+		return nil
+
+	default:
+		return r.value.Reset()
+	}
+}
+
+// Where the value applied by this step came from.
+func (r *resetStep) source() ValueSource {
+	if r.value.Tag.Env != "" && os.Getenv(r.value.Tag.Env) != "" {
+		return ValueSource{Kind: SourceEnv, Env: r.value.Tag.Env}
+	}
+	if r.value.Default != "" {
+		return ValueSource{Kind: SourceDefault}
+	}
+	return ValueSource{}
+}
+
+// Only unnamed basic types, and slices and maps of them, are cached, as named types may have custom mappers.
+func isCacheableDefault(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Name() == "" && isBasicType(t.Elem())
+	case reflect.Map:
+		return t.Name() == "" && isBasicType(t.Key()) && isBasicType(t.Elem())
+	default:
+		return isBasicType(t)
+	}
+}
+
+func isBasicType(t reflect.Type) bool {
+	if t.PkgPath() != "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// Copy slices and maps so that mutations of the target do not leak into the cached default.
+func copyDefault(def reflect.Value) reflect.Value {
+	switch def.Kind() {
+	case reflect.Slice:
+		out := reflect.MakeSlice(def.Type(), def.Len(), def.Len())
+		reflect.Copy(out, def)
+		return out
+
+	case reflect.Map:
+		out := reflect.MakeMapWithSize(def.Type(), def.Len())
+		iter := def.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return out
+
+	default:
+		return def
+	}
 }
 
 func (c *Context) endParsing() {
@@ -346,8 +578,30 @@ func (c *Context) endParsing() {
 	}
 }
 
+// Treat the unknown flag token and everything after it as positional arguments, reconstructing the
+// flag as it appeared on the command-line.
+func (c *Context) startPassthrough(token Token) {
+	c.scan.Pop()
+	raw := token.String()
+	next := c.scan.Peek()
+	switch {
+	case token.Type == FlagToken && next.Type == FlagValueToken:
+		raw += "=" + next.String()
+		c.scan.Pop()
+
+	case token.Type == ShortFlagToken && next.Type == ShortFlagTailToken:
+		raw += next.String()
+		c.scan.Pop()
+	}
+	c.endParsing()
+	c.scan.PushTyped(raw, PositionalArgumentToken)
+}
+
 func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 	positional := 0
+	// Values of a cumulative positional followed by required positionals, collected across any flags
+	// between them and assigned once the command-line is exhausted.
+	var reserved []Token
 
 	flags := []*Flag{}
 	flagNode := node
@@ -357,8 +611,12 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 		// naming the command on the CLI.
 		flagNode = node.DefaultCmd
 	}
-	for _, group := range flagNode.AllFlags(false) {
-		flags = append(flags, group...)
+	if c.strictFlags {
+		flags = c.strictFlagsFor(node, flagNode)
+	} else {
+		for _, group := range flagNode.AllFlags(false) {
+			flags = append(flags, group...)
+		}
 	}
 
 	for !c.scan.Peek().IsEOL() {
@@ -393,11 +651,18 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 				// Short flag.
 				case strings.HasPrefix(v, "-"):
 					c.scan.Pop()
+					name := v[1:2]
+					if c.multiRuneShort {
+						name = multiRuneShortFlag(flags, v[1:])
+					}
+					if err := c.checkPOSIXShortFlag(name, v[1+len(name):]); err != nil {
+						return err
+					}
 					// Note: tokens must be pushed in reverse order.
-					if tail := v[2:]; tail != "" {
+					if tail := v[1+len(name):]; tail != "" {
 						c.scan.PushTyped(tail, ShortFlagTailToken)
 					}
-					c.scan.PushTyped(v[1:2], ShortFlagToken)
+					c.scan.PushTyped(name, ShortFlagToken)
 				}
 			default:
 				c.scan.Pop()
@@ -406,20 +671,23 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 
 		case ShortFlagTailToken:
 			c.scan.Pop()
+			if err := c.checkPOSIXShortFlag(token.String()[0:1], token.String()[1:]); err != nil {
+				return err
+			}
 			// Note: tokens must be pushed in reverse order.
 			if tail := token.String()[1:]; tail != "" {
 				c.scan.PushTyped(tail, ShortFlagTailToken)
 			}
 			c.scan.PushTyped(token.String()[0:1], ShortFlagToken)
 
-		case FlagToken:
-			if err := c.parseFlag(flags, token.String()); err != nil {
-				return err
-			}
-
-		case ShortFlagToken:
+		case FlagToken, ShortFlagToken:
 			if err := c.parseFlag(flags, token.String()); err != nil {
-				return err
+				// An unknown flag starts passthrough, if the next positional is a passthrough argument.
+				if positional < len(node.Positional) && node.Positional[positional].Passthrough && !hasFlag(flags, token.String()) {
+					c.startPassthrough(token)
+					break
+				}
+				return c.checkStrictFlag(node, token.String(), err)
 			}
 
 		case FlagValueToken:
@@ -432,10 +700,16 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 			if positional < len(node.Positional) {
 				arg := node.Positional[positional]
 
-				if arg.Passthrough {
+				// A passthrough command stops flag parsing at its first positional argument, as does any
+				// positional argument in POSIX strict mode.
+				if arg.Passthrough || (node.Type == CommandNode && node.Tag.Passthrough) || c.posixStrict {
 					c.endParsing()
 				}
 
+				if arg.IsCumulative() && reservedPositionals(node.Positional[positional+1:]) > 0 {
+					reserved = append(reserved, c.scan.PopWhile(func(t Token) bool { return t.IsValue() })...)
+					break
+				}
 				err := arg.Parse(c.scan, c.getValue(arg))
 				if err != nil {
 					return err
@@ -448,6 +722,13 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 				break
 			}
 
+			// Naming a repeated command again starts another invocation of it.
+			if repeatsCommand(node, token) {
+				c.scan.Pop()
+				c.repeatCommand(node)
+				return c.trace(node)
+			}
+
 			// Assign token value to a branch name if tagged as an alias
 			// An alias will be ignored in the case of an existing command
 			cmds := make(map[string]bool)
@@ -483,17 +764,20 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 			}
 
 			// Finally, check arguments.
+			// Branches are tried in declaration order, the first whose key maps being taken.
+			keyErrs := []string{}
 			for _, branch := range node.Children {
 				if branch.Type == ArgumentNode {
-					arg := branch.Argument
-					if err := arg.Parse(c.scan, c.getValue(arg)); err == nil {
-						c.Path = append(c.Path, &Path{
-							Parent:   node,
-							Argument: branch,
-							Flags:    branch.Flags,
-						})
-						return c.trace(branch)
+					if err := c.parseBranchKey(branch.Argument); err != nil {
+						keyErrs = append(keyErrs, err.Error())
+						continue
 					}
+					c.Path = append(c.Path, &Path{
+						Parent:   node,
+						Argument: branch,
+						Flags:    branch.Flags,
+					})
+					return c.trace(branch)
 				}
 			}
 
@@ -508,41 +792,183 @@ func (c *Context) trace(node *Node) (err error) { // nolint: gocyclo
 				return c.trace(node.DefaultCmd)
 			}
 
+			if err := c.misplacedCommand(node, token.String()); err != nil {
+				return err
+			}
+			if len(keyErrs) > 0 {
+				return findPotentialCandidates(token.String(), candidates, "unexpected argument %s (%s)", token, strings.Join(keyErrs, "; "))
+			}
 			return findPotentialCandidates(token.String(), candidates, "unexpected argument %s", token)
 		default:
 			return fmt.Errorf("unexpected token %s", token)
 		}
 	}
+	if reserved != nil {
+		if err := c.parseReservedPositionals(node, positional, reserved); err != nil {
+			return err
+		}
+	}
 	return c.maybeSelectDefault(flags, node)
 }
 
+// The name of the short flag at the start of word, without its dash, with MultiRuneShortFlags().
+//
+// The longest multi-character short name that word starts with takes precedence, with the rest of the word
+// being its value or clustered short flags. Otherwise the name is the first character of word.
+func multiRuneShortFlag(flags []*Flag, word string) string {
+	name := word[:1]
+	for _, flag := range flags {
+		if short := flag.Tag.ShortName; short != "" && strings.HasPrefix(word, short) && len(short) > len(name) {
+			name = short
+		}
+	}
+	return name
+}
+
+// In POSIX strict mode, a short flag's value may only be attached directly (-fVALUE) or given as the next
+// argument (-f VALUE), never with "=".
+func (c *Context) checkPOSIXShortFlag(flag, tail string) error {
+	if c.posixStrict && strings.HasPrefix(tail, "=") {
+		return fmt.Errorf("unexpected \"=\" after short flag -%s, use -%sVALUE or -%s VALUE", flag, flag, flag)
+	}
+	return nil
+}
+
+// Flags accepted at node when StrictFlags() is enabled.
+func (c *Context) strictFlagsFor(node, flagNode *Node) []*Flag {
+	flags := []*Flag{}
+	for _, builtin := range []*Flag{c.helpFlag, c.helpHiddenFlag} {
+		if builtin != nil && node.Type != ApplicationNode {
+			flags = append(flags, builtin)
+		}
+	}
+	flags = append(flags, node.Flags...)
+	if flagNode != node {
+		flags = append(flags, flagNode.Flags...)
+	}
+	return flags
+}
+
+// Replace an unknown flag error with a more helpful one if the flag belongs to an ancestor in strict mode.
+func (c *Context) checkStrictFlag(node *Node, match string, err error) error {
+	if !c.strictFlags {
+		return err
+	}
+	for parent := node.Parent; parent != nil; parent = parent.Parent {
+		for _, flag := range parent.Flags {
+			if (match == "--"+flag.Name && !flag.Tag.ShortOnly) || (flag.shortName() != "" && match == "-"+flag.shortName()) {
+				return fmt.Errorf("%s must be specified before %q", match, node.Name)
+			}
+		}
+	}
+	return err
+}
+
+// The number of required positionals that must be reserved for after a cumulative positional,
+// eg. <dst> in "<src> ... <dst>".
+func reservedPositionals(trailing []*Positional) (reserved int) {
+	for _, positional := range trailing {
+		if positional.Required && !positional.IsCumulative() {
+			reserved++
+		}
+	}
+	return reserved
+}
+
+// Assign the values collected for the cumulative positional at index "positional", reserving the last of
+// them for the positionals that follow it.
+func (c *Context) parseReservedPositionals(node *Node, positional int, tokens []Token) error {
+	reserved := reservedPositionals(node.Positional[positional+1:])
+	if reserved > len(tokens) {
+		reserved = len(tokens)
+	}
+	split := len(tokens) - reserved
+	if split == 0 {
+		// Every argument is reserved, so leave this positional unset, unless it's required.
+		if err := checkMissingPositionals(positional, node.Positional[:positional+1]); err != nil {
+			return err
+		}
+	} else {
+		arg := node.Positional[positional]
+		if err := arg.Parse(ScanFromTokens(tokens[:split]...), c.getValue(arg)); err != nil {
+			return err
+		}
+		c.Path = append(c.Path, &Path{Parent: node, Positional: arg})
+	}
+	scan := ScanFromTokens(tokens[split:]...)
+	for _, arg := range node.Positional[positional+1:] {
+		if scan.Peek().IsEOL() {
+			break
+		}
+		if err := arg.Parse(scan, c.getValue(arg)); err != nil {
+			return err
+		}
+		c.Path = append(c.Path, &Path{Parent: node, Positional: arg})
+	}
+	return nil
+}
+
+// DefaultCommandSelector may be implemented by a command, or the application, to choose which of its
+// sub-commands is selected when none is given on the command-line, eg. depending on whether stdin is a
+// terminal. It takes precedence over a sub-command tagged with default:"1".
+type DefaultCommandSelector interface {
+	// SelectDefaultCommand returns the name of the sub-command to select, or "" to select none.
+	SelectDefaultCommand(ctx *Context) string
+}
+
 // End of the line, check for a default command, but only if we're not displaying help,
 // otherwise we'd only ever display the help for the default command.
 func (c *Context) maybeSelectDefault(flags []*Flag, node *Node) error {
 	for _, flag := range flags {
-		if flag.Name == "help" && flag.Set {
+		if (flag.Name == "help" || flag.Name == "help-hidden") && flag.Set {
 			return nil
 		}
 	}
-	if node.DefaultCmd != nil {
+	defaultCmd := node.DefaultCmd
+	if node.Target.IsValid() && node.Target.CanAddr() {
+		if selector, ok := node.Target.Addr().Interface().(DefaultCommandSelector); ok {
+			name := selector.SelectDefaultCommand(c)
+			defaultCmd = findChildCommand(node, name)
+			if name != "" && defaultCmd == nil {
+				return fmt.Errorf("default command %q is not a sub-command of %s", name, node.Name)
+			}
+		}
+	}
+	if defaultCmd != nil {
 		c.Path = append(c.Path, &Path{
-			Parent:  node.DefaultCmd,
-			Command: node.DefaultCmd,
-			Flags:   node.DefaultCmd.Flags,
+			Parent:  defaultCmd,
+			Command: defaultCmd,
+			Flags:   defaultCmd.Flags,
 		})
 	}
 	return nil
 }
 
+// Find the child command of node called name, or with name as an alias.
+func findChildCommand(node *Node, name string) *Node {
+	for _, child := range node.Children {
+		if child.Type != CommandNode {
+			continue
+		}
+		if child.Name == name || containsString(child.Aliases, name) {
+			return child
+		}
+	}
+	return nil
+}
+
 // Resolve walks through the traced path, applying resolvers to any unset flags.
 func (c *Context) Resolve() error {
 	resolvers := c.combineResolvers()
-	if len(resolvers) == 0 {
+	if len(resolvers) == 0 && len(c.Kong.namedResolvers) == 0 {
 		return nil
 	}
 
+	// With AggregateErrors(), the failures of every flag are collected rather than returned immediately.
+	errs := ValidationErrors{}
 	inserted := []*Path{}
 	for _, path := range c.Path {
+		batches := map[Resolver]*resolvedBatch{}
 		for _, flag := range path.Flags {
 			// Flag has already been set on the command-line.
 			if _, ok := c.values[flag.Value]; ok {
@@ -550,38 +976,118 @@ func (c *Context) Resolve() error {
 			}
 
 			// Pick the last resolved value.
-			var selected interface{}
-			for _, resolver := range resolvers {
-				s, err := resolver.Resolve(c, path, flag)
+			var (
+				selected         interface{}
+				selectedResolver Resolver
+				err              error
+			)
+			for _, resolver := range c.flagResolvers(resolvers, path, flag) {
+				var s interface{}
+				if batch, ok := resolver.(BatchResolver); ok {
+					s, err = c.resolveBatch(batches, batch, path, flag)
+				} else {
+					s, err = resolver.Resolve(c, path, flag)
+				}
+				if err != nil && c.resolverErrorHandler != nil {
+					loaded, _ := unwrapFileResolver(resolver)
+					err = c.resolverErrorHandler(c, loaded, flag, err)
+					if err == nil {
+						continue
+					}
+				}
 				if err != nil {
-					return errors.Wrap(err, flag.ShortSummary())
+					err = errors.Wrap(err, flag.ShortSummary())
+					break
 				}
 				if s == nil {
 					continue
 				}
 				selected = s
+				selectedResolver = resolver
 			}
 
-			if selected == nil {
-				continue
+			if err == nil && selected != nil {
+				scan := Scan().PushTyped(selected, FlagValueToken)
+				delete(c.values, flag.Value)
+				if err = flag.Parse(scan, c.getValue(flag.Value)); err == nil {
+					loaded, _ := unwrapFileResolver(selectedResolver)
+					inserted = append(inserted, &Path{
+						Flag:     flag,
+						Resolved: true,
+						Resolver: loaded,
+					})
+				} else {
+					err = newConfigValueError(selectedResolver, flag, selected, err)
+				}
 			}
-
-			scan := Scan().PushTyped(selected, FlagValueToken)
-			delete(c.values, flag.Value)
-			err := flag.Parse(scan, c.getValue(flag.Value))
 			if err != nil {
-				return err
+				if !c.aggregateErrors {
+					return err
+				}
+				errs = append(errs, err)
 			}
-			inserted = append(inserted, &Path{
-				Flag:     flag,
-				Resolved: true,
-			})
 		}
 	}
+	switch len(errs) {
+	case 0:
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
 	c.Path = append(inserted, c.Path...)
 	return nil
 }
 
+type resolvedBatch struct {
+	values map[string]interface{}
+	err    error
+}
+
+// Resolve flag from the batch of values of its command, fetching the batch on first use.
+func (c *Context) resolveBatch(batches map[Resolver]*resolvedBatch, resolver BatchResolver, path *Path, flag *Flag) (interface{}, error) {
+	batch, ok := batches[resolver]
+	if !ok {
+		flags := []*Flag{}
+		for _, flag := range path.Flags {
+			if _, ok := c.values[flag.Value]; !ok {
+				flags = append(flags, flag)
+			}
+		}
+		batch = &resolvedBatch{}
+		batch.values, batch.err = resolver.ResolveBatch(c, path, flags)
+		batches[resolver] = batch
+	}
+	// A failed batch fails each of its flags, so that they can be handled by ResolverErrorHandler().
+	if batch.err != nil {
+		return nil, batch.err
+	}
+	return batch.values[flag.Name], nil
+}
+
+// The resolvers to consult for flag, in increasing order of precedence: those of the application and
+// context, then named resolvers referenced by the commands enclosing the flag, then by the flag itself.
+func (c *Context) flagResolvers(resolvers []Resolver, path *Path, flag *Flag) []Resolver {
+	names := flag.Tag.Resolvers
+	for node := path.Node(); node != nil; node = node.Parent {
+		if node.Tag != nil {
+			names = append(append([]string(nil), node.Tag.Resolvers...), names...)
+		}
+	}
+	if len(names) == 0 {
+		return resolvers
+	}
+	out := append([]Resolver(nil), resolvers...)
+	seen := map[string]bool{}
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			out = append(out, c.Kong.namedResolvers[name])
+		}
+	}
+	return out
+}
+
 // Combine application-level resolvers and context resolvers.
 func (c *Context) combineResolvers() []Resolver {
 	resolvers := []Resolver{}
@@ -590,6 +1096,37 @@ func (c *Context) combineResolvers() []Resolver {
 	return resolvers
 }
 
+// Parse the key of a branching positional argument. The branch is only taken if the key maps to its
+// type and satisfies its enum, otherwise the scanner and the value are left untouched so that other
+// branches can be tried.
+func (c *Context) parseBranchKey(arg *Value) error {
+	args := append([]Token(nil), c.scan.args...)
+	set := arg.Set
+	previous, existing := c.values[arg]
+	if existing {
+		// Parse into a copy, so a failed attempt can't clobber the previous value.
+		value := reflect.New(previous.Type()).Elem()
+		value.Set(previous)
+		c.values[arg] = value
+	}
+	value := c.getValue(arg)
+	err := arg.Parse(c.scan, value)
+	if err == nil && arg.Enum != "" {
+		err = checkEnum(arg, value)
+	}
+	if err != nil {
+		c.scan.args = args
+		arg.Set = set
+		if existing {
+			c.values[arg] = previous
+		} else {
+			delete(c.values, arg)
+		}
+		return err
+	}
+	return nil
+}
+
 func (c *Context) getValue(value *Value) reflect.Value {
 	v, ok := c.values[value]
 	if !ok {
@@ -633,6 +1170,8 @@ func (c *Context) ApplyDefaults() error {
 // Apply traced context to the target grammar.
 func (c *Context) Apply() (string, error) {
 	path := []string{}
+	invocations := &invocationCollector{c: c}
+	var invocation map[*Value]reflect.Value
 
 	for _, trace := range c.Path {
 		var value *Value
@@ -643,6 +1182,10 @@ func (c *Context) Apply() (string, error) {
 			value = trace.Argument.Argument
 		case trace.Command != nil:
 			path = append(path, trace.Command.Name)
+			if trace.Command.Repeated {
+				invocations.begin(trace.Command)
+				invocation = trace.invocation
+			}
 		case trace.Flag != nil:
 			value = trace.Flag.Value
 		case trace.Positional != nil:
@@ -652,28 +1195,48 @@ func (c *Context) Apply() (string, error) {
 			panic("unsupported path ?!")
 		}
 		if value != nil {
-			value.Apply(c.getValue(value))
+			if v, ok := invocation[value]; ok {
+				value.Apply(v)
+			} else {
+				value.Apply(c.getValue(value))
+			}
 		}
 	}
+	invocations.finish()
 
 	return strings.Join(path, " "), nil
 }
 
-func (c *Context) parseFlag(flags []*Flag, match string) (err error) {
-	candidates := []string{}
+// Does the flag token "match" name the flag, eg. "--name", "-n" or "--no-name"?
+func (f *Flag) matches(match string) bool {
+	long := "--" + f.Name
+	short := "-" + f.shortName()
+	if f.Tag.ShortOnly {
+		long = short
+	}
+	return short == match || long == match || (match == "--no-"+f.Name && f.Tag.Negatable)
+}
+
+func hasFlag(flags []*Flag, match string) bool {
 	for _, flag := range flags {
-		long := "--" + flag.Name
-		short := "-" + string(flag.Short)
-		neg := "--no-" + flag.Name
-		candidates = append(candidates, long)
-		if flag.Short != 0 {
-			candidates = append(candidates, short)
+		if flag.matches(match) {
+			return true
 		}
-		if short != match && long != match && !(match == neg && flag.Tag.Negatable) {
+	}
+	return false
+}
+
+func (c *Context) parseFlag(flags []*Flag, match string) (err error) {
+	for _, flag := range flags {
+		if !flag.matches(match) {
 			continue
 		}
+		neg := "--no-" + flag.Name
 		// Found a matching flag.
 		c.scan.Pop()
+		if handled, err := c.parseDuplicateFlag(flag); handled {
+			return err
+		}
 		if match == neg && flag.Tag.Negatable {
 			flag.Negated = true
 		}
@@ -686,12 +1249,22 @@ func (c *Context) parseFlag(flags []*Flag, match string) (err error) {
 		}
 		if flag.Negated {
 			value := c.getValue(flag.Value)
-			value.SetBool(!value.Bool())
+			reflect.Indirect(value).SetBool(!reflect.Indirect(value).Bool())
 			flag.Value.Apply(value)
 		}
 		c.Path = append(c.Path, &Path{Flag: flag})
 		return nil
 	}
+	// Candidates are only collected on failure, to avoid allocating for every flag parsed.
+	candidates := []string{}
+	for _, flag := range flags {
+		if !flag.Tag.ShortOnly {
+			candidates = append(candidates, "--"+flag.Name)
+		}
+		if short := flag.shortName(); short != "" {
+			candidates = append(candidates, "-"+short)
+		}
+	}
 	return findPotentialCandidates(match, candidates, "unknown flag %s", match)
 }
 
@@ -703,20 +1276,37 @@ func (c *Context) parseFlag(flags []*Flag, match string) (err error) {
 // all parent nodes in the command structure will be bound.
 func (c *Context) RunNode(node *Node, binds ...interface{}) (err error) {
 	type targetMethod struct {
-		node   *Node
+		target reflect.Value
 		method reflect.Value
+		runner Runner
 		binds  bindings
 	}
-	methodBinds := c.Kong.bindings.clone().add(binds...).add(c).merge(c.bindings)
+	// Output writers are bound by default, but may be overridden by explicit bindings.
+	stdout := &detachableWriter{w: c.Stdout}
+	buffered := bufio.NewWriter(stdout)
+	defer func() {
+		// A Run() method that timed out may still be writing to buffered.
+		if stdout.isDetached() {
+			return
+		}
+		if ferr := buffered.Flush(); err == nil {
+			err = ferr
+		}
+	}()
+	methodBinds := bindings{}
+	methodBinds.addTo(io.Writer(stdout), (*io.Writer)(nil))
+	methodBinds = methodBinds.add(buffered).merge(c.Kong.bindings).add(binds...).add(c).merge(c.bindings)
 	methods := []targetMethod{}
 	for i := 0; node != nil; i, node = i+1, node.Parent {
-		method := getMethod(node.Target, "Run")
+		target := runTarget(node)
+		method := getMethod(target, "Run")
 		methodBinds = methodBinds.clone()
 		for p := node; p != nil; p = p.Parent {
 			methodBinds = methodBinds.add(p.Target.Addr().Interface())
 		}
 		if method.IsValid() {
-			methods = append(methods, targetMethod{node, method, methodBinds})
+			runner, _ := target.Addr().Interface().(Runner)
+			methods = append(methods, targetMethod{target, method, runner, methodBinds})
 		}
 	}
 	if len(methods) == 0 {
@@ -727,18 +1317,47 @@ func (c *Context) RunNode(node *Node, binds ...interface{}) (err error) {
 		return err
 	}
 
-	for _, method := range methods {
-		if err = callMethod("Run", method.node.Target, method.method, method.binds); err != nil {
-			return err
+	if c.runTimeout > 0 {
+		runCtx, cancel := context.WithTimeout(boundContext(methodBinds), c.runTimeout)
+		defer cancel()
+		for _, method := range methods {
+			method.binds.addTo(runCtx, (*context.Context)(nil))
 		}
 	}
-	return nil
+	start := time.Now()
+	err = c.guardRun(stdout, func() error {
+		for _, method := range methods {
+			if method.runner != nil {
+				if err := method.runner.Run(c); err != nil {
+					return err
+				}
+				continue
+			}
+			result, err := callRunMethod("Run", method.target, method.method, method.binds)
+			if err != nil {
+				return err
+			}
+			// Methods are called from the selected command upwards, so the most specific result wins.
+			if result != nil && c.Result == nil {
+				c.Result = result
+			}
+		}
+		return nil
+	})
+	c.reportUsage(start, err)
+	if auditErr := c.audit(start, err); err == nil {
+		err = auditErr
+	}
+	return err
 }
 
 // Run executes the Run() method on the selected command, which must exist.
 //
 // Any passed values will be bindable to arguments of the target Run() method. Additionally,
 // all parent nodes in the command structure will be bound.
+//
+// Run() methods may return either "error" or "(T, error)", in which case T is recorded in
+// Context.Result.
 func (c *Context) Run(binds ...interface{}) (err error) {
 	node := c.Selected()
 	if node == nil {
@@ -765,10 +1384,15 @@ func (c *Context) PrintUsage(summary bool) error {
 	return c.help(options, c)
 }
 
-func checkMissingFlags(flags []*Flag) error {
+// With EnvOnly(), flags can't be set on the command-line, so they're reported by environment variable instead.
+func checkMissingFlags(flags []*Flag, envOnly bool) error {
 	xorGroupSet := map[string]bool{}
 	xorGroup := map[string][]string{}
 	missing := []string{}
+	summary := (*Flag).Summary
+	if envOnly {
+		summary = envOnlySummary
+	}
 	for _, flag := range flags {
 		if flag.Set {
 			for _, xor := range flag.Xor {
@@ -783,10 +1407,10 @@ func checkMissingFlags(flags []*Flag) error {
 				if xorGroupSet[xor] {
 					continue
 				}
-				xorGroup[xor] = append(xorGroup[xor], flag.Summary())
+				xorGroup[xor] = append(xorGroup[xor], summary(flag))
 			}
 		} else {
-			missing = append(missing, flag.Summary())
+			missing = append(missing, summary(flag))
 		}
 	}
 	for _, flags := range xorGroup {
@@ -801,9 +1425,20 @@ func checkMissingFlags(flags []*Flag) error {
 
 	sort.Strings(missing)
 
+	if envOnly {
+		return fmt.Errorf("missing values for %s", strings.Join(missing, ", "))
+	}
 	return fmt.Errorf("missing flags: %s", strings.Join(missing, ", "))
 }
 
+// The environment variable setting flag, eg. "$APP_ADDR", or its name if it has none.
+func envOnlySummary(flag *Flag) string {
+	if flag.Tag.Env != "" {
+		return "$" + flag.Tag.Env
+	}
+	return flag.Name
+}
+
 func checkMissingChildren(node *Node) error {
 	missing := []string{}
 
@@ -883,12 +1518,32 @@ func checkEnum(value *Value, target reflect.Value) error {
 		}
 		return nil
 
-	case reflect.Map, reflect.Struct:
-		return errors.Errorf("enum can only be applied to a slice or value")
+	case reflect.Ptr:
+		if target.IsNil() {
+			return nil
+		}
+		return checkEnum(value, target.Elem())
+
+	case reflect.Map:
+		// The enum constrains the values of maps, not their keys.
+		keys := target.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			if err := checkEnum(value, target.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Struct:
+		return errors.Errorf("enum can only be applied to a slice, map or value")
 
 	default:
 		enumMap := value.EnumMap()
 		v := fmt.Sprintf("%v", target)
+		if name, ok := enumName(target); ok {
+			v = name
+		}
 		if enumMap[v] {
 			return nil
 		}
@@ -919,6 +1574,49 @@ func checkXorDuplicates(paths []*Path) error {
 	return nil
 }
 
+func checkAndMissing(paths []*Path) error {
+	for _, path := range paths {
+		if path.Node() == nil {
+			continue
+		}
+		for _, group := range path.Node().FlagGroups() {
+			if group.Kind != AndGroup {
+				continue
+			}
+			set, missing := []string{}, []string{}
+			for _, flag := range group.Flags {
+				if flag.Set {
+					set = append(set, "--"+flag.Name)
+				} else {
+					missing = append(missing, "--"+flag.Name)
+				}
+			}
+			if len(set) > 0 && len(missing) > 0 {
+				return fmt.Errorf("%s must be used with %s", strings.Join(set, ", "), strings.Join(missing, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// Suggest the full path of a command that exists elsewhere in the tree, eg. "server start" for "start".
+func (c *Context) misplacedCommand(node *Node, name string) error {
+	paths := []string{}
+	for _, cmd := range c.commandIndex[name] {
+		if cmd.Parent != node {
+			paths = append(paths, fmt.Sprintf("%q", commandPath(cmd)))
+		}
+	}
+	switch len(paths) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("unexpected argument %s, did you mean %s?", name, paths[0])
+	default:
+		return fmt.Errorf("unexpected argument %s, did you mean one of %s?", name, strings.Join(paths, ", "))
+	}
+}
+
 func findPotentialCandidates(needle string, haystack []string, format string, args ...interface{}) error {
 	if len(haystack) == 0 {
 		return fmt.Errorf(format, args...)
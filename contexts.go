@@ -0,0 +1,248 @@
+package kong
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Contexts adds named contexts, sets of saved flag values that can be switched between, similar to
+// kubectl and docker contexts.
+//
+// A "context" command is added with "use", "list", "show" and "set" subcommands, and a resolver is
+// registered that supplies the flag values of the active context. The active context is selected with
+// "context use <name>", or overridden by the <envPrefix>_CONTEXT environment variable. An unknown active
+// context fails the parse, except for the "context" command, so that another can be selected.
+//
+// Contexts are stored in "contexts.json" alongside the state file. See StateFile().
+//
+//     kong.Contexts("MYAPP")
+func Contexts(envPrefix string) Option {
+	return OptionFunc(func(k *Kong) error {
+		envar := envPrefix + "_CONTEXT"
+		k.contextsEnvar = envar
+		k.dynamicCommands = append(k.dynamicCommands, &dynamicCommand{
+			name: "context",
+			help: "Manage named contexts of flag values.",
+			cmd:  &contextsCmd{},
+		})
+		k.resolvers = append(k.resolvers, ResolverFunc(func(ctx *Context, parent *Path, flag *Flag) (interface{}, error) {
+			if value, ok := ctx.activeContext[flag.Name]; ok {
+				return value, nil
+			}
+			return nil, nil
+		}))
+		return nil
+	})
+}
+
+// Load the flag values of the active context into ctx, for the resolver added by Contexts().
+//
+// Nothing is loaded for the "context" command itself, so that it can be used to recover from an unknown
+// active context.
+func (k *Kong) loadActiveContext(ctx *Context) error {
+	if k.contextsEnvar == "" {
+		return nil
+	}
+	for _, path := range ctx.Path {
+		if path.Command != nil && path.Command.Target.Type() == reflect.TypeOf(contextsCmd{}) {
+			return nil
+		}
+	}
+	// Without a configuration directory there are no saved contexts, which is only an error if one is
+	// selected.
+	contexts := &namedContexts{}
+	if _, err := k.contextsPath(); err == nil {
+		if contexts, err = k.loadContexts(); err != nil {
+			return err
+		}
+	}
+	name := contexts.active(k.contextsEnvar)
+	if name == "" {
+		return nil
+	}
+	values, ok := contexts.Contexts[name]
+	if !ok {
+		return errors.Errorf("unknown context %q, switch to another with \"%s context use <name>\"", name, k.Model.Name)
+	}
+	ctx.activeContext = values
+	return nil
+}
+
+type namedContexts struct {
+	Current  string                       `json:"current,omitempty"`
+	Contexts map[string]map[string]string `json:"contexts"`
+}
+
+// Name of the active context, which may be overridden by envar.
+func (n *namedContexts) active(envar string) string {
+	if name := os.Getenv(envar); name != "" {
+		return name
+	}
+	return n.Current
+}
+
+func (k *Kong) contextsPath() (string, error) {
+	path, err := k.statePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), "contexts.json"), nil
+}
+
+func (k *Kong) loadContexts() (*namedContexts, error) {
+	contexts := &namedContexts{Contexts: map[string]map[string]string{}}
+	path, err := k.contextsPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return contexts, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, contexts); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if contexts.Contexts == nil {
+		contexts.Contexts = map[string]map[string]string{}
+	}
+	return contexts, nil
+}
+
+func (k *Kong) saveContexts(contexts *namedContexts) error {
+	path, err := k.contextsPath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(contexts, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+type contextsCmd struct {
+	Use  contextUseCmd  `cmd:"" help:"Switch to a named context."`
+	List contextListCmd `cmd:"" help:"List contexts."`
+	Show contextShowCmd `cmd:"" help:"Show the flag values of a context, defaulting to the active context."`
+	Set  contextSetCmd  `cmd:"" help:"Set flag values of a context, creating it if necessary."`
+}
+
+type contextUseCmd struct {
+	Name string `arg:"" help:"Name of the context."`
+}
+
+func (c *contextUseCmd) Run(ctx *Context) error {
+	contexts, err := ctx.Kong.loadContexts()
+	if err != nil {
+		return err
+	}
+	if _, ok := contexts.Contexts[c.Name]; !ok {
+		return errors.Errorf("unknown context %q", c.Name)
+	}
+	contexts.Current = c.Name
+	if err := ctx.Kong.saveContexts(contexts); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Stdout, "Switched to context %q\n", c.Name)
+	return nil
+}
+
+type contextListCmd struct{}
+
+func (c *contextListCmd) Run(ctx *Context) error {
+	contexts, err := ctx.Kong.loadContexts()
+	if err != nil {
+		return err
+	}
+	active := contexts.active(ctx.Kong.contextsEnvar)
+	names := make([]string, 0, len(contexts.Contexts))
+	for name := range contexts.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		marker := " "
+		if name == active {
+			marker = "*"
+		}
+		fmt.Fprintf(ctx.Stdout, "%s %s\n", marker, name)
+	}
+	return nil
+}
+
+type contextShowCmd struct {
+	Name string `arg:"" optional:"" help:"Name of the context."`
+}
+
+func (c *contextShowCmd) Run(ctx *Context) error {
+	contexts, err := ctx.Kong.loadContexts()
+	if err != nil {
+		return err
+	}
+	name := c.Name
+	if name == "" {
+		name = contexts.active(ctx.Kong.contextsEnvar)
+		if name == "" {
+			return errors.New("no context is active")
+		}
+	}
+	values, ok := contexts.Contexts[name]
+	if !ok {
+		return errors.Errorf("unknown context %q", name)
+	}
+	flags := make([]string, 0, len(values))
+	for flag := range values {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	for _, flag := range flags {
+		fmt.Fprintf(ctx.Stdout, "--%s=%s\n", flag, values[flag])
+	}
+	return nil
+}
+
+type contextSetCmd struct {
+	Name   string   `arg:"" help:"Name of the context."`
+	Values []string `arg:"" optional:"" help:"Flag values to set, as <flag>=<value>. An empty value unsets the flag."`
+}
+
+func (c *contextSetCmd) Run(ctx *Context) error {
+	contexts, err := ctx.Kong.loadContexts()
+	if err != nil {
+		return err
+	}
+	values := contexts.Contexts[c.Name]
+	if values == nil {
+		values = map[string]string{}
+		contexts.Contexts[c.Name] = values
+	}
+	for _, value := range c.Values {
+		parts := strings.SplitN(value, "=", 2)
+		flag := strings.TrimPrefix(parts[0], "--")
+		if len(parts) != 2 {
+			return errors.Errorf("expected <flag>=<value> but got %q", value)
+		}
+		if !hasFlagNamed(ctx.Model.Node, flag) {
+			return errors.Errorf("unknown flag --%s", flag)
+		}
+		if parts[1] == "" {
+			delete(values, flag)
+		} else {
+			values[flag] = parts[1]
+		}
+	}
+	return ctx.Kong.saveContexts(contexts)
+}
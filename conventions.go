@@ -0,0 +1,243 @@
+package kong
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A ConventionalType implies tags for every field of the type, so they don't have to be repeated on each
+// field. Tags on a field take precedence over those implied by its type.
+//
+// Kong provides conventional types for common values, which also validate them: Port, HostPort, URL,
+// ExistingFile, ExistingDir and FilePath. Applications can define their own, eg. a Region type implying
+// `enum:"us,eu" default:"us"`.
+type ConventionalType interface {
+	// ConventionalTags returns the implied tags in struct tag syntax, eg. `placeholder:"PORT"`.
+	ConventionalTags() string
+}
+
+var conventionalType = reflect.TypeOf((*ConventionalType)(nil)).Elem()
+
+// An EnumType implies the enum:"" tag of every field of the type from its EnumValues(), so the values a
+// flag accepts can't drift from the constants of the type.
+//
+// Integer types, such as a set of iota constants, are also decoded from those names, EnumValues()[i]
+// being the name of the constant with value i, unless they implement MapperValue or
+// encoding.TextUnmarshaler. As their zero value is then the first constant, their fields need no default:
+//
+//     type Format int
+//
+//     const (
+//         JSON Format = iota
+//         Text
+//     )
+//
+//     func (Format) EnumValues() []string { return []string{"json", "text"} }
+type EnumType interface {
+	EnumValues() []string
+}
+
+var enumType = reflect.TypeOf((*EnumType)(nil)).Elem()
+
+// The tag items implied by typ, or the element type of a slice or map, if it's a ConventionalType or an
+// EnumType.
+func conventionalTagItems(typ reflect.Type) (map[string][]string, error) {
+	typ = conventionalElemType(typ)
+	items := map[string][]string{}
+	if reflect.PtrTo(typ).Implements(conventionalType) {
+		tags := reflect.New(typ).Interface().(ConventionalType).ConventionalTags()
+		parsed, err := parseTagItems(tags, bareChars)
+		if err != nil {
+			return nil, err
+		}
+		items = parsed
+	}
+	if values := enumValues(typ); values != nil {
+		if _, ok := items["enum"]; !ok {
+			items["enum"] = []string{strings.Join(values, ",")}
+		}
+	}
+	return items, nil
+}
+
+// The type that implies the tags of fields of typ: the element type of slices and maps, dereferenced.
+func conventionalElemType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// Returns true if enum is the one implied by the EnumType of fields of typ, and the zero value of the
+// type is one of its values, eg. the first constant of an integer enum.
+func enumZeroValid(typ reflect.Type, enum []string) bool {
+	typ = conventionalElemType(typ)
+	values := enumValues(typ)
+	if values == nil || len(enum) != 1 || enum[0] != strings.Join(values, ",") {
+		return false
+	}
+	zero := reflect.Zero(typ)
+	name, ok := enumName(zero)
+	if !ok {
+		name = fmt.Sprintf("%v", zero)
+	}
+	for _, value := range values {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// The EnumValues() of typ, or nil if it's not an EnumType.
+func enumValues(typ reflect.Type) []string {
+	if !reflect.PtrTo(typ).Implements(enumType) {
+		return nil
+	}
+	return reflect.New(typ).Interface().(EnumType).EnumValues()
+}
+
+// The name of the constant of an integer EnumType held by value.
+func enumName(value reflect.Value) (string, bool) {
+	values := enumValues(value.Type())
+	var i uint64
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Int() < 0 {
+			return "", false
+		}
+		i = uint64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i = value.Uint()
+	default:
+		return "", false
+	}
+	if i >= uint64(len(values)) {
+		return "", false
+	}
+	return values[i], true
+}
+
+// enumTypeMapper decodes integer EnumTypes from the names of their constants.
+type enumTypeMapper struct{}
+
+func (enumTypeMapper) Decode(ctx *DecodeContext, target reflect.Value) error {
+	var name string
+	if err := ctx.Scan.PopValueInto("value", &name); err != nil {
+		return err
+	}
+	values := enumValues(target.Type())
+	for i, value := range values {
+		if value != name {
+			continue
+		}
+		if target.Kind() >= reflect.Uint && target.Kind() <= reflect.Uint64 {
+			target.SetUint(uint64(i))
+		} else {
+			target.SetInt(int64(i))
+		}
+		return nil
+	}
+	return errors.Errorf("expected one of %s but got %q", strings.Join(values, ", "), name)
+}
+
+// Port is a TCP or UDP port number.
+type Port uint16
+
+// ConventionalTags implements ConventionalType.
+func (Port) ConventionalTags() string { return `placeholder:"PORT"` }
+
+func (p *Port) Decode(ctx *DecodeContext) error { // nolint: revive
+	var port string
+	if err := ctx.Scan.PopValueInto("port", &port); err != nil {
+		return err
+	}
+	n, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return errors.Errorf("expected a port number from 0 to 65535 but got %q", port)
+	}
+	*p = Port(n)
+	return nil
+}
+
+// HostPort is a network address in the form "host:port", as accepted by net.Dial.
+type HostPort string
+
+// ConventionalTags implements ConventionalType.
+func (HostPort) ConventionalTags() string { return `placeholder:"HOST:PORT"` }
+
+func (h *HostPort) Decode(ctx *DecodeContext) error { // nolint: revive
+	var address string
+	if err := ctx.Scan.PopValueInto("address", &address); err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return errors.Errorf("invalid address %q, expected HOST:PORT", address)
+	}
+	*h = HostPort(address)
+	return nil
+}
+
+// URL is an absolute URL, such as "https://example.com/".
+type URL struct {
+	url.URL
+}
+
+// ConventionalTags implements ConventionalType.
+func (URL) ConventionalTags() string { return `placeholder:"URL"` }
+
+func (u *URL) Decode(ctx *DecodeContext) error { // nolint: revive
+	var raw string
+	if err := ctx.Scan.PopValueInto("url", &raw); err != nil {
+		return err
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if !parsed.IsAbs() {
+		return errors.Errorf("%q is not an absolute URL", raw)
+	}
+	u.URL = *parsed
+	return nil
+}
+
+// ExistingFile is the path of a file that must exist, or "-" for stdin. See the existingfile type.
+type ExistingFile string
+
+// ConventionalTags implements ConventionalType.
+func (ExistingFile) ConventionalTags() string { return `placeholder:"FILE"` }
+
+func (f *ExistingFile) Decode(ctx *DecodeContext) error { // nolint: revive
+	return existingFileMapper(nil)(ctx, reflect.ValueOf(f).Elem())
+}
+
+// ExistingDir is the path of a directory that must exist. See the existingdir type.
+type ExistingDir string
+
+// ConventionalTags implements ConventionalType.
+func (ExistingDir) ConventionalTags() string { return `placeholder:"DIR"` }
+
+func (d *ExistingDir) Decode(ctx *DecodeContext) error { // nolint: revive
+	return existingDirMapper(nil)(ctx, reflect.ValueOf(d).Elem())
+}
+
+// FilePath is a file or directory path, made absolute with "~" expanded to the user's home directory. See the
+// path type.
+type FilePath string
+
+// ConventionalTags implements ConventionalType.
+func (FilePath) ConventionalTags() string { return `placeholder:"PATH"` }
+
+func (p *FilePath) Decode(ctx *DecodeContext) error { // nolint: revive
+	return pathMapper(nil)(ctx, reflect.ValueOf(p).Elem())
+}
@@ -0,0 +1,149 @@
+package kong_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type region string
+
+func (region) ConventionalTags() string {
+	return `enum:"us,eu" default:"us" help:"Region to deploy to."`
+}
+
+func TestConventionalTypes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte("{}"), 0600))
+	type cli struct {
+		Port    kong.Port
+		Listen  kong.HostPort
+		Server  kong.URL
+		Config  kong.ExistingFile
+		Data    kong.ExistingDir
+		Output  kong.FilePath
+		Region  region
+		Regions []region `default:"eu"`
+	}
+	var flags cli
+	p := mustNew(t, &flags)
+	_, err := p.Parse([]string{
+		"--port=8080", "--listen=localhost:80", "--server=https://example.com/api",
+		"--config=" + file, "--data=" + dir, "--output=out.txt",
+	})
+	require.NoError(t, err)
+	require.Equal(t, kong.Port(8080), flags.Port)
+	require.Equal(t, kong.HostPort("localhost:80"), flags.Listen)
+	require.Equal(t, "https://example.com/api", flags.Server.String())
+	require.Equal(t, kong.ExistingFile(file), flags.Config)
+	require.Equal(t, kong.ExistingDir(dir), flags.Data)
+	output, err := filepath.Abs("out.txt")
+	require.NoError(t, err)
+	require.Equal(t, kong.FilePath(output), flags.Output)
+	require.Equal(t, region("us"), flags.Region)
+	require.Equal(t, []region{"eu"}, flags.Regions)
+
+	placeholders := map[string]string{}
+	for _, flag := range p.Model.Flags {
+		placeholders[flag.Name] = flag.PlaceHolder
+	}
+	require.Equal(t, map[string]string{
+		"help": "", "port": "PORT", "listen": "HOST:PORT", "server": "URL", "config": "FILE",
+		"data": "DIR", "output": "PATH", "region": "REGION", "regions": "",
+	}, placeholders)
+	require.Equal(t, "Region to deploy to.", p.Model.Flags[7].Help)
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--port=70000"}, `--port: expected a port number from 0 to 65535 but got "70000"`},
+		{[]string{"--listen=localhost"}, `--listen: invalid address "localhost", expected HOST:PORT`},
+		{[]string{"--server=example.com"}, `--server: "example.com" is not an absolute URL`},
+		{[]string{"--config=" + dir}, `--config: "` + dir + `" exists but is a directory`},
+		{[]string{"--data=" + file}, `--data: "` + file + `" exists but is not a directory`},
+		{[]string{"--region=apac"}, `--region must be one of "eu","us" but got "apac"`},
+	} {
+		_, err := mustNew(t, &cli{}).Parse(test.args)
+		require.EqualError(t, err, test.err, test.args)
+	}
+}
+
+func TestConventionalTypeOverriddenByTags(t *testing.T) {
+	var cli struct {
+		Region region `enum:"us,eu,apac" placeholder:"WHERE"`
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"--region=apac"})
+	require.NoError(t, err)
+	require.Equal(t, region("apac"), cli.Region)
+	require.Equal(t, "WHERE", p.Model.Flags[1].PlaceHolder)
+	require.Equal(t, "us", p.Model.Flags[1].Default)
+}
+
+type logFormat int
+
+const (
+	logFormatJSON logFormat = iota
+	logFormatText
+	logFormatLogfmt
+)
+
+func (logFormat) EnumValues() []string { return []string{"json", "text", "logfmt"} }
+
+type color string
+
+func (color) EnumValues() []string { return []string{"red", "green"} }
+
+func TestEnumType(t *testing.T) {
+	type cli struct {
+		Format  logFormat   `default:"text"`
+		Formats []logFormat `default:"json,logfmt"`
+		Color   color       `default:"red"`
+	}
+	var flags cli
+	p := mustNew(t, &flags)
+	require.Equal(t, "json,text,logfmt", p.Model.Flags[1].Enum)
+	require.Equal(t, "red,green", p.Model.Flags[3].Enum)
+	_, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, cli{Format: logFormatText, Formats: []logFormat{logFormatJSON, logFormatLogfmt}, Color: "red"}, flags)
+
+	flags = cli{}
+	_, err = mustNew(t, &flags).Parse([]string{"--format=logfmt", "--color=green"})
+	require.NoError(t, err)
+	require.Equal(t, logFormatLogfmt, flags.Format)
+	require.Equal(t, color("green"), flags.Color)
+
+	_, err = mustNew(t, &cli{}).Parse([]string{"--format=xml"})
+	require.EqualError(t, err, `--format: expected one of json, text, logfmt but got "xml"`)
+	_, err = mustNew(t, &cli{}).Parse([]string{"--color=blue"})
+	require.EqualError(t, err, `--color must be one of "green","red" but got "blue"`)
+}
+
+func TestEnumTypeWithoutDefault(t *testing.T) {
+	var cli struct {
+		Format  logFormat
+		Formats []logFormat
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, logFormatJSON, cli.Format)
+	_, err = p.Parse([]string{"--format=text", "--formats=logfmt,json"})
+	require.NoError(t, err)
+	require.Equal(t, logFormatText, cli.Format)
+	require.Equal(t, []logFormat{logFormatLogfmt, logFormatJSON}, cli.Formats)
+
+	// The zero value of a string enum is not one of its values, so it still needs a default.
+	var colors struct {
+		Color color
+	}
+	_, err = kong.New(&colors)
+	require.EqualError(t, err, "<anonymous struct>.Color: enum value is only valid if it is either required or has a valid default value")
+}
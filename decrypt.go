@@ -0,0 +1,45 @@
+package kong
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// A Decryptor decrypts the contents of a configuration file, eg. one encrypted with age or sops.
+type Decryptor interface {
+	// Decrypt returns the plaintext of data.
+	//
+	// Decryptors should return data unaltered if it is not encrypted, so that plaintext and encrypted
+	// configuration files can be mixed.
+	Decrypt(data []byte) ([]byte, error)
+}
+
+// DecryptorFunc is a function that implements the Decryptor interface.
+type DecryptorFunc func(data []byte) ([]byte, error)
+
+// Decrypt calls the function.
+func (d DecryptorFunc) Decrypt(data []byte) ([]byte, error) { return d(data) }
+
+// DecryptingLoader wraps a ConfigurationLoader such that each configuration file is decrypted before
+// being passed to loader.
+//
+// This allows secrets to be committed in encrypted configuration files and decrypted transparently at
+// parse time, eg.
+//
+//     kong.Configuration(kong.DecryptingLoader(kong.JSON, sopsDecryptor), "~/.myapp.enc.json")
+func DecryptingLoader(loader ConfigurationLoader, decryptor Decryptor) ConfigurationLoader {
+	return func(r io.Reader) (Resolver, error) {
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		plaintext, err := decryptor.Decrypt(data)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decrypt configuration")
+		}
+		return loader(bytes.NewReader(plaintext))
+	}
+}
@@ -0,0 +1,137 @@
+package kong
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DispatchResult is the outcome of Kong.Dispatch().
+type DispatchResult struct {
+	// Command that was selected, or "" if the command-line could not be parsed.
+	Command string
+	// Output written to Stdout and Stderr while parsing and running.
+	Stdout string
+	Stderr string
+	// Exit status requested with Kong.Exit(), eg. by --help, or -1 if Exit was not called.
+	Exit int
+	// Error from splitting, parsing or running the command, if any.
+	Err error
+	// Value returned by the command's Run() method, if any. See Context.Result.
+	Result interface{}
+}
+
+// Dispatch splits a command string, such as one received from a chat message or HTTP request, parses it
+// into a fresh target and runs the selected command, capturing all output.
+//
+// The target is built with the same options as this Kong, except that loaders registered with Indirection()
+// are dropped: the command string is untrusted remote input, and must not be able to read files or run
+// commands on the host. Calls to Kong.Exit(), such as from --help, stop processing rather than terminating
+// the process.
+//
+//     var cli CLI
+//     result := parser.Dispatch(`deploy --env=prod "my service"`, &cli)
+//     reply(result.Stdout)
+func (k *Kong) Dispatch(command string, target interface{}, binds ...interface{}) (result *DispatchResult) {
+	result = &DispatchResult{Exit: -1}
+	args, err := SplitCommandLine(command)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	options := append(k.options[:len(k.options):len(k.options)], OptionFunc(func(k *Kong) error {
+		k.indirections = nil
+		return nil
+	}))
+	parser, err := New(target, options...)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	// Output is detached before it's captured, in case a Run() method that timed out is still writing to it.
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	stdoutWriter, stderrWriter := &detachableWriter{w: stdout}, &detachableWriter{w: stderr}
+	parser.Stdout = stdoutWriter
+	parser.Stderr = stderrWriter
+	parser.Exit = func(status int) { panic(dispatchExit(status)) }
+	defer func() {
+		stdoutWriter.detach()
+		stderrWriter.detach()
+		if r := recover(); r != nil {
+			status, ok := r.(dispatchExit)
+			if !ok {
+				panic(r)
+			}
+			result.Exit = int(status)
+		}
+		result.Stdout = stdout.String()
+		result.Stderr = stderr.String()
+	}()
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Command = ctx.Command()
+	result.Err = ctx.Run(binds...)
+	result.Result = ctx.Result
+	return result
+}
+
+type dispatchExit int
+
+// SplitCommandLine splits a command-line into arguments, following the quoting rules of POSIX shells.
+//
+// Single quotes preserve their contents literally, double quotes allow backslash escapes, and unquoted
+// backslashes escape the following character.
+func SplitCommandLine(command string) ([]string, error) {
+	args := []string{}
+	arg := strings.Builder{}
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range command {
+		switch {
+		case escaped:
+			arg.WriteRune(r)
+			escaped = false
+
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escaped {
+		return nil, errors.Errorf("unterminated escape in %q", command)
+	}
+	if quote != 0 {
+		return nil, errors.Errorf("unterminated %c quote in %q", quote, command)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,81 @@
+package kong_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type dispatchCLI struct {
+	Deploy dispatchDeployCmd `cmd:"" help:"Deploy a service."`
+}
+
+type dispatchDeployCmd struct {
+	Env     string `default:"dev"`
+	Service string `arg:""`
+}
+
+func (d *dispatchDeployCmd) Run(app *kong.Kong) error {
+	fmt.Fprintf(app.Stdout, "deploying %s to %s\n", d.Service, d.Env)
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	var root dispatchCLI
+	p := mustNew(t, &root, kong.Name("bot"))
+
+	var cli dispatchCLI
+	result := p.Dispatch(`deploy --env=prod "my service"`, &cli)
+	require.NoError(t, result.Err)
+	require.Equal(t, "deploy <service>", result.Command)
+	require.Equal(t, "deploying my service to prod\n", result.Stdout)
+	require.Equal(t, -1, result.Exit)
+
+	cli = dispatchCLI{}
+	result = p.Dispatch(`deploy --help`, &cli)
+	require.NoError(t, result.Err)
+	require.Equal(t, 0, result.Exit)
+	require.Contains(t, result.Stdout, "Usage: bot deploy <service>")
+
+	cli = dispatchCLI{}
+	result = p.Dispatch(`deploy`, &cli)
+	require.EqualError(t, result.Err, `expected "<service>"`)
+
+	result = p.Dispatch(`deploy "unterminated`, &cli)
+	require.EqualError(t, result.Err, `unterminated " quote in "deploy \"unterminated"`)
+}
+
+func TestDispatchIgnoresIndirections(t *testing.T) {
+	t.Setenv("KONG_DISPATCH_SECRET", "s3cr3t")
+	var root dispatchCLI
+	p := mustNew(t, &root, kong.Indirection("env", kong.EnvIndirection))
+
+	var cli dispatchCLI
+	result := p.Dispatch(`deploy --env=env://KONG_DISPATCH_SECRET env://KONG_DISPATCH_SECRET`, &cli)
+	require.NoError(t, result.Err)
+	require.Equal(t, "deploying env://KONG_DISPATCH_SECRET to env://KONG_DISPATCH_SECRET\n", result.Stdout)
+
+	_, err := p.Parse([]string{"deploy", "env://KONG_DISPATCH_SECRET"})
+	require.NoError(t, err)
+	require.Equal(t, "s3cr3t", root.Deploy.Service)
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected []string
+	}{
+		{``, []string{}},
+		{`a b  c`, []string{"a", "b", "c"}},
+		{`a "b c" 'd e'`, []string{"a", "b c", "d e"}},
+		{`a\ b "c\"d" 'e\f'`, []string{"a b", `c"d`, `e\f`}},
+		{`--flag="" x`, []string{"--flag=", "x"}},
+	} {
+		actual, err := kong.SplitCommandLine(test.input)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, actual, test.input)
+	}
+}
@@ -0,0 +1,360 @@
+package kong
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// A DocRenderer renders the reference documentation of a command in a markup language, for WriteDocs()
+// and WriteDocPages(). MarkdownRenderer and AsciiDocRenderer are provided.
+type DocRenderer interface {
+	// Extension of pages, eg. ".md".
+	Extension() string
+	// Render the documentation of a command.
+	Render(w io.Writer, doc *CommandDoc) error
+}
+
+// CommandDoc is the documentation of the application or one of its commands, for a DocRenderer.
+type CommandDoc struct {
+	Node *Node
+	// Heading level of the command, from 1.
+	Level int
+	// Command path, eg. "myapp serve".
+	Path string
+	// Identifier of the command for links, eg. "myapp-serve", which is also the name of its page.
+	ID string
+	// Help followed by detailed help.
+	Help string
+	// Usage of the command, eg. "myapp serve [flags] [<root>]".
+	Usage     string
+	Arguments []*ValueDoc
+	// Flags of the command, and those it inherits from its ancestors, grouped and ordered as they are by
+	// help.
+	Flags          []*FlagGroupDoc
+	InheritedFlags []*FlagGroupDoc
+	// Subcommands, grouped by their group:"" and category:"" tags and ordered as they are by help.
+	Commands []*CommandGroupDoc
+	Examples []string
+}
+
+// FlagGroupDoc is a group of flags. Flags without a group:"" tag come first, in a group without a title.
+type FlagGroupDoc struct {
+	Title       string
+	Description string
+	Flags       []*ValueDoc
+}
+
+// CommandGroupDoc is a group or category of subcommands. Commands without either come first, in a group
+// without a title.
+type CommandGroupDoc struct {
+	Title       string
+	Description string
+	Commands    []*CommandLink
+}
+
+// ValueDoc is the documentation of a positional argument or flag.
+type ValueDoc struct {
+	Value *Value
+	// As used on the command-line, eg. "[<root>]" or "-p, --port=PORT".
+	Name string
+	// Help on a single line.
+	Help    string
+	Enum    []string
+	Default string
+	Env     string
+}
+
+// CommandLink links to the documentation of a subcommand.
+type CommandLink struct {
+	Path string
+	ID   string
+	// Summary of the help of the command.
+	Help string
+	// Page documenting the command, eg. "myapp-serve.md", or empty if it's in the same document.
+	Page string
+}
+
+// WriteDocs writes reference documentation for the application and all of its visible commands as a
+// single document, rendered by renderer, with a section per command.
+func (k *Kong) WriteDocs(w io.Writer, renderer DocRenderer) error {
+	var write func(node *Node, level int) error
+	write = func(node *Node, level int) error {
+		if err := renderer.Render(w, k.newCommandDoc(node, level, "")); err != nil {
+			return err
+		}
+		for _, child := range k.docChildren(node) {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+			if err := write(child, 2); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return write(k.Model.Node, 1)
+}
+
+// WriteDocPages writes a page of reference documentation for the application and each of its visible
+// commands to dir, rendered by renderer. Pages are named after the command path, eg. "myapp.md" and
+// "myapp-serve.md", and linked to each other.
+func (k *Kong) WriteDocPages(dir string, renderer DocRenderer) error {
+	if err := os.MkdirAll(dir, 0755); err != nil { // nolint: gosec
+		return err
+	}
+	var write func(node *Node) error
+	write = func(node *Node) error {
+		w := &strings.Builder{}
+		if err := renderer.Render(w, k.newCommandDoc(node, 1, renderer.Extension())); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, docPageName(node)+renderer.Extension()), []byte(w.String()), 0644); err != nil { // nolint: gosec
+			return err
+		}
+		for _, child := range k.docChildren(node) {
+			if err := write(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return write(k.Model.Node)
+}
+
+// Document node, linking to pages with extension, or within the same document if extension is empty.
+func (k *Kong) newCommandDoc(node *Node, level int, extension string) *CommandDoc {
+	path := docCommandPath(node)
+	doc := &CommandDoc{
+		Node:     node,
+		Level:    level,
+		Path:     path,
+		ID:       docPageName(node),
+		Help:     strings.TrimSpace(node.Help + "\n\n" + node.Detail),
+		Usage:    strings.Join(append([]string{path}, docSynopsis(node)...), " "),
+		Examples: node.Tag.Examples,
+	}
+	for _, positional := range node.Positional {
+		doc.Arguments = append(doc.Arguments, newValueDoc(positional, positional.Summary()))
+	}
+	flags, inherited := k.docFlags(node)
+	doc.Flags = newFlagGroupDocs(flags)
+	doc.InheritedFlags = newFlagGroupDocs(inherited)
+	for _, group := range k.docCommands(node) {
+		groupDoc := &CommandGroupDoc{Title: group.Title, Description: group.Description}
+		for _, child := range group.Commands {
+			link := &CommandLink{Path: docCommandPath(child), ID: docPageName(child), Help: completionHelp(child.Help)}
+			if extension != "" {
+				link.Page = link.ID + extension
+			}
+			groupDoc.Commands = append(groupDoc.Commands, link)
+		}
+		doc.Commands = append(doc.Commands, groupDoc)
+	}
+	return doc
+}
+
+func newFlagGroupDocs(groups []docFlagGroup) []*FlagGroupDoc {
+	out := []*FlagGroupDoc{}
+	for _, group := range groups {
+		groupDoc := &FlagGroupDoc{Title: group.Title, Description: group.Description}
+		for _, flag := range group.Flags {
+			groupDoc.Flags = append(groupDoc.Flags, newValueDoc(flag.Value, docFlagSpec(flag)))
+		}
+		out = append(out, groupDoc)
+	}
+	return out
+}
+
+func newValueDoc(value *Value, name string) *ValueDoc {
+	return &ValueDoc{
+		Value:   value,
+		Name:    name,
+		Help:    strings.Join(strings.Fields(value.Help), " "),
+		Enum:    docEnum(value),
+		Default: docDefault(value),
+		Env:     value.Tag.Env,
+	}
+}
+
+// Helpers shared by the generators of reference documentation, such as man pages and Markdown.
+
+// The title of a group as a heading, without the trailing colon of help, eg. "Output" for "Output:".
+func docGroupTitle(title string) string {
+	return strings.TrimSuffix(strings.TrimSpace(title), ":")
+}
+
+// The heading of a group of flags or commands, its title or fallback if it has none.
+func docGroupHeading(title, fallback string) string {
+	if title == "" {
+		return fallback
+	}
+	return title
+}
+
+// The heading of a group of inherited flags.
+func docInheritedHeading(title string) string {
+	if title == "" {
+		return "Inherited flags"
+	}
+	return title + " (inherited)"
+}
+
+// The name of the page documenting node, eg. "myapp-serve".
+func docPageName(node *Node) string {
+	return strings.ReplaceAll(docCommandPath(node), " ", "-")
+}
+
+// The command path of node, eg. "myapp serve".
+func docCommandPath(node *Node) string {
+	parts := []string{}
+	for n := node; n != nil; n = n.Parent {
+		parts = append([]string{n.Name}, parts...)
+	}
+	return strings.Join(parts, " ")
+}
+
+// The synopsis of node after its command path, eg. "[flags] <root>".
+func docSynopsis(node *Node) []string {
+	synopsis := []string{}
+	if len(node.AllFlags(true)) > 0 {
+		synopsis = append(synopsis, "[flags]")
+	}
+	for _, positional := range node.Positional {
+		synopsis = append(synopsis, positional.Summary())
+	}
+	if len(completionChildren(node)) > 0 {
+		synopsis = append(synopsis, "<command>")
+	}
+	return synopsis
+}
+
+// The visible commands below node, ordered by HelpOptions.SortCommands.
+func (k *Kong) docChildren(node *Node) []*Node {
+	return k.helpOptions.sortCommands(completionChildren(node))
+}
+
+// Flags in a group, or without one if Title is empty.
+type docFlagGroup struct {
+	Title       string
+	Description string
+	Flags       []*Flag
+}
+
+// The visible flags of node itself, and those it inherits from its ancestors, grouped and ordered as they
+// are by help.
+func (k *Kong) docFlags(node *Node) (flags, inherited []docFlagGroup) {
+	own := []*Flag{}
+	for _, flag := range node.Flags {
+		if !flag.Hidden {
+			own = append(own, flag)
+		}
+	}
+	flags = k.docFlagGroups([][]*Flag{own})
+	if node.Parent != nil {
+		inherited = k.docFlagGroups(node.Parent.AllFlags(true))
+	}
+	return flags, inherited
+}
+
+func (k *Kong) docFlagGroups(levels [][]*Flag) []docFlagGroup {
+	out := []docFlagGroup{}
+	for _, group := range collectFlagGroups(k.helpOptions.sortFlagGroups(levels)) {
+		flags := docFlagGroup{}
+		if group.Metadata.Key != "" {
+			flags.Title, flags.Description = docGroupTitle(group.Metadata.Title), group.Metadata.Description
+		}
+		for _, level := range group.Flags {
+			flags.Flags = append(flags.Flags, level...)
+		}
+		out = append(out, flags)
+	}
+	return out
+}
+
+// Commands in a group or category, or in neither if Title is empty.
+type docCommandGroup struct {
+	Title       string
+	Description string
+	Commands    []*Node
+}
+
+// The visible commands below node, grouped by their group and category and ordered as they are by help.
+func (k *Kong) docCommands(node *Node) []docCommandGroup {
+	out := []docCommandGroup{}
+	uncategorised, categories := collectCommandCategories(node, k.docChildren(node))
+	for _, group := range collectCommandGroups(uncategorised) {
+		commands := docCommandGroup{Commands: group.Commands}
+		if group.Metadata.Key != "" {
+			commands.Title, commands.Description = docGroupTitle(group.Metadata.Title), group.Metadata.Description
+		}
+		out = append(out, commands)
+	}
+	for _, category := range categories {
+		out = append(out, docCommandGroup{Title: category.Title, Commands: category.Commands})
+	}
+	return out
+}
+
+// The names of flag as used on the command-line, eg. "-p" and "--port", or "--[no-]color" if negatable.
+func docFlagNames(flag *Flag) []string {
+	names := []string{}
+	if short := flag.shortName(); short != "" {
+		names = append(names, "-"+short)
+	}
+	if !flag.Tag.ShortOnly {
+		if flag.Tag.Negatable {
+			names = append(names, "--[no-]"+flag.Name)
+		} else {
+			names = append(names, "--"+flag.Name)
+		}
+	}
+	return names
+}
+
+// The placeholder of the value of flag and the separator between it and the flag, or "" if it has no value.
+func docFlagPlaceHolder(flag *Flag) (sep, placeholder string) {
+	if flag.IsBool() || flag.IsCounter() {
+		return "", ""
+	}
+	placeholder = flag.PlaceHolder
+	switch {
+	case flag.IsMap():
+		placeholder = "KEY=VALUE"
+	case placeholder == "":
+		placeholder = strings.ToUpper(flag.Name)
+	}
+	if flag.Tag.ShortOnly {
+		return " ", placeholder
+	}
+	return "=", placeholder
+}
+
+// The flag as it's used on the command-line, eg. "-p, --port=PORT".
+func docFlagSpec(flag *Flag) string {
+	sep, placeholder := docFlagPlaceHolder(flag)
+	return strings.Join(docFlagNames(flag), ", ") + sep + placeholder
+}
+
+// The default of value, with its unit if any, eg. "30s".
+func docDefault(value *Value) string {
+	def := value.Default
+	if def != "" && value.Unit != "" && isNumber(def) {
+		def += value.Unit
+	}
+	return def
+}
+
+// The enum values of value, if any.
+func docEnum(value *Value) []string {
+	enums := []string{}
+	if value.Enum == "" {
+		return enums
+	}
+	for _, enum := range strings.Split(value.Enum, ",") {
+		enums = append(enums, strings.TrimSpace(enum))
+	}
+	return enums
+}
@@ -0,0 +1,70 @@
+package kong
+
+import (
+	"github.com/pkg/errors"
+)
+
+// DuplicateFlagPolicy determines what happens when a flag that holds a single value is given more than once.
+//
+// Slice, map and counter flags accumulate their values, so are never duplicates.
+type DuplicateFlagPolicy int
+
+// Duplicate flag policies.
+const (
+	// The last value given wins. This is the default.
+	DuplicateFlagsLastWins DuplicateFlagPolicy = iota
+	// The first value given wins, and later values are parsed but discarded.
+	DuplicateFlagsFirstWins
+	// Giving the flag more than once is an error.
+	DuplicateFlagsError
+)
+
+var duplicatePolicies = map[string]DuplicateFlagPolicy{
+	"last":  DuplicateFlagsLastWins,
+	"first": DuplicateFlagsFirstWins,
+	"error": DuplicateFlagsError,
+}
+
+// DuplicateFlags sets the policy for flags that hold a single value and are given more than once.
+//
+// The policy of an individual flag can be overridden with the "duplicates" tag, eg.
+//
+//     Output string `duplicates:"error"`
+func DuplicateFlags(policy DuplicateFlagPolicy) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.duplicateFlags = policy
+		return nil
+	})
+}
+
+// The duplicate policy for flag.
+func (c *Context) duplicatePolicy(flag *Flag) DuplicateFlagPolicy {
+	if policy, ok := duplicatePolicies[flag.Tag.Duplicates]; ok {
+		return policy
+	}
+	return c.duplicateFlags
+}
+
+// Parse a repeated occurrence of flag, whose value has already been parsed, according to its duplicate policy.
+//
+// Returns false if the flag should be parsed as usual.
+func (c *Context) parseDuplicateFlag(flag *Flag) (bool, error) {
+	previous, seen := c.values[flag.Value]
+	if !seen || flag.IsCumulative() || flag.IsCounter() {
+		return false, nil
+	}
+	switch c.duplicatePolicy(flag) {
+	case DuplicateFlagsError:
+		return true, errors.Errorf("%s can only be specified once", flag.ShortSummary())
+
+	case DuplicateFlagsFirstWins:
+		// The value must still be consumed, so parse it into a scratch value that is then discarded.
+		delete(c.values, flag.Value)
+		err := flag.Parse(c.scan, c.getValue(flag.Value))
+		c.values[flag.Value] = previous
+		return true, err
+
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,61 @@
+package kong_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestDuplicateFlags(t *testing.T) {
+	type cli struct {
+		Output  string   `short:"o"`
+		Level   string   `duplicates:"error"`
+		Tags    []string `short:"t"`
+		Verbose int      `short:"v" type:"counter"`
+	}
+	for _, test := range []struct {
+		name    string
+		options []kong.Option
+		args    []string
+		output  string
+		err     string
+	}{
+		{name: "LastWins", args: []string{"-o", "a", "--output=b"}, output: "b"},
+		{name: "FirstWins", options: []kong.Option{kong.DuplicateFlags(kong.DuplicateFlagsFirstWins)}, args: []string{"-o", "a", "--output=b"}, output: "a"},
+		{name: "Error", options: []kong.Option{kong.DuplicateFlags(kong.DuplicateFlagsError)}, args: []string{"-o", "a", "--output=b"}, err: "--output can only be specified once"},
+		{name: "TagError", args: []string{"--level=a", "--level=b"}, err: "--level can only be specified once"},
+		{name: "Cumulative", options: []kong.Option{kong.DuplicateFlags(kong.DuplicateFlagsError)}, args: []string{"-t", "a", "-t", "b", "-vv", "-v"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var c cli
+			_, err := mustNew(t, &c, test.options...).Parse(test.args)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.output, c.Output)
+		})
+	}
+}
+
+func TestDuplicateFlagsFirstWinsConsumesValue(t *testing.T) {
+	var cli struct {
+		Output string   `short:"o" duplicates:"first"`
+		Files  []string `arg:""`
+	}
+	_, err := mustNew(t, &cli).Parse([]string{"-o", "a", "-o", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, "a", cli.Output)
+	require.Equal(t, []string{"c"}, cli.Files)
+}
+
+func TestInvalidDuplicatesTag(t *testing.T) {
+	var cli struct {
+		Output string `duplicates:"never"`
+	}
+	_, err := kong.New(&cli)
+	require.EqualError(t, err, `<anonymous struct>.Output: invalid duplicates policy "never", must be one of last, first or error`)
+}
@@ -0,0 +1,70 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteEnvExample writes a documented ".env.example" template for every environment variable bound to a
+// flag or positional argument with the "env" tag. Hidden and internal flags, flags of hidden commands and
+// flags that trigger an action, such as --version, are left out.
+//
+// Each variable is preceded by its help as a comment, and is set to its default value, if any:
+//
+//     # Port to listen on.
+//     APP_PORT=8080
+func (k *Kong) WriteEnvExample(w io.Writer) error {
+	seen := map[string]bool{}
+	first := true
+	return Visit(k.Model, func(node Visitable, next Next) error {
+		switch node := node.(type) {
+		case *Node:
+			// Hidden commands are left out, as they are from help.
+			if node.Hidden {
+				return nil
+			}
+		case *Flag:
+			// As are hidden and internal flags, and flags that trigger an action such as --version.
+			if node.Hidden || isActionFlag(node) {
+				return nil
+			}
+		}
+		value, ok := node.(*Value)
+		if !ok || value.Tag.Env == "" || seen[value.Tag.Env] {
+			return next(nil)
+		}
+		seen[value.Tag.Env] = true
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		comments := []string{}
+		if value.Help != "" {
+			comments = append(comments, strings.Split(value.Help, "\n")...)
+		}
+		if value.Enum != "" {
+			enums := []string{}
+			for _, enum := range strings.Split(value.Enum, ",") {
+				enums = append(enums, strings.TrimSpace(enum))
+			}
+			comments = append(comments, "One of: "+strings.Join(enums, ", ")+".")
+		}
+		if value.Required {
+			comments = append(comments, "Required.")
+		}
+		for _, comment := range comments {
+			fmt.Fprintf(w, "# %s\n", comment)
+		}
+		_, err := fmt.Fprintf(w, "%s=%s\n", value.Tag.Env, envExampleValue(value.Default))
+		return next(err)
+	})
+}
+
+// Quote values that would otherwise be misinterpreted by .env parsers.
+func envExampleValue(value string) string {
+	if strings.ContainsAny(value, " \t#\"'$\\") {
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
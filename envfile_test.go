@@ -0,0 +1,55 @@
+package kong_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestWriteEnvExample(t *testing.T) {
+	var cli struct {
+		Port    int    `env:"APP_PORT" default:"8080" help:"Port to listen on."`
+		Level   string `env:"APP_LEVEL" default:"info" enum:"debug,info" help:"Log level."`
+		Verbose bool
+		Serve   struct {
+			Root  string `env:"APP_ROOT" required:"" help:"Root directory."`
+			Motto string `env:"APP_MOTTO" default:"hello world"`
+		} `cmd:""`
+	}
+	w := &bytes.Buffer{}
+	err := mustNew(t, &cli).WriteEnvExample(w)
+	require.NoError(t, err)
+	expected := `# Port to listen on.
+APP_PORT=8080
+
+# Log level.
+# One of: debug, info.
+APP_LEVEL=info
+
+# Root directory.
+# Required.
+APP_ROOT=
+
+APP_MOTTO="hello world"
+`
+	require.Equal(t, expected, w.String())
+}
+
+func TestWriteEnvExampleSkipsUndocumentedFlags(t *testing.T) {
+	var cli struct {
+		Version kong.VersionFlag
+		Debug   bool `hidden:""`
+		FD      int  `internal:""`
+		Port    int  `default:"8080"`
+		Admin   struct {
+			Token string
+		} `cmd:"" hidden:""`
+	}
+	w := &bytes.Buffer{}
+	err := mustNew(t, &cli, kong.DefaultEnvars("APP")).WriteEnvExample(w)
+	require.NoError(t, err)
+	require.Equal(t, "APP_PORT=8080\n", w.String())
+}
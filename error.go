@@ -1,5 +1,31 @@
 package kong
 
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrHelpRequested is returned by Kong.Parse() when help, or other documentation such as the
+	// configuration schema or completion candidates, was printed and the NoExit() option is set.
+	ErrHelpRequested = errors.New("help requested")
+	// ErrVersionRequested is returned by Kong.Parse() when the version was printed and the NoExit()
+	// option is set.
+	ErrVersionRequested = errors.New("version requested")
+)
+
+// ExitError is returned when the NoExit() option is set in place of exiting with a status other than
+// that of help or the version, eg. by ReExec() on Windows once the re-executed application completes.
+type ExitError struct {
+	Status int
+}
+
+func (e *ExitError) Error() string { return fmt.Sprintf("exit status %d", e.Status) }
+
+// ExitCode returns the status Kong would have exited with.
+func (e *ExitError) ExitCode() int { return e.Status }
+
 // ParseError is the error type returned by Kong.Parse().
 //
 // It contains the parse Context that triggered the error.
@@ -10,3 +36,6 @@ type ParseError struct {
 
 // Cause returns the original cause of the error.
 func (p *ParseError) Cause() error { return p.error }
+
+// Unwrap returns the original cause of the error.
+func (p *ParseError) Unwrap() error { return p.error }
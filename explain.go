@@ -0,0 +1,103 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Explain adds a hidden "explain" command that describes how a command-line would be parsed.
+//
+// eg.
+//
+//     app explain -- server start --port=8080 /srv
+//
+// Each token is traced against the grammar, and the resulting decisions are printed: which commands
+// and arguments matched, which flags and positionals consumed which values, and the first error
+// that parsing or validation would produce. Nothing is executed, although the grammar's fields will
+// have been reset and populated by the time explain returns.
+func Explain() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.dynamicCommands = append(k.dynamicCommands, &dynamicCommand{
+			name: "explain",
+			help: "Explain how a command-line would be parsed, without executing it.",
+			cmd:  &explainCmd{},
+			tags: []string{`hidden:""`},
+		})
+		return nil
+	})
+}
+
+type explainCmd struct {
+	Args []string `arg:"" optional:"" passthrough:"" help:"Command-line to explain."`
+}
+
+func (e *explainCmd) Run(ctx *Context) error {
+	k := ctx.Kong
+	args := append([]string(nil), e.Args...)
+	return explain(k, k.Stdout, args)
+}
+
+func explain(k *Kong, w io.Writer, args []string) error {
+	fmt.Fprintf(w, "command-line: %s\n", strings.Join(args, " "))
+	ctx, err := Trace(k, args)
+	if err != nil {
+		return err
+	}
+	for _, path := range ctx.Path {
+		switch {
+		case path.App != nil:
+			continue
+
+		case path.Command != nil:
+			fmt.Fprintf(w, "  command %s\n", path.Command.Name)
+
+		case path.Argument != nil:
+			fmt.Fprintf(w, "  argument <%s> consumed %s\n", path.Argument.Name, explainValue(ctx, path))
+
+		case path.Positional != nil:
+			fmt.Fprintf(w, "  positional %s consumed %s\n", path.Positional.ShortSummary(), explainValue(ctx, path))
+
+		case path.Flag != nil:
+			fmt.Fprintf(w, "  flag %s consumed %s\n", path.Flag.ShortSummary(), explainValue(ctx, path))
+		}
+	}
+	if ctx.Error != nil {
+		fmt.Fprintf(w, "parse error: %s\n", ctx.Error)
+		return nil
+	}
+	stages := []struct {
+		name string
+		fn   func() error
+	}{
+		{"reset", ctx.Reset},
+		{"resolve", ctx.Resolve},
+		{"apply", func() error { _, err := ctx.Apply(); return err }},
+		{"validate", ctx.Validate},
+	}
+	for _, stage := range stages {
+		if err := stage.fn(); err != nil {
+			fmt.Fprintf(w, "%s error: %s\n", stage.name, err)
+			return nil
+		}
+	}
+	for _, path := range ctx.Path {
+		if path.Resolved {
+			fmt.Fprintf(w, "  flag %s resolved to %s\n", path.Flag.ShortSummary(), explainValue(ctx, path))
+		}
+	}
+	if command := ctx.Command(); command != "" {
+		fmt.Fprintf(w, "ok: would run %q\n", command)
+	} else {
+		fmt.Fprintln(w, "ok")
+	}
+	return nil
+}
+
+func explainValue(ctx *Context, path *Path) string {
+	value := ctx.Value(path)
+	if !value.IsValid() {
+		return "nothing"
+	}
+	return fmt.Sprintf("%q", fmt.Sprintf("%v", value.Interface()))
+}
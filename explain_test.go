@@ -0,0 +1,66 @@
+package kong_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type explainCLI struct {
+	Debug bool `help:"Enable debug mode."`
+
+	Server struct {
+		Port int    `help:"Port to listen on." required:""`
+		Root string `arg:"" help:"Root directory."`
+	} `cmd:""`
+}
+
+func TestExplain(t *testing.T) {
+	w := &bytes.Buffer{}
+	newParser := func() *kong.Kong {
+		w.Reset()
+		return mustNew(t, &explainCLI{}, kong.Explain(), kong.Writers(w, w))
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		ctx, err := newParser().Parse([]string{"explain", "--", "--debug", "server", "--port=8080", "/srv"})
+		require.NoError(t, err)
+		require.Equal(t, "explain <args>", ctx.Command())
+		require.NoError(t, ctx.Run())
+		require.Equal(t, `command-line: --debug server --port=8080 /srv
+  flag --debug consumed "true"
+  command server
+  flag --port consumed "8080"
+  positional <root> consumed "/srv"
+ok: would run "server <root>"
+`, w.String())
+	})
+
+	t.Run("ValidationError", func(t *testing.T) {
+		ctx, err := newParser().Parse([]string{"explain", "server", "/srv"})
+		require.NoError(t, err)
+		require.NoError(t, ctx.Run())
+		require.Equal(t, `command-line: server /srv
+  command server
+  positional <root> consumed "/srv"
+validate error: missing flags: --port=INT
+`, w.String())
+	})
+
+	t.Run("ParseError", func(t *testing.T) {
+		ctx, err := newParser().Parse([]string{"explain", "--", "server", "--port=x"})
+		require.NoError(t, err)
+		require.NoError(t, ctx.Run())
+		require.Equal(t, `command-line: server --port=x
+  command server
+parse error: --port: expected a valid 64 bit int but got "x"
+`, w.String())
+	})
+
+	t.Run("Hidden", func(t *testing.T) {
+		require.True(t, newParser().Model.Children[1].Hidden)
+	})
+}
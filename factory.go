@@ -0,0 +1,57 @@
+package kong
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// A CommandFactory creates a command, a pointer to a struct, for fields tagged with use:"<name>".
+type CommandFactory func() interface{}
+
+var (
+	commandFactoriesLock sync.Mutex
+	commandFactories     = map[string]CommandFactory{}
+)
+
+// RegisterCommandFactory registers a factory for commands under name, typically from the init()
+// function of the package implementing the command.
+//
+// Grammar fields of an interface type tagged with use:"<name>" are set to a new command created by the
+// factory each time a parser is built, which allows large applications to be composed of independently
+// compiled feature packages. eg.
+//
+//     func init() {
+//         kong.RegisterCommandFactory("migrate", func() interface{} { return &MigrateCmd{} })
+//     }
+//
+//     var cli struct {
+//         Migrate interface{} `use:"migrate" help:"Run database migrations."`
+//     }
+//
+// It panics if a factory is already registered under name.
+func RegisterCommandFactory(name string, factory CommandFactory) {
+	commandFactoriesLock.Lock()
+	defer commandFactoriesLock.Unlock()
+	if factory == nil {
+		panic("kong: command factory " + name + " is nil")
+	}
+	if _, ok := commandFactories[name]; ok {
+		panic("kong: command factory " + name + " is already registered")
+	}
+	commandFactories[name] = factory
+}
+
+func newFactoryCommand(name string) (interface{}, error) {
+	commandFactoriesLock.Lock()
+	factory, ok := commandFactories[name]
+	commandFactoriesLock.Unlock()
+	if !ok {
+		return nil, errors.Errorf("unknown command factory %q, use kong.RegisterCommandFactory()", name)
+	}
+	cmd := factory()
+	if cmd == nil {
+		return nil, errors.Errorf("command factory %q returned nil", name)
+	}
+	return cmd, nil
+}
@@ -0,0 +1,49 @@
+package kong_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type migrateCmd struct {
+	Steps int `default:"1" help:"Number of migrations to apply."`
+}
+
+func init() {
+	kong.RegisterCommandFactory("test-migrate", func() interface{} { return &migrateCmd{} })
+	kong.RegisterCommandFactory("test-not-a-struct", func() interface{} { return "migrate" })
+}
+
+func TestCommandFactory(t *testing.T) {
+	var cli struct {
+		Migrate interface{} `use:"test-migrate" help:"Run migrations."`
+		Other   interface{} `use:"test-migrate" name:"rollback"`
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"migrate", "--steps=3"})
+	require.NoError(t, err)
+	require.Equal(t, "migrate", ctx.Command())
+	require.Equal(t, &migrateCmd{Steps: 3}, cli.Migrate)
+	require.Equal(t, &migrateCmd{Steps: 1}, cli.Other)
+	require.NotSame(t, cli.Migrate, cli.Other)
+	require.Equal(t, "Run migrations.", p.Model.Children[0].Help)
+
+	var unknown struct {
+		Migrate interface{} `use:"missing"`
+	}
+	_, err = kong.New(&unknown)
+	require.EqualError(t, err, `<anonymous struct>.Migrate: unknown command factory "missing", use kong.RegisterCommandFactory()`)
+
+	var invalid struct {
+		Migrate interface{} `use:"test-not-a-struct"`
+	}
+	_, err = kong.New(&invalid)
+	require.EqualError(t, err, "<anonymous struct>.Migrate: implementation of interface {} must be a pointer to a struct but got string")
+
+	require.Panics(t, func() {
+		kong.RegisterCommandFactory("test-migrate", func() interface{} { return &migrateCmd{} })
+	})
+}
@@ -0,0 +1,66 @@
+package kong
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FeatureGate makes flags and commands tagged with feature:"<name>" available only if enabled(name)
+// returns true, eg. to gate beta or licensed functionality.
+//
+// By default the flags and commands of disabled features are omitted from the grammar entirely. See
+// HideDisabledFeatures() to keep them in the grammar.
+//
+//     kong.FeatureGate(func(feature string) bool { return license.Allows(feature) })
+func FeatureGate(enabled func(feature string) bool) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.featureEnabled = enabled
+		k.ignoreFields = append(k.ignoreFields, func(parent reflect.Type, field reflect.StructField, tag *Tag) bool {
+			return !k.hideDisabledFeatures && !k.isFeatureEnabled(tag)
+		})
+		k.postBuildOptions = append(k.postBuildOptions, OptionFunc(func(k *Kong) error {
+			return Visit(k.Model, func(node Visitable, next Next) error {
+				switch node := node.(type) {
+				case *Node:
+					node.Hidden = node.Hidden || !k.isFeatureEnabled(node.Tag)
+				case *Flag:
+					node.Hidden = node.Hidden || !k.isFeatureEnabled(node.Tag)
+				}
+				return next(nil)
+			})
+		}))
+		return nil
+	})
+}
+
+// HideDisabledFeatures keeps the flags and commands of features disabled by FeatureGate() in the
+// grammar, but hidden, so that using them fails with an error naming the feature rather than as if
+// they did not exist.
+func HideDisabledFeatures() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.hideDisabledFeatures = true
+		return nil
+	})
+}
+
+func (k *Kong) isFeatureEnabled(tag *Tag) bool {
+	return tag == nil || tag.Feature == "" || k.featureEnabled == nil || k.featureEnabled(tag.Feature)
+}
+
+// Check that no flags or commands of disabled features are used.
+func (c *Context) checkFeatures() error {
+	if c.Kong.featureEnabled == nil {
+		return nil
+	}
+	for _, path := range c.Path {
+		switch {
+		case path.Flag != nil && !c.Kong.isFeatureEnabled(path.Flag.Tag):
+			return fmt.Errorf("--%s requires the feature %q, which is not enabled", path.Flag.Name, path.Flag.Tag.Feature)
+		case path.Command != nil && !c.Kong.isFeatureEnabled(path.Command.Tag):
+			return fmt.Errorf("%q requires the feature %q, which is not enabled", commandPath(path.Command), path.Command.Tag.Feature)
+		case path.Argument != nil && !c.Kong.isFeatureEnabled(path.Argument.Tag):
+			return fmt.Errorf("%q requires the feature %q, which is not enabled", commandPath(path.Argument), path.Argument.Tag.Feature)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package kong_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type featureCLI struct {
+	Fast  bool `feature:"beta-speed" help:"Go faster."`
+	Serve struct {
+		Port int `default:"8080"`
+	} `cmd:"" default:"1"`
+	Replicate struct {
+		Target string `arg:""`
+	} `cmd:"" feature:"enterprise" help:"Replicate data."`
+}
+
+func TestFeatureGate(t *testing.T) {
+	enabled := map[string]bool{}
+	gate := kong.FeatureGate(func(feature string) bool { return enabled[feature] })
+
+	var cli featureCLI
+	p := mustNew(t, &cli, gate)
+	_, err := p.Parse([]string{"--fast"})
+	require.EqualError(t, err, "unknown flag --fast")
+	_, err = p.Parse([]string{"replicate", "db"})
+	require.EqualError(t, err, `unexpected argument replicate`)
+
+	enabled["enterprise"] = true
+	enabled["beta-speed"] = true
+	cli = featureCLI{}
+	p = mustNew(t, &cli, gate)
+	ctx, err := p.Parse([]string{"--fast", "replicate", "db"})
+	require.NoError(t, err)
+	require.Equal(t, "replicate <target>", ctx.Command())
+	require.True(t, cli.Fast)
+}
+
+func TestHideDisabledFeatures(t *testing.T) {
+	var cli featureCLI
+	p := mustNew(t, &cli, kong.FeatureGate(func(feature string) bool { return false }), kong.HideDisabledFeatures())
+	_, err := p.Parse([]string{"--fast"})
+	require.EqualError(t, err, `--fast requires the feature "beta-speed", which is not enabled`)
+	_, err = p.Parse([]string{"replicate", "db"})
+	require.EqualError(t, err, `"replicate" requires the feature "enterprise", which is not enabled`)
+	require.True(t, p.Model.Children[1].Hidden)
+	require.True(t, p.Model.Flags[1].Hidden)
+	require.Equal(t, "fast", p.Model.Flags[1].Name)
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"go/doc"
 	"io"
+	"reflect"
+	"sort"
 	"strings"
 )
 
 const (
 	defaultIndent        = 2
 	defaultColumnPadding = 4
+	defaultMaxInlineEnum = 10
 )
 
 // Help flag.
@@ -19,12 +22,52 @@ type helpValue bool
 func (h helpValue) BeforeApply(ctx *Context) error {
 	options := ctx.Kong.helpOptions
 	options.Summary = false
-	err := ctx.Kong.help(options, ctx)
-	if err != nil {
+	if err := ctx.printHelp(options); err != nil {
 		return err
 	}
-	ctx.Kong.Exit(0)
-	return nil
+	return ctx.Kong.exitOr(ErrHelpRequested)
+}
+
+// Help flag that includes hidden flags and commands.
+type helpHiddenValue bool
+
+func (h helpHiddenValue) BeforeApply(ctx *Context) error {
+	options := ctx.Kong.helpOptions
+	options.Summary = false
+	options.ShowHidden = true
+	if err := ctx.printHelp(options); err != nil {
+		return err
+	}
+	return ctx.Kong.exitOr(ErrHelpRequested)
+}
+
+// HelpOutcome records that help was printed while parsing. See Context.HelpPrinted.
+type HelpOutcome struct {
+	// Node whose help was printed: the selected command or argument, or the application.
+	Node *Node
+	// Options the help was formatted with, eg. whether hidden flags and commands were shown.
+	Options HelpOptions
+	// Output written by the help printer.
+	Output string
+}
+
+// Print help for the context, recording the outcome in Context.HelpPrinted.
+func (c *Context) printHelp(options HelpOptions) error {
+	node := c.Selected()
+	if node == nil {
+		node = c.Model.Node
+	}
+	output := &bytes.Buffer{}
+	stdout := c.Kong.Stdout
+	// The width of stdout is preserved so help is still wrapped to the terminal.
+	c.Kong.Stdout = &widthWriter{Writer: io.MultiWriter(stdout, output), width: writerWidth(stdout)}
+	defer func() { c.Kong.Stdout = stdout }()
+	err := c.Kong.help(options, c)
+	if err == nil {
+		c.Kong.writeHints(c.Kong.Stdout, HintHelp, node, nil)
+	}
+	c.HelpPrinted = &HelpOutcome{Node: node, Options: options, Output: output.String()}
+	return err
 }
 
 // HelpOptions for HelpPrinters.
@@ -38,6 +81,9 @@ type HelpOptions struct {
 	// Write help in a more compact, but still fully-specified, form.
 	Compact bool
 
+	// Include hidden flags and commands.
+	ShowHidden bool
+
 	// Tree writes command chains in a tree structure instead of listing them separately.
 	Tree bool
 
@@ -52,10 +98,30 @@ type HelpOptions struct {
 	// Don't show the help associated with subcommands
 	NoExpandSubcommands bool
 
+	// SortFlags, if provided, orders flags within each level of the help output. It reports whether
+	// flag "a" should be displayed before flag "b". By default flags are displayed in declaration order.
+	SortFlags func(a, b *Flag) bool
+
+	// SortCommands, if provided, orders commands in the help output. It reports whether command "a"
+	// should be displayed before command "b". By default commands are displayed in declaration order.
+	SortCommands func(a, b *Node) bool
+
 	// Clamp the help wrap width to a value smaller than the terminal width.
 	// If this is set to a non-positive number, the terminal width is used; otherwise,
 	// the min of this value or the terminal width is used.
 	WrapUpperBound int
+
+	// Don't annotate flags with the flags they conflict with or require, from their xor and and groups.
+	NoConstraints bool
+
+	// Enums with more values than this are listed in columns beneath the help of their flag or positional
+	// argument, rather than inline. If zero, enums with more than 10 values are; if negative, enums are
+	// always inline.
+	MaxInlineEnum int
+
+	// Only list enums too large to be inline in help that includes hidden flags and commands, eg. with
+	// --help-hidden, and only give their number of values otherwise.
+	DeferLargeEnums bool
 }
 
 // Apply options to Kong as a configuration option.
@@ -70,6 +136,15 @@ type HelpProvider interface {
 	Help() string
 }
 
+// ContextHelpProvider can be implemented by commands/args to provide detailed help generated from the
+// parse Context, such as resolved flag values or bindings.
+//
+// It is checked when help is rendered, and takes precedence over HelpProvider.
+type ContextHelpProvider interface {
+	// This string is formatted by go/doc and thus has the same formatting rules.
+	Help(ctx *Context) string
+}
+
 // PlaceHolderProvider can be implemented by mappers to provide custom placeholder text.
 type PlaceHolderProvider interface {
 	PlaceHolder(flag *Flag) string
@@ -86,17 +161,29 @@ type HelpValueFormatter func(value *Value) string
 
 // DefaultHelpValueFormatter is the default HelpValueFormatter.
 func DefaultHelpValueFormatter(value *Value) string {
-	if value.Tag.Env == "" {
-		return value.Help
+	suffixes := []string{}
+	if value.Unit != "" {
+		suffixes = append(suffixes, "(in "+UnitName(value.Unit)+")")
+	}
+	if value.Tag.Env != "" {
+		suffixes = append(suffixes, "($"+value.Tag.Env+")")
 	}
-	suffix := "($" + value.Tag.Env + ")"
+	return appendHelpSuffix(value.Help, suffixes...)
+}
+
+// Append parenthesised suffixes to help, before any trailing full stop.
+func appendHelpSuffix(help string, suffixes ...string) string {
+	if len(suffixes) == 0 {
+		return help
+	}
+	suffix := strings.Join(suffixes, " ")
 	switch {
-	case strings.HasSuffix(value.Help, "."):
-		return value.Help[:len(value.Help)-1] + " " + suffix + "."
-	case value.Help == "":
+	case strings.HasSuffix(help, "."):
+		return help[:len(help)-1] + " " + suffix + "."
+	case help == "":
 		return suffix
 	default:
-		return value.Help + " " + suffix
+		return help + " " + suffix
 	}
 }
 
@@ -105,6 +192,11 @@ func DefaultShortHelpPrinter(options HelpOptions, ctx *Context) error {
 	w := newHelpWriter(ctx, options)
 	cmd := ctx.Selected()
 	app := ctx.Model
+	if ctx.Kong.envOnly {
+		// There's no command-line to describe.
+		w.Printf("Usage: %s", app.Name)
+		return w.Write(ctx.Stdout)
+	}
 	if cmd == nil {
 		w.Printf("Usage: %s%s", app.Name, app.Summary())
 		w.Printf(`Run "%s --help" for more information.`, app.Name)
@@ -132,10 +224,23 @@ func DefaultHelpPrinter(options HelpOptions, ctx *Context) error {
 
 func printApp(w *helpWriter, app *Application) {
 	if !w.NoAppSummary {
-		w.Printf("Usage: %s%s", app.Name, app.Summary())
+		if w.ctx.Kong.envOnly {
+			w.Printf("Usage: %s", app.Name)
+		} else {
+			w.Printf("Usage: %s%s", app.Name, app.Summary())
+		}
+	}
+	printNodeDetail(w, app.Node, !w.ShowHidden)
+	if len(app.Presets) > 0 && !w.Summary {
+		w.Print("")
+		w.Print("Presets:")
+		rows := [][2]string{}
+		for _, preset := range app.Presets {
+			rows = append(rows, [2]string{preset.Name, preset.Summary()})
+		}
+		writeTwoColumns(w.Indent(), rows)
 	}
-	printNodeDetail(w, app.Node, true)
-	cmds := app.Leaves(true)
+	cmds := app.Leaves(!w.ShowHidden)
 	if len(cmds) > 0 && app.HelpFlag != nil {
 		w.Print("")
 		if w.Summary {
@@ -150,7 +255,7 @@ func printCommand(w *helpWriter, app *Application, cmd *Command) {
 	if !w.NoAppSummary {
 		w.Printf("Usage: %s %s", app.Name, cmd.Summary())
 	}
-	printNodeDetail(w, cmd, true)
+	printNodeDetail(w, cmd, !w.ShowHidden)
 	if w.Summary && app.HelpFlag != nil {
 		w.Print("")
 		w.Printf(`Run "%s --help" for more information.`, cmd.FullPath())
@@ -165,9 +270,9 @@ func printNodeDetail(w *helpWriter, node *Node, hide bool) {
 	if w.Summary {
 		return
 	}
-	if node.Detail != "" {
+	if detail := w.nodeDetail(node); detail != "" {
 		w.Print("")
-		w.Wrap(node.Detail)
+		w.Wrap(detail)
 	}
 	if len(node.Positional) > 0 {
 		w.Print("")
@@ -175,7 +280,15 @@ func printNodeDetail(w *helpWriter, node *Node, hide bool) {
 		writePositionals(w.Indent(), node.Positional)
 	}
 	printFlags := func() {
-		if flags := node.AllFlags(true); len(flags) > 0 {
+		// Flags can't be set on the command-line with EnvOnly().
+		if w.ctx != nil && w.ctx.Kong.envOnly {
+			return
+		}
+		if flags := w.sortFlagGroups(withoutInternalFlags(node.AllFlags(hide))); len(flags) > 0 {
+			constraints := map[*Flag]string{}
+			if !w.NoConstraints {
+				constraints = flagConstraints(node, hide)
+			}
 			groupedFlags := collectFlagGroups(flags)
 			for _, group := range groupedFlags {
 				w.Print("")
@@ -186,7 +299,7 @@ func printNodeDetail(w *helpWriter, node *Node, hide bool) {
 					w.Indent().Wrap(group.Metadata.Description)
 					w.Print("")
 				}
-				writeFlags(w.Indent(), group.Flags)
+				writeFlags(w.Indent(), group.Flags, constraints)
 			}
 		}
 	}
@@ -199,6 +312,7 @@ func printNodeDetail(w *helpWriter, node *Node, hide bool) {
 	} else {
 		cmds = node.Leaves(hide)
 	}
+	cmds = w.sortCommands(cmds)
 	if len(cmds) > 0 {
 		iw := w.Indent()
 		if w.Tree {
@@ -206,7 +320,14 @@ func printNodeDetail(w *helpWriter, node *Node, hide bool) {
 			w.Print("Commands:")
 			writeCommandTree(iw, node)
 		} else {
-			groupedCmds := collectCommandGroups(cmds)
+			uncategorised, categories := collectCommandCategories(node, cmds)
+			groupedCmds := collectCommandGroups(uncategorised)
+			for _, category := range categories {
+				groupedCmds = append(groupedCmds, helpCommandGroup{
+					Metadata: &Group{Title: category.Title + ":"},
+					Commands: category.Commands,
+				})
+			}
 			for _, group := range groupedCmds {
 				w.Print("")
 				if group.Metadata.Title != "" {
@@ -232,7 +353,7 @@ func printNodeDetail(w *helpWriter, node *Node, hide bool) {
 
 func writeCommandList(cmds []*Node, iw *helpWriter) {
 	for i, cmd := range cmds {
-		if cmd.Hidden {
+		if cmd.Hidden && !iw.ShowHidden {
 			continue
 		}
 		printCommandSummary(iw, cmd)
@@ -245,7 +366,7 @@ func writeCommandList(cmds []*Node, iw *helpWriter) {
 func writeCompactCommandList(cmds []*Node, iw *helpWriter) {
 	rows := [][2]string{}
 	for _, cmd := range cmds {
-		if cmd.Hidden {
+		if cmd.Hidden && !iw.ShowHidden {
 			continue
 		}
 		rows = append(rows, [2]string{cmd.Path(), cmd.Help})
@@ -255,12 +376,13 @@ func writeCompactCommandList(cmds []*Node, iw *helpWriter) {
 
 func writeCommandTree(w *helpWriter, node *Node) {
 	rows := make([][2]string, 0, len(node.Children)*2)
-	for i, cmd := range node.Children {
-		if cmd.Hidden {
+	children := w.sortCommands(node.Children)
+	for i, cmd := range children {
+		if cmd.Hidden && !w.ShowHidden {
 			continue
 		}
 		rows = append(rows, w.CommandTree(cmd, "")...)
-		if i != len(node.Children)-1 {
+		if i != len(children)-1 {
 			rows = append(rows, [2]string{"", ""})
 		}
 	}
@@ -305,6 +427,7 @@ func collectFlagGroups(flags [][]*Flag) []helpFlagGroup {
 		}
 	}
 
+	sortGroups(groups)
 	out := []helpFlagGroup{}
 	// Ungrouped flags are always displayed first.
 	if ungroupedFlags, ok := flagsByGroup[""]; ok {
@@ -341,6 +464,7 @@ func collectCommandGroups(nodes []*Node) []helpCommandGroup {
 		nodesByGroup[key] = append(nodesByGroup[key], node)
 	}
 
+	sortGroups(groups)
 	out := []helpCommandGroup{}
 	// Ungrouped nodes are always displayed first.
 	if ungroupedNodes, ok := nodesByGroup[""]; ok {
@@ -355,6 +479,45 @@ func collectCommandGroups(nodes []*Node) []helpCommandGroup {
 	return out
 }
 
+type helpCommandCategory struct {
+	Title    string
+	Commands []*Node
+}
+
+// Split descendant commands of parent into those without a category, and those in each category, in order
+// of appearance.
+//
+// A command's category is the first category found between it and parent.
+func collectCommandCategories(parent *Node, nodes []*Node) (uncategorised []*Node, categories []helpCommandCategory) {
+	index := map[string]int{}
+	for _, node := range nodes {
+		category := ""
+		for ancestor := node; ancestor != nil && ancestor != parent; ancestor = ancestor.Parent {
+			if ancestor.Category != "" {
+				category = ancestor.Category
+				break
+			}
+		}
+		if category == "" {
+			uncategorised = append(uncategorised, node)
+			continue
+		}
+		i, ok := index[category]
+		if !ok {
+			i = len(categories)
+			index[category] = i
+			categories = append(categories, helpCommandCategory{Title: category})
+		}
+		categories[i].Commands = append(categories[i].Commands, node)
+	}
+	return uncategorised, categories
+}
+
+// Sort groups by their Order, preserving order of appearance for equal values.
+func sortGroups(groups []*Group) {
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Order < groups[j].Order })
+}
+
 func printCommandSummary(w *helpWriter, cmd *Command) {
 	w.Print(cmd.Summary())
 	if cmd.Help != "" {
@@ -367,12 +530,45 @@ type helpWriter struct {
 	width         int
 	lines         *[]string
 	helpFormatter HelpValueFormatter
+	ctx           *Context
 	HelpOptions
 }
 
+// Detailed help for a node, preferring a ContextHelpProvider over the static Detail.
+func (h *helpWriter) nodeDetail(node *Node) string {
+	if h.ctx != nil && node.Target.IsValid() && node.Target.CanAddr() {
+		if provider, ok := node.Target.Addr().Interface().(ContextHelpProvider); ok {
+			return provider.Help(h.ctx)
+		}
+	}
+	return node.Detail
+}
+
+// A WidthWriter is an output writer that knows its width in columns, eg. a terminal emulated in a browser
+// where the width can't be queried from the platform. Help written to it is wrapped to that width.
+type WidthWriter interface {
+	io.Writer
+	Width() int
+}
+
+// Width of w in columns, from WidthWriter if it's implemented, or guessed from the platform.
+func writerWidth(w io.Writer) int {
+	if w, ok := w.(WidthWriter); ok && w.Width() > 0 {
+		return w.Width()
+	}
+	return guessWidth(w)
+}
+
+type widthWriter struct {
+	io.Writer
+	width int
+}
+
+func (w *widthWriter) Width() int { return w.width }
+
 func newHelpWriter(ctx *Context, options HelpOptions) *helpWriter {
 	lines := []string{}
-	wrapWidth := guessWidth(ctx.Stdout)
+	wrapWidth := writerWidth(ctx.Stdout)
 	if options.WrapUpperBound > 0 && wrapWidth > options.WrapUpperBound {
 		wrapWidth = options.WrapUpperBound
 	}
@@ -381,6 +577,7 @@ func newHelpWriter(ctx *Context, options HelpOptions) *helpWriter {
 		width:         wrapWidth,
 		lines:         &lines,
 		helpFormatter: ctx.Kong.helpFormatter,
+		ctx:           ctx,
 		HelpOptions:   options,
 	}
 	return w
@@ -396,7 +593,7 @@ func (h *helpWriter) Print(text string) {
 
 // Indent returns a new helpWriter indented by two characters.
 func (h *helpWriter) Indent() *helpWriter {
-	return &helpWriter{indent: h.indent + "  ", lines: h.lines, width: h.width - 2, HelpOptions: h.HelpOptions, helpFormatter: h.helpFormatter}
+	return &helpWriter{indent: h.indent + "  ", lines: h.lines, width: h.width - 2, HelpOptions: h.HelpOptions, helpFormatter: h.helpFormatter, ctx: h.ctx}
 }
 
 func (h *helpWriter) String() string {
@@ -421,20 +618,139 @@ func (h *helpWriter) Wrap(text string) {
 	}
 }
 
+// Positional arguments are written as a table of their name, type and whether they're required,
+// followed by their help, default and allowed values.
 func writePositionals(w *helpWriter, args []*Positional) {
+	columns := make([][3]string, len(args))
+	widths := [2]int{}
+	for i, arg := range args {
+		required := "optional"
+		if arg.Required {
+			required = "required"
+		}
+		columns[i] = [3]string{arg.Summary(), positionalPlaceHolder(arg), required}
+		for j := range widths {
+			if len(columns[i][j]) > widths[j] {
+				widths[j] = len(columns[i][j])
+			}
+		}
+	}
 	rows := [][2]string{}
-	for _, arg := range args {
-		rows = append(rows, [2]string{arg.Summary(), w.helpFormatter(arg)})
+	for i, arg := range args {
+		left := fmt.Sprintf("%-*s  %-*s  %s", widths[0], columns[i][0], widths[1], columns[i][1], columns[i][2])
+		suffixes := []string{}
+		if arg.Default != "" {
+			suffixes = append(suffixes, fmt.Sprintf("(default: %s)", arg.Default))
+		}
+		if arg.Enum != "" && !w.isLargeEnum(arg) {
+			enums := []string{}
+			for _, enum := range strings.Split(arg.Enum, ",") {
+				enums = append(enums, strings.TrimSpace(enum))
+			}
+			suffixes = append(suffixes, fmt.Sprintf("(one of: %s)", strings.Join(enums, ", ")))
+		}
+		help := appendHelpSuffix(w.helpFormatter(arg), suffixes...)
+		if w.isLargeEnum(arg) {
+			help = w.largeEnumHelp(arg, help)
+		}
+		rows = append(rows, [2]string{left, help})
 	}
 	writeTwoColumns(w, rows)
 }
 
-func writeFlags(w *helpWriter, groups [][]*Flag) {
+// Whether the enum of value has too many values to be listed inline. See HelpOptions.MaxInlineEnum.
+func (h *helpWriter) isLargeEnum(value *Value) bool {
+	limit := h.MaxInlineEnum
+	if limit == 0 {
+		limit = defaultMaxInlineEnum
+	}
+	return value.Enum != "" && limit > 0 && strings.Count(value.Enum, ",")+1 > limit
+}
+
+// Help for a value with a large enum, whose values are listed in columns beneath help, or only in help that
+// shows hidden flags if HelpOptions.DeferLargeEnums is set.
+//
+// The inline list of values in help, if any, is replaced with a reference to them. Positional arguments,
+// whose values are always listed, are given one. Flags whose help doesn't list their values are unchanged.
+func (h *helpWriter) largeEnumHelp(value *Value, help string) string {
+	enums := []string{}
+	for _, enum := range strings.Split(value.Enum, ",") {
+		enums = append(enums, strings.TrimSpace(enum))
+	}
+	deferred := h.DeferLargeEnums && !h.ShowHidden
+	reference := "the values below"
+	if deferred {
+		reference = fmt.Sprintf("%d values", len(enums))
+		if h.ctx != nil && h.ctx.Kong.helpHiddenFlag != nil {
+			reference += " listed by --help-hidden"
+		}
+	}
+	switch {
+	case strings.Contains(help, value.Enum):
+		help = strings.Replace(help, value.Enum, reference, 1)
+	case value.Flag == nil:
+		help = appendHelpSuffix(help, "(one of "+reference+")")
+	default:
+		return help
+	}
+	if deferred {
+		return help
+	}
+	// The help column is at least this wide, less the indent of preformatted text.
+	maxLeft := 375 * h.width / 1000
+	if maxLeft < 30 {
+		maxLeft = 30
+	}
+	width := h.width - maxLeft - defaultColumnPadding - defaultIndent
+	return help + "\n\n" + enumColumns(enums, width)
+}
+
+// Lay out enums in as many columns as fit in width, ordered down each column. Lines are indented so that
+// they're preformatted when the help is formatted.
+func enumColumns(enums []string, width int) string {
+	columnWidth := 0
+	for _, enum := range enums {
+		if len(enum)+2 > columnWidth {
+			columnWidth = len(enum) + 2
+		}
+	}
+	columns := width / columnWidth
+	if columns < 1 {
+		columns = 1
+	}
+	rows := (len(enums) + columns - 1) / columns
+	lines := make([]string, rows)
+	for row := range lines {
+		line := ""
+		for column := 0; column < columns && column*rows+row < len(enums); column++ {
+			line += fmt.Sprintf("%-*s", columnWidth, enums[column*rows+row])
+		}
+		lines[row] = " " + strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func positionalPlaceHolder(arg *Positional) string {
+	if arg.Tag.PlaceHolder != "" {
+		return arg.Tag.PlaceHolder
+	}
+	// Unnamed types such as []string have no placeholder of their own.
+	typ := arg.Target.Type()
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	if typ.Name() != "" {
+		return strings.ToUpper(dashedString(typ.Name()))
+	}
+	return strings.ToUpper(arg.Name)
+}
+
+func writeFlags(w *helpWriter, groups [][]*Flag, constraints map[*Flag]string) {
 	rows := [][2]string{}
 	haveShort := false
 	for _, group := range groups {
 		for _, flag := range group {
-			if flag.Short != 0 {
+			if flag.shortName() != "" {
 				haveShort = true
 				break
 			}
@@ -445,14 +761,74 @@ func writeFlags(w *helpWriter, groups [][]*Flag) {
 			rows = append(rows, [2]string{"", ""})
 		}
 		for _, flag := range group {
-			if !flag.Hidden {
-				rows = append(rows, [2]string{formatFlag(haveShort, flag), w.helpFormatter(flag.Value)})
+			if !flag.Hidden || w.ShowHidden {
+				help := w.helpFormatter(flag.Value)
+				if w.isLargeEnum(flag.Value) {
+					help = w.largeEnumHelp(flag.Value, help)
+				}
+				if constraint := constraints[flag]; constraint != "" {
+					help = strings.TrimSpace(help + " " + constraint)
+				}
+				rows = append(rows, [2]string{formatFlag(haveShort, flag), help})
 			}
 		}
 	}
 	writeTwoColumns(w, rows)
 }
 
+// Describe the flags each flag of node and its ancestors conflicts with or requires, eg. "Conflicts with --json."
+func flagConstraints(node *Node, hide bool) map[*Flag]string {
+	out := map[*Flag]string{}
+	for ; node != nil; node = node.Parent {
+		conflicts := map[*Flag][]string{}
+		requires := map[*Flag][]string{}
+		for _, group := range node.FlagGroups() {
+			related := conflicts
+			if group.Kind == AndGroup {
+				related = requires
+			}
+			for _, flag := range group.Flags {
+				for _, other := range group.Flags {
+					if other == flag || (hide && other.Hidden) || other.Tag.Internal || containsString(related[flag], "--"+other.Name) {
+						continue
+					}
+					related[flag] = append(related[flag], "--"+other.Name)
+				}
+			}
+		}
+		for _, flag := range node.Flags {
+			parts := []string{}
+			if len(conflicts[flag]) > 0 {
+				parts = append(parts, "Conflicts with "+strings.Join(conflicts[flag], ", ")+".")
+			}
+			if len(requires[flag]) > 0 {
+				parts = append(parts, "Requires "+strings.Join(requires[flag], ", ")+".")
+			}
+			if len(parts) > 0 {
+				out[flag] = strings.Join(parts, " ")
+			}
+		}
+	}
+	return out
+}
+
+// Internal flags are parsed but never documented.
+func withoutInternalFlags(groups [][]*Flag) [][]*Flag {
+	out := make([][]*Flag, 0, len(groups))
+	for _, group := range groups {
+		flags := make([]*Flag, 0, len(group))
+		for _, flag := range group {
+			if !flag.Tag.Internal {
+				flags = append(flags, flag)
+			}
+		}
+		if len(flags) > 0 {
+			out = append(out, flags)
+		}
+	}
+	return out
+}
+
 func writeTwoColumns(w *helpWriter, rows [][2]string) {
 	maxLeft := 375 * w.width / 1000
 	if maxLeft < 30 {
@@ -490,11 +866,18 @@ func formatFlag(haveShort bool, flag *Flag) string {
 	flagString := ""
 	name := flag.Name
 	isBool := flag.IsBool()
-	if flag.Short != 0 {
+	if flag.Tag.ShortOnly {
+		flagString = "-" + flag.shortName()
+		if !isBool {
+			flagString += " " + flag.FormatPlaceHolder()
+		}
+		return flagString
+	}
+	if short := flag.shortName(); short != "" {
 		if isBool && flag.Tag.Negatable {
-			flagString += fmt.Sprintf("-%c, --[no-]%s", flag.Short, name)
+			flagString += fmt.Sprintf("-%s, --[no-]%s", short, name)
 		} else {
-			flagString += fmt.Sprintf("-%c, --%s", flag.Short, name)
+			flagString += fmt.Sprintf("-%s, --%s", short, name)
 		}
 	} else {
 		if isBool && flag.Tag.Negatable {
@@ -538,8 +921,8 @@ func (h *HelpOptions) CommandTree(node *Node, prefix string) (rows [][2]string)
 	for _, arg := range node.Positional {
 		rows = append(rows, [2]string{prefix + arg.Summary(), arg.Help})
 	}
-	for _, subCmd := range node.Children {
-		if subCmd.Hidden {
+	for _, subCmd := range h.sortCommands(node.Children) {
+		if subCmd.Hidden && !h.ShowHidden {
 			continue
 		}
 		rows = append(rows, h.CommandTree(subCmd, prefix)...)
@@ -547,6 +930,30 @@ func (h *HelpOptions) CommandTree(node *Node, prefix string) (rows [][2]string)
 	return
 }
 
+// Returns a copy of each level of flags, ordered by SortFlags.
+func (h *HelpOptions) sortFlagGroups(groups [][]*Flag) [][]*Flag {
+	if h.SortFlags == nil {
+		return groups
+	}
+	out := make([][]*Flag, 0, len(groups))
+	for _, group := range groups {
+		group = append([]*Flag(nil), group...)
+		sort.SliceStable(group, func(i, j int) bool { return h.SortFlags(group[i], group[j]) })
+		out = append(out, group)
+	}
+	return out
+}
+
+// Returns a copy of nodes ordered by SortCommands.
+func (h *HelpOptions) sortCommands(nodes []*Node) []*Node {
+	if h.SortCommands == nil {
+		return nodes
+	}
+	nodes = append([]*Node(nil), nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool { return h.SortCommands(nodes[i], nodes[j]) })
+	return nodes
+}
+
 // SpaceIndenter adds a space indent to the given prefix.
 func SpaceIndenter(prefix string) string {
 	return prefix + strings.Repeat(" ", defaultIndent)
@@ -2,6 +2,7 @@ package kong_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -43,8 +44,8 @@ func TestHelpOptionalArgs(t *testing.T) {
 	expected := `Usage: test-app [<one> [<two>]]
 
 Arguments:
-  [<one>]    One optional arg.
-  [<two>]    Two optional arg.
+  [<one>]  STRING  optional    One optional arg.
+  [<two>]  STRING  optional    Two optional arg.
 
 Flags:
   -h, --help    Show context-sensitive help.
@@ -52,6 +53,20 @@ Flags:
 	require.Equal(t, expected, w.String())
 }
 
+func TestHelpPositionals(t *testing.T) {
+	var cli struct {
+		Paths []string `arg:"" type:"path" help:"Paths of files to open."`
+		Mode  string   `arg:"" enum:"r,w" default:"r" help:"Open mode."`
+	}
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &cli, kong.Writers(w, w), kong.Exit(func(int) {}))
+	_, _ = app.Parse([]string{"--help"})
+	require.Contains(t, w.String(), `Arguments:
+  <paths> ...  STRING  required    Paths of files to open.
+  [<mode>]     STRING  optional    Open mode (default: r) (one of: r, w).
+`)
+}
+
 func TestHelp(t *testing.T) {
 	var cli struct {
 		String   string         `help:"A string flag."`
@@ -757,3 +772,484 @@ Flags:
 	t.Log(expected)
 	require.Equal(t, expected, w.String())
 }
+
+type widthWriter struct {
+	bytes.Buffer
+	width int
+}
+
+func (w *widthWriter) Width() int { return w.width }
+
+func TestHelpWidthWriter(t *testing.T) {
+	var cli struct {
+		Flag string `help:"A string flag with long help that wraps."`
+	}
+	w := &widthWriter{width: 40}
+	app := mustNew(t, &cli, kong.Name("test-app"), kong.Writers(w, w), kong.Exit(func(int) {}))
+	_, err := app.Parse([]string{"--help"})
+	require.NoError(t, err)
+	expected := `Usage: test-app
+
+Flags:
+  -h, --help           Show
+                       context-sensitive
+                       help.
+      --flag=STRING    A string flag
+                       with long help
+                       that wraps.
+`
+	require.Equal(t, expected, w.String())
+}
+
+func TestHelpHidden(t *testing.T) {
+	var cli struct {
+		Visible string `help:"A visible flag."`
+		Secret  string `help:"A hidden flag." hidden:""`
+
+		One struct{} `cmd:"" help:"A visible command."`
+		Two struct{} `cmd:"" help:"A hidden command." hidden:""`
+	}
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &cli,
+		kong.Name("test-app"),
+		kong.Writers(w, w),
+		kong.HelpHidden(),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+
+	t.Run("Help", func(t *testing.T) {
+		w.Truncate(0)
+		require.PanicsWithValue(t, true, func() {
+			_, err := app.Parse([]string{"--help"})
+			require.NoError(t, err)
+		})
+		expected := `Usage: test-app <command>
+
+Flags:
+  -h, --help              Show context-sensitive help.
+      --visible=STRING    A visible flag.
+
+Commands:
+  one
+    A visible command.
+
+Run "test-app <command> --help" for more information on a command.
+`
+		require.Equal(t, expected, w.String())
+	})
+
+	t.Run("HelpHidden", func(t *testing.T) {
+		w.Truncate(0)
+		require.PanicsWithValue(t, true, func() {
+			_, err := app.Parse([]string{"--help-hidden"})
+			require.NoError(t, err)
+		})
+		expected := `Usage: test-app <command>
+
+Flags:
+  -h, --help              Show context-sensitive help.
+      --help-hidden       Show help, including hidden flags and commands.
+      --visible=STRING    A visible flag.
+      --secret=STRING     A hidden flag.
+
+Commands:
+  one
+    A visible command.
+
+  two
+    A hidden command.
+
+Run "test-app <command> --help" for more information on a command.
+`
+		require.Equal(t, expected, w.String())
+	})
+}
+
+func TestHelpGroupOrder(t *testing.T) {
+	var cli struct {
+		First  string `help:"In the first group." group:"first"`
+		Second string `help:"In the second group." group:"second"`
+
+		One struct{} `cmd:"" help:"In the first group." group:"first"`
+		Two struct{} `cmd:"" help:"In the second group." group:"second"`
+	}
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &cli,
+		kong.Name("test-app"),
+		kong.Writers(w, w),
+		kong.ExplicitGroups([]kong.Group{
+			{Key: "first", Title: "First:", Order: 2},
+			{Key: "second", Title: "Second:", Description: "Displayed before the first group.", Order: 1},
+		}),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.PanicsWithValue(t, true, func() {
+		_, err := app.Parse([]string{"--help"})
+		require.NoError(t, err)
+	})
+	expected := `Usage: test-app <command>
+
+Flags:
+  -h, --help    Show context-sensitive help.
+
+Second:
+  Displayed before the first group.
+
+  --second=STRING    In the second group.
+
+First:
+  --first=STRING    In the first group.
+
+Second:
+  Displayed before the first group.
+
+  two
+    In the second group.
+
+First:
+  one
+    In the first group.
+
+Run "test-app <command> --help" for more information on a command.
+`
+	require.Equal(t, expected, w.String())
+}
+
+func TestHelpCommandCategories(t *testing.T) {
+	var cli struct {
+		Run       struct{} `cmd:"" help:"Run a container."`
+		Container struct {
+			Ls struct{} `cmd:"" help:"List containers."`
+			Rm struct{} `cmd:"" help:"Remove containers."`
+		} `cmd:"" help:"Manage containers." category:"Management Commands"`
+		Ps struct{} `cmd:"" help:"List containers."`
+	}
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &cli,
+		kong.Name("test-app"),
+		kong.Writers(w, w),
+		kong.ConfigureHelp(kong.HelpOptions{Compact: true}),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.Equal(t, "Management Commands", app.Model.Children[1].Category)
+	require.PanicsWithValue(t, true, func() {
+		_, err := app.Parse([]string{"--help"})
+		require.NoError(t, err)
+	})
+	expected := `Usage: test-app <command>
+
+Flags:
+  -h, --help    Show context-sensitive help.
+
+Commands:
+  run    Run a container.
+  ps     List containers.
+
+Management Commands:
+  container ls    List containers.
+  container rm    Remove containers.
+
+Run "test-app <command> --help" for more information on a command.
+`
+	require.Equal(t, expected, w.String())
+
+	w.Reset()
+	require.PanicsWithValue(t, true, func() {
+		_, err := app.Parse([]string{"container", "--help"})
+		require.NoError(t, err)
+	})
+	require.Contains(t, w.String(), "\nCommands:\n  container ls")
+}
+
+func TestHelpSorting(t *testing.T) {
+	var cli struct {
+		Optional string `help:"An optional flag."`
+		Required string `help:"A required flag." required:""`
+
+		Destroy struct{} `cmd:"" help:"Destroy everything."`
+		Create  struct{} `cmd:"" help:"Create something."`
+	}
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &cli,
+		kong.Name("test-app"),
+		kong.Writers(w, w),
+		kong.ConfigureHelp(kong.HelpOptions{
+			Compact:      true,
+			SortFlags:    func(a, b *kong.Flag) bool { return a.Required && !b.Required },
+			SortCommands: func(a, b *kong.Node) bool { return b.Name == "destroy" },
+		}),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.PanicsWithValue(t, true, func() {
+		_, err := app.Parse([]string{"--help"})
+		require.NoError(t, err)
+	})
+	expected := `Usage: test-app --required=STRING <command>
+
+Flags:
+      --required=STRING    A required flag.
+  -h, --help               Show context-sensitive help.
+      --optional=STRING    An optional flag.
+
+Commands:
+  create     Create something.
+  destroy    Destroy everything.
+
+Run "test-app <command> --help" for more information on a command.
+`
+	require.Equal(t, expected, w.String())
+}
+
+type profilesCmd struct{}
+
+func (profilesCmd) Help(ctx *kong.Context) string {
+	return fmt.Sprintf("Configured profile: %s", ctx.FlagValue(ctx.Flags()[1]))
+}
+
+func TestContextHelpProvider(t *testing.T) {
+	var cli struct {
+		Profile  string      `default:"production"`
+		Profiles profilesCmd `cmd:"" help:"Manage profiles."`
+	}
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &cli,
+		kong.Name("test-app"),
+		kong.Writers(w, w),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.PanicsWithValue(t, true, func() {
+		_, err := app.Parse([]string{"--profile=staging", "profiles", "--help"})
+		require.NoError(t, err)
+	})
+	require.Contains(t, w.String(), "Manage profiles.\n\nConfigured profile: staging\n")
+}
+
+func TestPresets(t *testing.T) {
+	type cli struct {
+		Workers int  `default:"4" preset:"fast=16" preset:"safe=1" help:"Number of workers."`
+		Cache   bool `preset:"fast=true" help:"Enable caching."`
+	}
+	var c cli
+	_, err := mustNew(t, &c).Parse([]string{"--preset=fast"})
+	require.NoError(t, err)
+	require.Equal(t, cli{Workers: 16, Cache: true}, c)
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"--preset=fast", "--workers=2"})
+	require.NoError(t, err)
+	require.Equal(t, cli{Workers: 2, Cache: true}, c)
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"--preset=slow"})
+	require.EqualError(t, err, `unknown preset "slow"`)
+
+	var conflict struct {
+		Preset  string
+		Workers int `preset:"fast=16"`
+	}
+	_, err = kong.New(&conflict)
+	require.EqualError(t, err, "presets are defined but the --preset flag is already in use")
+
+	w := bytes.NewBuffer(nil)
+	app := mustNew(t, &c,
+		kong.Name("test-app"),
+		kong.Writers(w, w),
+		kong.Exit(func(int) {
+			panic(true) // Panic to fake "exit".
+		}),
+	)
+	require.PanicsWithValue(t, true, func() {
+		_, err := app.Parse([]string{"--help"})
+		require.NoError(t, err)
+	})
+	expected := `Usage: test-app
+
+Flags:
+  -h, --help           Show context-sensitive help.
+      --preset=NAME    Apply a named preset of flag values (fast, safe).
+      --workers=4      Number of workers.
+      --cache          Enable caching.
+
+Presets:
+  fast    --workers=16 --cache=true
+  safe    --workers=1
+`
+	require.Equal(t, expected, w.String())
+}
+
+func TestHelpPrintedOutcome(t *testing.T) {
+	var cli struct {
+		Secret bool `hidden:""`
+		Deploy struct {
+			Env string
+		} `cmd:"" help:"Deploy the app."`
+	}
+	w := &strings.Builder{}
+	p, err := kong.New(&cli, kong.NoExit(), kong.HelpHidden(), kong.Writers(w, w))
+	require.NoError(t, err)
+
+	_, err = p.Parse([]string{"deploy", "--help"})
+	var parseErr *kong.ParseError
+	require.True(t, errors.As(err, &parseErr))
+	outcome := parseErr.Context.HelpPrinted
+	require.NotNil(t, outcome)
+	require.Equal(t, "deploy", outcome.Node.Name)
+	require.False(t, outcome.Options.ShowHidden)
+	require.Equal(t, w.String(), outcome.Output)
+	require.Contains(t, outcome.Output, "Deploy the app.")
+
+	_, err = p.Parse([]string{"--help-hidden"})
+	require.True(t, errors.As(err, &parseErr))
+	require.Equal(t, parseErr.Context.Model.Node, parseErr.Context.HelpPrinted.Node)
+	require.True(t, parseErr.Context.HelpPrinted.Options.ShowHidden)
+	require.Contains(t, parseErr.Context.HelpPrinted.Output, "--secret")
+
+	ctx, err := p.Parse([]string{"deploy"})
+	require.NoError(t, err)
+	require.Nil(t, ctx.HelpPrinted)
+}
+
+func TestHints(t *testing.T) {
+	var cli struct {
+		Server struct {
+			Start struct {
+				Port int `required:""`
+			} `cmd:""`
+		} `cmd:""`
+	}
+	stdout, stderr := &strings.Builder{}, &strings.Builder{}
+	p, err := kong.New(&cli, kong.Name("app"), kong.NoExit(), kong.Writers(stdout, stderr),
+		kong.Hint(`{{if eq .Kind "parse-error"}}Run '{{.App.Name}} {{.Command}} --help' for details.{{end}}`),
+		kong.Hint(`{{if eq .Kind "help"}}Flags can also be set with APP_* environment variables.{{end}}`),
+		kong.Hint(`{{if .Error}}Error kind: {{.Kind}}.{{end}}`))
+	require.NoError(t, err)
+
+	_, err = p.Parse([]string{"server", "start"})
+	require.Error(t, err)
+	p.FatalIfErrorf(err)
+	require.Equal(t, `app: error: missing flags: --port=INT
+Run 'app server start --help' for details.
+Error kind: parse-error.
+`, stderr.String())
+
+	stderr.Reset()
+	p.FatalIfErrorf(errors.New("failed"))
+	require.Equal(t, "app: error: failed\nError kind: error.\n", stderr.String())
+
+	_, err = p.Parse([]string{"--help"})
+	require.True(t, errors.Is(err, kong.ErrHelpRequested))
+	require.True(t, strings.HasSuffix(stdout.String(), "\n\nFlags can also be set with APP_* environment variables.\n"), stdout.String())
+
+	_, err = kong.New(&cli, kong.Hint(`{{if}}`))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid hint")
+}
+
+func TestHelpConstraints(t *testing.T) {
+	var cli struct {
+		JSON     bool   `xor:"format" help:"Output JSON."`
+		YAML     bool   `xor:"format" help:"Output YAML."`
+		Username string `and:"auth" help:"Username."`
+		Password string `and:"auth"`
+		Debug    bool   `xor:"format" hidden:""`
+	}
+	w := &strings.Builder{}
+	p := mustNew(t, &cli, kong.Writers(w, w), kong.Exit(func(int) {}))
+	_, err := p.Parse([]string{"--help"})
+	require.NoError(t, err)
+	require.Equal(t, `Usage: test
+
+Flags:
+  -h, --help               Show context-sensitive help.
+      --json               Output JSON. Conflicts with --yaml.
+      --yaml               Output YAML. Conflicts with --json.
+      --username=STRING    Username. Requires --password.
+      --password=STRING    Requires --username.
+`, w.String())
+
+	w.Reset()
+	p = mustNew(t, &cli, kong.Writers(w, w), kong.Exit(func(int) {}), kong.ConfigureHelp(kong.HelpOptions{NoConstraints: true}))
+	_, err = p.Parse([]string{"--help"})
+	require.NoError(t, err)
+	require.Contains(t, w.String(), "--json               Output JSON.\n")
+}
+
+func TestInternalFlags(t *testing.T) {
+	var cli struct {
+		Debug    bool   `hidden:"" help:"Debug mode."`
+		ReExecFD int    `internal:"" help:"File descriptor passed by the parent process."`
+		Name     string `help:"Name."`
+	}
+	w := &strings.Builder{}
+	p := mustNew(t, &cli, kong.Writers(w, w), kong.Exit(func(int) {}), kong.HelpHidden(), kong.Configuration(kong.JSON))
+	_, err := p.Parse([]string{"--re-exec-fd=3"})
+	require.NoError(t, err)
+	require.Equal(t, 3, cli.ReExecFD)
+
+	_, err = p.Parse([]string{"--help-hidden"})
+	require.NoError(t, err)
+	require.Contains(t, w.String(), "--debug")
+	require.NotContains(t, w.String(), "re-exec-fd")
+
+	w.Reset()
+	_, err = p.Parse([]string{"--config-schema"})
+	require.NoError(t, err)
+	require.Contains(t, w.String(), "debug")
+	require.NotContains(t, w.String(), "re_exec_fd")
+
+	require.Empty(t, p.Complete([]string{"--re"}))
+}
+
+func TestHelpLargeEnums(t *testing.T) {
+	type cli struct {
+		Region string `enum:"us-east-1,us-east-2,us-west-1,us-west-2,eu-west-1,eu-west-2,eu-central-1,ap-south-1,ap-northeast-1,ap-southeast-1,sa-east-1" default:"us-east-1" help:"Region to deploy to, one of ${enum}."`
+		Zone   string `arg:"" enum:"a,b,c,d,e,f,g,h,i,j,k,l" default:"a" help:"Availability zone."`
+	}
+	help := func(options ...kong.Option) string {
+		w := &strings.Builder{}
+		options = append(options, kong.Writers(w, w), kong.Exit(func(int) { panic(true) }))
+		p := mustNew(t, &cli{}, options...)
+		require.PanicsWithValue(t, true, func() {
+			_, err := p.Parse([]string{"--help"})
+			require.NoError(t, err)
+		})
+		return w.String()
+	}
+	require.Equal(t, `Usage: test [<zone>]
+
+Arguments:
+  [<zone>]  STRING  optional    Availability zone (default: a) (one of the
+                                values below).
+
+                                  a  b  c  d  e  f  g  h  i  j  k  l
+
+Flags:
+  -h, --help                  Show context-sensitive help.
+      --region="us-east-1"    Region to deploy to, one of the values below.
+
+                                us-east-1       eu-central-1
+                                us-east-2       ap-south-1
+                                us-west-1       ap-northeast-1
+                                us-west-2       ap-southeast-1
+                                eu-west-1       sa-east-1
+                                eu-west-2
+`, help())
+
+	deferred := help(kong.ConfigureHelp(kong.HelpOptions{DeferLargeEnums: true}), kong.HelpHidden())
+	require.Contains(t, deferred, "Availability zone (default: a) (one of 12 values\n                                listed by --help-hidden).")
+	require.Contains(t, deferred, "Region to deploy to, one of 11 values listed by\n")
+	require.NotContains(t, deferred, "eu-central-1")
+
+	inline := help(kong.ConfigureHelp(kong.HelpOptions{MaxInlineEnum: -1}))
+	require.Contains(t, inline, "us-east-1,us-east-2,us-west-1,")
+}
@@ -0,0 +1,89 @@
+package kong
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// HintKind is the kind of output a hint is appended to.
+type HintKind string
+
+// Kinds of output hints are appended to.
+const (
+	// Help printed by --help.
+	HintHelp HintKind = "help"
+	// A command-line that failed to parse or validate, reported by FatalIfErrorf().
+	HintParseError HintKind = "parse-error"
+	// Any other error reported by FatalIfErrorf(), eg. from a Run() method.
+	HintError HintKind = "error"
+)
+
+// HintContext is the data available to the templates of Hint().
+type HintContext struct {
+	Kind HintKind
+	App  *Application
+	// Selected command, or the root of the application if none.
+	Node *Node
+	// Error being reported, or nil for help.
+	Error error
+}
+
+// Command is the path of the selected command, eg. "server start", or "" if none is selected.
+func (h *HintContext) Command() string {
+	return commandPath(h.Node)
+}
+
+// Hint appends a line rendered from a text/template to help and errors, with a HintContext as its data.
+//
+// Hints that render to an empty string are omitted, which allows them to be conditional. eg.
+//
+//     kong.Hint(`{{if eq .Kind "parse-error"}}Run '{{.App.Name}} {{.Command}} --help' for details.{{end}}`)
+//     kong.Hint(`{{if eq .Kind "help"}}Flags can also be set with MYAPP_* environment variables.{{end}}`)
+func Hint(text string) Option {
+	return OptionFunc(func(k *Kong) error {
+		tmpl, err := template.New("hint").Parse(text)
+		if err != nil {
+			return errors.Wrap(err, "invalid hint")
+		}
+		k.hints = append(k.hints, tmpl)
+		return nil
+	})
+}
+
+// Render the hints for kind, one per line.
+func (k *Kong) renderHints(kind HintKind, node *Node, err error) []string {
+	if node == nil {
+		node = k.Model.Node
+	}
+	hctx := &HintContext{Kind: kind, App: k.Model, Node: node, Error: err}
+	lines := []string{}
+	for _, tmpl := range k.hints {
+		w := &bytes.Buffer{}
+		// A hint that fails to render is not worth failing for.
+		if tmpl.Execute(w, hctx) != nil {
+			continue
+		}
+		if line := strings.TrimSpace(w.String()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func (k *Kong) writeHints(w io.Writer, kind HintKind, node *Node, err error) {
+	lines := k.renderHints(kind, node, err)
+	if len(lines) == 0 {
+		return
+	}
+	if kind == HintHelp {
+		fmt.Fprintln(w)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
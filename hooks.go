@@ -1,5 +1,10 @@
 package kong
 
+import (
+	"fmt"
+	"reflect"
+)
+
 // BeforeResolve is a documentation-only interface describing hooks that run before resolvers are applied.
 type BeforeResolve interface {
 	// This is not the correct signature - see README for details.
@@ -17,3 +22,73 @@ type AfterApply interface {
 	// This is not the correct signature - see README for details.
 	AfterApply(args ...interface{}) error
 }
+
+// Tags referencing functions registered with NamedHook(), and the hook each is called for.
+var hookTags = map[string]string{
+	"beforeresolve": "BeforeResolve",
+	"beforeapply":   "BeforeApply",
+	"afterapply":    "AfterApply",
+}
+
+// NamedHook registers a hook function that can be referenced by name from the beforeresolve, beforeapply
+// and afterapply tags of flags, arguments and commands.
+//
+// This allows hooks to be attached to fields without defining methods on their types, eg. for generated
+// grammars. The function must return an error and accepts the same bindings as hook methods, plus a
+// pointer to the tagged field.
+//
+//     kong.NamedHook("loadSession", func(token *string, ctx *kong.Context) error { ... })
+//
+//     Token string `afterapply:"loadSession"`
+func NamedHook(name string, fn interface{}) Option {
+	return OptionFunc(func(k *Kong) error {
+		f := reflect.ValueOf(fn)
+		if f.Kind() != reflect.Func || f.Type().NumOut() != 1 || f.Type().Out(0) != callbackReturnSignature {
+			return fmt.Errorf("named hook %q must be a function returning exactly \"error\" but got %T", name, fn)
+		}
+		if k.namedHooks == nil {
+			k.namedHooks = map[string]reflect.Value{}
+		}
+		k.namedHooks[name] = f
+		return nil
+	})
+}
+
+// Call the functions named by tag for hook, binding a pointer to value.
+func (k *Kong) callNamedHooks(hook string, tag *Tag, value reflect.Value, binds bindings) error {
+	if tag == nil {
+		return nil
+	}
+	for _, name := range tag.Hooks[hook] {
+		if value.CanAddr() {
+			binds = binds.clone().add(value.Addr().Interface())
+		}
+		if err := callMethod(name, value, k.namedHooks[name], binds); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check that all hooks referenced by tags have been registered with NamedHook().
+func (k *Kong) checkNamedHooks(node *Node) error {
+	return Visit(node, func(node Visitable, next Next) error {
+		var tag *Tag
+		switch node := node.(type) {
+		case *Node:
+			tag = node.Tag
+		case *Value:
+			tag = node.Tag
+		}
+		if tag != nil {
+			for _, names := range tag.Hooks {
+				for _, name := range names {
+					if _, ok := k.namedHooks[name]; !ok {
+						return fmt.Errorf("unknown hook %q, use kong.NamedHook(%q, fn)", name, name)
+					}
+				}
+			}
+		}
+		return next(nil)
+	})
+}
@@ -0,0 +1,131 @@
+package kong
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// FromModel creates a Kong parser from a model that was not built by reflecting over a grammar, eg.
+// because it was generated or deserialised, allowing a grammar to be shared between processes.
+//
+// As such a model has no Go values to parse into, each flag and positional argument must have a
+// pointer to its target in "targets", keyed by the names of its enclosing commands and its own name,
+// separated by ".", eg. "deploy.env" for the flag --env of the command "deploy". Commands may also
+// have a target, keyed by their path, eg. "deploy", whose Run() method is called by Context.Run().
+//
+// Parent links are restored and mappers are selected for each target, so a model only needs its
+// names, help, tags and structure.
+//
+//     targets := map[string]interface{}{"debug": &debug, "deploy.env": &env, "deploy": &deployCmd{}}
+//     parser, err := kong.FromModel(model, targets)
+func FromModel(app *Application, targets map[string]interface{}, options ...Option) (*Kong, error) {
+	k, err := newKong(options)
+	if err != nil {
+		return nil, err
+	}
+	if app.Node == nil {
+		return nil, fmt.Errorf("model has no root node")
+	}
+	app.Type = ApplicationNode
+	if err := k.bindModelNode(app.Node, nil, "", targets); err != nil {
+		return nil, err
+	}
+	for _, flag := range k.extraFlags() {
+		if !hasFlagNamed(app.Node, flag.Name) {
+			app.Flags = append([]*Flag{flag}, app.Flags...)
+		}
+	}
+	if app.Tag == nil {
+		app.Tag = newEmptyTag()
+	}
+	app.Tag.Vars = k.vars
+	if app.Name == "" {
+		app.Name = filepath.Base(os.Args[0])
+	}
+	if err := k.init(app); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Restore the parent links, targets and mappers of node and its children.
+func (k *Kong) bindModelNode(node, parent *Node, path string, targets map[string]interface{}) error {
+	node.Parent = parent
+	if node.Tag == nil {
+		node.Tag = newEmptyTag()
+	}
+	if target, ok := targets[path]; ok && path != "" {
+		v := reflect.ValueOf(target)
+		if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("target for %q must be a pointer to a struct but got %T", path, target)
+		}
+		node.Target = v.Elem()
+	} else {
+		// Nodes without a target can't be run, but are still addressable.
+		node.Target = reflect.New(reflect.TypeOf(struct{}{})).Elem()
+	}
+	if node.Argument != nil {
+		if err := k.bindModelValue(node.Argument, path, targets); err != nil {
+			return err
+		}
+	}
+	for _, flag := range node.Flags {
+		flag.Value.Flag = flag
+		if err := k.bindModelValue(flag.Value, modelPath(path, flag.Name), targets); err != nil {
+			return err
+		}
+	}
+	for i, positional := range node.Positional {
+		positional.Flag = nil
+		positional.Position = i
+		if err := k.bindModelValue(positional, modelPath(path, positional.Name), targets); err != nil {
+			return err
+		}
+	}
+	for _, child := range node.Children {
+		if err := k.bindModelNode(child, node, modelPath(path, child.Name), targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (k *Kong) bindModelValue(value *Value, path string, targets map[string]interface{}) error {
+	target, ok := targets[path]
+	if !ok {
+		return fmt.Errorf("no target for %q", path)
+	}
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr {
+		return fmt.Errorf("target for %q must be a pointer but got %T", path, target)
+	}
+	value.Target = v.Elem()
+	value.DefaultValue = reflect.New(value.Target.Type()).Elem()
+	if value.Tag == nil {
+		value.Tag = newEmptyTag()
+	}
+	if value.Mapper == nil {
+		if value.Tag.Type != "" {
+			value.Mapper = k.registry.ForNamedValue(value.Tag.Type, value.Target)
+		} else {
+			value.Mapper = k.registry.ForValue(value.Target)
+		}
+		if value.Mapper == nil {
+			return fmt.Errorf("unsupported type %s for %q", value.Target.Type(), path)
+		}
+	}
+	normalise, err := unitTransform(value.Unit, value.Target.Type())
+	if err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	value.Transform = chainTransforms(value.Transform, normalise)
+	value.indirections = k.indirections
+	return nil
+}
+
+func modelPath(parent, name string) string {
+	return strings.TrimPrefix(parent+"."+name, ".")
+}
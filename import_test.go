@@ -0,0 +1,81 @@
+package kong_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type importedDeployCmd struct {
+	ran bool
+}
+
+func (d *importedDeployCmd) Run() error {
+	d.ran = true
+	return nil
+}
+
+func importedModel() *kong.Application {
+	return &kong.Application{
+		Node: &kong.Node{
+			Name: "app",
+			Help: "An imported app.",
+			Flags: []*kong.Flag{
+				{Value: &kong.Value{Name: "debug", Help: "Debug mode."}, Short: 'd'},
+			},
+			Children: []*kong.Node{{
+				Type: kong.CommandNode,
+				Name: "deploy",
+				Help: "Deploy a service.",
+				Flags: []*kong.Flag{
+					{Value: &kong.Value{Name: "env", Default: "dev", Enum: "dev,prod"}, PlaceHolder: "ENV"},
+				},
+				Positional: []*kong.Value{
+					{Name: "service", Required: true},
+				},
+			}},
+		},
+	}
+}
+
+func TestFromModel(t *testing.T) {
+	var (
+		debug   bool
+		env     string
+		service string
+		deploy  importedDeployCmd
+	)
+	stdout := &bytes.Buffer{}
+	p, err := kong.FromModel(importedModel(), map[string]interface{}{
+		"debug":          &debug,
+		"deploy":         &deploy,
+		"deploy.env":     &env,
+		"deploy.service": &service,
+	}, kong.Writers(stdout, stdout), kong.NoExit())
+	require.NoError(t, err)
+	require.Equal(t, "app", p.Model.Name)
+
+	ctx, err := p.Parse([]string{"-d", "deploy", "api"})
+	require.NoError(t, err)
+	require.Equal(t, "deploy <service>", ctx.Command())
+	require.True(t, debug)
+	require.Equal(t, "dev", env)
+	require.Equal(t, "api", service)
+	require.NoError(t, ctx.Run())
+	require.True(t, deploy.ran)
+
+	_, err = p.Parse([]string{"deploy", "--env=staging", "api"})
+	require.EqualError(t, err, `--env must be one of "dev","prod" but got "staging"`)
+
+	_, err = p.Parse([]string{"--help"})
+	require.True(t, errors.Is(err, kong.ErrHelpRequested))
+	require.Contains(t, stdout.String(), "Usage: app <command>")
+	require.Contains(t, stdout.String(), "deploy <service>")
+
+	_, err = kong.FromModel(importedModel(), map[string]interface{}{"debug": &debug})
+	require.EqualError(t, err, `no target for "deploy.env"`)
+}
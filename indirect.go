@@ -0,0 +1,102 @@
+package kong
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// An IndirectLoader loads the value referenced by an indirection URI, such that eg.
+// "file:///run/secrets/token" is replaced by the contents of "/run/secrets/token".
+//
+// "ref" is the URI with its scheme and "://" removed.
+type IndirectLoader func(ref string) (string, error)
+
+// Indirection registers a loader for values prefixed with "<scheme>://".
+//
+// Indirection is opt-in: values are only resolved for schemes that have been registered, and are
+// resolved before transforms and mapping, so any flag or positional argument can accept indirect
+// sources uniformly. eg.
+//
+//     kong.Indirection("file", kong.FileIndirection),
+//     kong.Indirection("env", kong.EnvIndirection),
+//
+// Indirections give whoever supplies the command-line access to the host, so they are never applied to
+// untrusted remote input passed to Kong.Dispatch().
+func Indirection(scheme string, loader IndirectLoader) Option {
+	return OptionFunc(func(k *Kong) error {
+		if k.indirections == nil {
+			k.indirections = map[string]IndirectLoader{}
+		}
+		k.indirections[scheme] = loader
+		return nil
+	})
+}
+
+// FileIndirection loads the contents of a file, with trailing newlines removed.
+func FileIndirection(ref string) (string, error) {
+	data, err := ioutil.ReadFile(ExpandPath(ref))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// EnvIndirection loads the value of an environment variable, which must be set.
+func EnvIndirection(ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", errors.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
+
+// ExecIndirection runs a command, split with SplitCommandLine(), and loads its output with trailing
+// newlines removed, eg. "exec://pass show api-token".
+//
+// As the command is run with the privileges of the application, only register this loader for
+// applications whose command-line is trusted.
+func ExecIndirection(ref string) (string, error) {
+	args, err := SplitCommandLine(ref)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", errors.New("no command to execute")
+	}
+	output, err := execOutput(args)
+	if err != nil {
+		return "", errors.Wrapf(err, "%s", ref)
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// Replace the next value token on the scanner with the value it references, if any.
+func (v *Value) resolveIndirection(scan *Scanner) error {
+	token := scan.Peek()
+	if !token.IsValue() || ((v.IsBool() || v.IsCounter()) && token.Type != FlagValueToken) {
+		return nil
+	}
+	value, ok := token.Value.(string)
+	if !ok {
+		return nil
+	}
+	scheme, ref, ok := strings.Cut(value, "://")
+	if !ok {
+		return nil
+	}
+	loader, ok := v.indirections[scheme]
+	if !ok {
+		return nil
+	}
+	value, err := loader(ref)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", token.Value)
+	}
+	scan.Pop()
+	token.Value = value
+	scan.PushToken(token)
+	return nil
+}
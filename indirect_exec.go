@@ -0,0 +1,10 @@
+//go:build !js && !wasip1
+// +build !js,!wasip1
+
+package kong
+
+import "os/exec"
+
+func execOutput(args []string) ([]byte, error) {
+	return exec.Command(args[0], args[1:]...).Output() // nolint: gosec
+}
@@ -0,0 +1,15 @@
+//go:build js || wasip1
+// +build js wasip1
+
+package kong
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+// Processes can't be started from WebAssembly, so exec:// references always fail.
+func execOutput(args []string) ([]byte, error) {
+	return nil, errors.Errorf("executing commands is not supported on %s", runtime.GOOS)
+}
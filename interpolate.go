@@ -3,11 +3,17 @@ package kong
 import (
 	"fmt"
 	"regexp"
+	"strings"
 )
 
-var interpolationRegex = regexp.MustCompile(`((?:\${([[:alpha:]_][[:word:]]*))(?:=([^}]+))?})|(\$)|([^$]+)`)
+var interpolationRegex = regexp.MustCompile(
+	`(\${([[:alpha:]_][[:word:]]*)\s*(==|!=)\s*('[^']*'|[^\s?}]+)\s*\?\s*('[^']*'|[^\s:}]+)\s*:\s*('[^']*'|[^\s}]+)\s*})|` +
+		`((?:\${([[:alpha:]_][[:word:]]*))(?:=([^}]+))?})|(\$)|([^$]+)`)
 
 // Interpolate variables from vars into s for substrings in the form ${var} or ${var=default}.
+//
+// Conditionals in the form ${var==value ? 'then' : 'else'} or ${var!=value ? 'then' : 'else'} are
+// replaced with one of two values depending on the value of var, which is empty if undefined.
 func interpolate(s string, vars Vars, updatedVars map[string]string) (string, error) {
 	out := ""
 	matches := interpolationRegex.FindAllStringSubmatch(s, -1)
@@ -22,13 +28,22 @@ func interpolate(s string, vars Vars, updatedVars map[string]string) (string, er
 	}
 	for _, match := range matches {
 		if name := match[2]; name != "" {
+			if (vars[name] == unquoteInterpolated(match[4])) == (match[3] == "==") {
+				out += unquoteInterpolated(match[5])
+			} else {
+				out += unquoteInterpolated(match[6])
+			}
+		} else if name := match[8]; name != "" {
+			if strings.HasPrefix(match[9], "=") {
+				return "", fmt.Errorf("invalid conditional %s, expected ${%s==value ? 'then' : 'else'}", match[0], name)
+			}
 			value, ok := vars[name]
 			if !ok {
 				// No default value.
-				if match[3] == "" {
+				if match[9] == "" {
 					return "", fmt.Errorf("undefined variable ${%s}", name)
 				}
-				value = match[3]
+				value = match[9]
 			}
 			out += value
 		} else {
@@ -37,3 +52,10 @@ func interpolate(s string, vars Vars, updatedVars map[string]string) (string, er
 	}
 	return out, nil
 }
+
+func unquoteInterpolated(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -17,3 +17,15 @@ func TestInterpolate(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `Bobby Brown is 35 years old and 180 cm tall`, actual)
 }
+
+func TestInterpolateConditional(t *testing.T) {
+	vars := map[string]string{
+		"profile": "prod",
+	}
+	actual, err := interpolate("${profile==prod ? 'warn' : 'debug'} ${profile != 'prod' ? on : off} ${region==eu ? 'a b' : ''}", vars, nil)
+	require.NoError(t, err)
+	require.Equal(t, `warn off `, actual)
+
+	_, err = interpolate("${profile==prod ? warn}", vars, nil)
+	require.EqualError(t, err, `invalid conditional ${profile==prod ? warn}, expected ${profile==value ? 'then' : 'else'}`)
+}
@@ -6,8 +6,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
-	"regexp"
 	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 var (
@@ -53,17 +56,67 @@ type Kong struct {
 	loader       ConfigurationLoader
 	resolvers    []Resolver
 	registry     *Registry
-	ignoreFields []*regexp.Regexp
-
-	noDefaultHelp bool
-	usageOnError  usageOnError
-	help          HelpPrinter
-	shortHelp     HelpPrinter
-	helpFormatter HelpValueFormatter
-	helpOptions   HelpOptions
-	helpFlag      *Flag
-	groups        []Group
-	vars          Vars
+	ignoreFields []ignoreFieldFunc
+	indirections map[string]IndirectLoader
+	userAliases  map[string]string
+	namedHooks   map[string]reflect.Value
+	commandIndex map[string][]*Node // All visible commands in the model, keyed by name and alias.
+	hints        []*template.Template
+
+	// Implementations of interface command fields, keyed by interface type.
+	implementations map[reflect.Type]reflect.Value
+	// Resolvers only consulted for flags that reference them with the resolver:"" tag.
+	namedResolvers map[string]Resolver
+
+	// Reports whether features are enabled, for flags and commands tagged with feature:"".
+	featureEnabled       func(feature string) bool
+	hideDisabledFeatures bool
+
+	// Path to the file in which flag values are recorded for completion, if any.
+	completionHistory  string
+	completionProtocol bool
+	completionScript   bool
+	// Predictors registered with NamedPredictor() and TypePredictor().
+	namedPredictors map[string]Predictor
+	typePredictors  map[reflect.Type]Predictor
+	// Extensions registered with TagExtensions(), that parse custom tag keys.
+	tagExtensions []TagExtension
+
+	// First run and update notification hooks, and the state file they share with remembered flags.
+	firstRunHook func(ctx *Context) error
+	updateCheck  *updateCheck
+	stateFile    string
+	forget       *Flag // Set if any flags are remembered.
+	// Environment variable overriding the active context, set by Contexts().
+	contextsEnvar string
+
+	resolverErrorHandler ResolverErrorHandlerFunc
+	runTimeout           time.Duration
+	recoverPanics        bool
+	usageHook            UsageHookFunc
+	auditLogger          AuditLogger
+	aggregateErrors      bool
+
+	noDefaultHelp  bool
+	noExit         bool
+	envOnly        bool
+	helpHidden     bool
+	strictFlags    bool
+	posixStrict    bool
+	multiRuneShort bool
+	duplicateFlags DuplicateFlagPolicy
+	usageOnError   usageOnError
+	help           HelpPrinter
+	shortHelp      HelpPrinter
+	helpFormatter  HelpValueFormatter
+	helpOptions    HelpOptions
+	helpFlag       *Flag
+	helpHiddenFlag *Flag
+	groups         []Group
+	vars           Vars
+
+	// Options passed to New(), used to build new parsers for ParseArgs().
+	options []Option
 
 	// Set temporarily by Options. These are applied after build().
 	postBuildOptions []Option
@@ -74,6 +127,24 @@ type Kong struct {
 //
 // See the README (https://github.com/alecthomas/kong) for usage instructions.
 func New(grammar interface{}, options ...Option) (*Kong, error) {
+	k, err := newKong(options)
+	if err != nil {
+		return nil, err
+	}
+
+	model, err := build(k, grammar)
+	if err != nil {
+		return k, err
+	}
+	model.Name = filepath.Base(os.Args[0])
+	if err = k.init(model); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Create a Kong with options applied, but no model.
+func newKong(options []Option) (*Kong, error) {
 	k := &Kong{
 		Exit:          os.Exit,
 		Stdout:        os.Stdout,
@@ -82,9 +153,9 @@ func New(grammar interface{}, options ...Option) (*Kong, error) {
 		vars:          Vars{},
 		bindings:      bindings{},
 		helpFormatter: DefaultHelpValueFormatter,
-		ignoreFields:  make([]*regexp.Regexp, 0),
 	}
 
+	k.options = append([]Option(nil), options...)
 	options = append(options, Bind(k))
 
 	for _, option := range options {
@@ -100,12 +171,11 @@ func New(grammar interface{}, options ...Option) (*Kong, error) {
 	if k.shortHelp == nil {
 		k.shortHelp = DefaultShortHelpPrinter
 	}
+	return k, nil
+}
 
-	model, err := build(k, grammar)
-	if err != nil {
-		return k, err
-	}
-	model.Name = filepath.Base(os.Args[0])
+// Finish initialising k with its model.
+func (k *Kong) init(model *Application) (err error) {
 	k.Model = model
 	k.Model.HelpFlag = k.helpFlag
 
@@ -113,7 +183,7 @@ func New(grammar interface{}, options ...Option) (*Kong, error) {
 	for _, dcmd := range k.dynamicCommands {
 		tag, terr := parseTagString(strings.Join(dcmd.tags, " "))
 		if terr != nil {
-			return nil, terr
+			return terr
 		}
 		tag.Name = dcmd.name
 		tag.Help = dcmd.help
@@ -125,24 +195,49 @@ func New(grammar interface{}, options ...Option) (*Kong, error) {
 			Type: v.Type(),
 		}, v, tag, dcmd.name, map[string]bool{})
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	for _, option := range k.postBuildOptions {
 		if err = option.Apply(k); err != nil {
-			return nil, err
+			return err
 		}
 	}
 	k.postBuildOptions = nil
 
 	if err = k.interpolate(k.Model.Node); err != nil {
-		return nil, err
+		return err
 	}
 
+	if err = k.checkNamedHooks(k.Model.Node); err != nil {
+		return err
+	}
+
+	if err = k.checkNamedResolvers(k.Model.Node); err != nil {
+		return err
+	}
+
+	if err = k.checkNamedPredictors(k.Model.Node); err != nil {
+		return err
+	}
+
+	k.commandIndex = indexCommands(k.Model.Node)
+
 	k.bindings.add(k.vars)
+	return nil
+}
 
-	return k, nil
+// Check that def, a default of value, can be decoded by its mapper, without changing the value.
+//
+// Defaults referencing an indirection aren't checked, as they're only loaded when applied.
+func checkDefault(value *Value, def string) error {
+	if !value.Target.IsValid() || len(value.indirections) > 0 {
+		return nil
+	}
+	set := value.Set
+	defer func() { value.Set = set }()
+	return value.Parse(ScanFromTokens(Token{Type: FlagValueToken, Value: def}), reflect.New(value.Target.Type()).Elem())
 }
 
 type varStack []Vars
@@ -186,12 +281,27 @@ func (k *Kong) interpolateValue(value *Value, vars Vars) (err error) {
 	if varsContributor, ok := value.Mapper.(VarsContributor); ok {
 		vars = vars.CloneWith(varsContributor.Vars(value))
 	}
+	if profileDefault, ok := value.Tag.Profiles[vars["profile"]]; ok {
+		value.Default = profileDefault
+	}
 	if value.Default, err = interpolate(value.Default, vars, nil); err != nil {
 		return fmt.Errorf("default value for %s: %s", value.Summary(), err)
 	}
 	if value.Enum, err = interpolate(value.Enum, vars, nil); err != nil {
 		return fmt.Errorf("enum value for %s: %s", value.Summary(), err)
 	}
+	// The defaults of every profile are checked, not just the selected one.
+	for profile, profileDefault := range value.Tag.Profiles {
+		if profileDefault, err = interpolate(profileDefault, vars, nil); err != nil {
+			return fmt.Errorf("default value for %s in profile %q: %s", value.ShortSummary(), profile, err)
+		}
+		if value.Enum != "" && !value.IsSlice() && !value.EnumMap()[profileDefault] {
+			return fmt.Errorf("default value %q for %s in profile %q must be one of %s", profileDefault, value.ShortSummary(), profile, value.Enum)
+		}
+		if err = checkDefault(value, profileDefault); err != nil {
+			return fmt.Errorf("default value %q in profile %q: %s", profileDefault, profile, err)
+		}
+	}
 	value.Help, err = interpolate(value.Help, vars, map[string]string{
 		"default": value.Default,
 		"enum":    value.Enum,
@@ -204,25 +314,44 @@ func (k *Kong) interpolateValue(value *Value, vars Vars) (err error) {
 
 // Provide additional builtin flags, if any.
 func (k *Kong) extraFlags() []*Flag {
-	if k.noDefaultHelp {
-		return nil
+	flags := []*Flag{}
+	if !k.noDefaultHelp {
+		var helpTarget helpValue
+		value := reflect.ValueOf(&helpTarget).Elem()
+		helpFlag := &Flag{
+			Short: 'h',
+			Value: &Value{
+				Name:         "help",
+				Help:         "Show context-sensitive help.",
+				Target:       value,
+				Tag:          &Tag{},
+				Mapper:       k.registry.ForValue(value),
+				DefaultValue: reflect.ValueOf(false),
+			},
+		}
+		helpFlag.Flag = helpFlag
+		k.helpFlag = helpFlag
+		flags = append(flags, helpFlag)
+	}
+	if k.helpHidden {
+		var helpHiddenTarget helpHiddenValue
+		value := reflect.ValueOf(&helpHiddenTarget).Elem()
+		helpHiddenFlag := &Flag{
+			Hidden: true,
+			Value: &Value{
+				Name:         "help-hidden",
+				Help:         "Show help, including hidden flags and commands.",
+				Target:       value,
+				Tag:          &Tag{},
+				Mapper:       k.registry.ForValue(value),
+				DefaultValue: reflect.ValueOf(false),
+			},
+		}
+		helpHiddenFlag.Flag = helpHiddenFlag
+		k.helpHiddenFlag = helpHiddenFlag
+		flags = append(flags, helpHiddenFlag)
 	}
-	var helpTarget helpValue
-	value := reflect.ValueOf(&helpTarget).Elem()
-	helpFlag := &Flag{
-		Short: 'h',
-		Value: &Value{
-			Name:         "help",
-			Help:         "Show context-sensitive help.",
-			Target:       value,
-			Tag:          &Tag{},
-			Mapper:       k.registry.ForValue(value),
-			DefaultValue: reflect.ValueOf(false),
-		},
-	}
-	helpFlag.Flag = helpFlag
-	k.helpFlag = helpFlag
-	return []*Flag{helpFlag}
+	return flags
 }
 
 // Parse arguments into target.
@@ -233,6 +362,74 @@ func (k *Kong) extraFlags() []*Flag {
 // Will return a ParseError if a *semantically* invalid command-line is encountered (as opposed to a syntactically
 // invalid one, which will report a normal error).
 func (k *Kong) Parse(args []string) (ctx *Context, err error) {
+	return k.parse(args)
+}
+
+// ParseArgs parses args into a different grammar target, built with the same options as this Kong.
+//
+// This is useful when embedding Kong as a command router, eg. in bots or RPC handlers, where each request
+// should be parsed into a fresh target:
+//
+//     var cli CLI
+//     ctx, err := parser.ParseArgs(strings.Fields(message), &cli)
+//
+// The Exit function and output writers of this Kong are used.
+func (k *Kong) ParseArgs(args []string, target interface{}) (*Context, error) {
+	parser, err := k.derive(target)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(args)
+}
+
+// ParseWithVars parses args like Parse(), with additional interpolation variables for this invocation
+// only, eg. values that are only known after the parser is created such as the current project.
+//
+// The variables are available to defaults, enums and help, which are interpolated afresh for each
+// invocation. As variables must be defined when New() is called, they should be given placeholder
+// values there, or be referenced with a default, eg. "${region=us-east-1}".
+//
+//     ctx, err := parser.ParseWithVars(os.Args[1:], kong.Vars{"project": detectProject()})
+//
+// The Exit function and output writers of this Kong are used.
+func (k *Kong) ParseWithVars(args []string, vars Vars) (*Context, error) {
+	if !k.Model.Target.CanAddr() {
+		return nil, fmt.Errorf("ParseWithVars requires a parser created by New()")
+	}
+	parser, err := k.derive(k.Model.Target.Addr().Interface(), vars)
+	if err != nil {
+		return nil, err
+	}
+	return parser.Parse(args)
+}
+
+// Create a parser for target with the options of this Kong followed by options.
+//
+// The parser is built afresh by New(), so its model, variables and hooks are its own, and parsing with it
+// leaves this Kong as it was.
+func (k *Kong) derive(target interface{}, options ...Option) (*Kong, error) {
+	parser, err := New(target, append(append([]Option(nil), k.options...), options...)...)
+	if err != nil {
+		return nil, err
+	}
+	parser.Exit = k.Exit
+	parser.Stdout = k.Stdout
+	parser.Stderr = k.Stderr
+	return parser, nil
+}
+
+// Parse args, adding resolvers after the BeforeResolve hooks have been applied.
+func (k *Kong) parse(args []string, resolvers ...Resolver) (ctx *Context, err error) {
+	if k.envOnly {
+		ctx = k.envContext()
+		if len(args) > 0 {
+			return nil, &ParseError{error: fmt.Errorf("unexpected argument %s, %s is configured from the environment only", args[0], k.Model.Name), Context: ctx}
+		}
+		return k.apply(ctx, resolvers)
+	}
+	if k.completionProtocol && len(args) > 0 && args[0] == completeCommand {
+		return nil, k.writeCompletionProtocol(args[1:])
+	}
 	ctx, err = Trace(k, args)
 	if err != nil {
 		return nil, err
@@ -240,18 +437,48 @@ func (k *Kong) Parse(args []string) (ctx *Context, err error) {
 	if ctx.Error != nil {
 		return nil, &ParseError{error: ctx.Error, Context: ctx}
 	}
+	if err = ctx.checkFeatures(); err != nil {
+		return nil, &ParseError{error: err, Context: ctx}
+	}
+	return k.apply(ctx, resolvers)
+}
+
+// A Context for the root of the application without a command-line, for EnvOnly().
+func (k *Kong) envContext() *Context {
+	return &Context{
+		Kong:     k,
+		Args:     []string{},
+		Path:     []*Path{{App: k.Model, Flags: k.Model.Flags}},
+		values:   map[*Value]reflect.Value{},
+		scan:     Scan(),
+		bindings: bindings{},
+
+		repeatBaselines: map[*Node]reflect.Value{},
+	}
+}
+
+// Reset, resolve, apply and validate a traced Context.
+func (k *Kong) apply(ctx *Context, resolvers []Resolver) (_ *Context, err error) {
 	if err = ctx.Reset(); err != nil {
 		return nil, &ParseError{error: err, Context: ctx}
 	}
 	if err = k.applyHook(ctx, "BeforeResolve"); err != nil {
 		return nil, &ParseError{error: err, Context: ctx}
 	}
+	for _, resolver := range resolvers {
+		ctx.AddResolver(resolver)
+	}
+	// Reported after the BeforeApply hooks, so that --help still works with an unusable context.
+	contextErr := k.loadActiveContext(ctx)
 	if err = ctx.Resolve(); err != nil {
 		return nil, &ParseError{error: err, Context: ctx}
 	}
 	if err = k.applyHook(ctx, "BeforeApply"); err != nil {
 		return nil, &ParseError{error: err, Context: ctx}
 	}
+	if contextErr != nil {
+		return nil, &ParseError{error: contextErr, Context: ctx}
+	}
 	if _, err = ctx.Apply(); err != nil {
 		return nil, &ParseError{error: err, Context: ctx}
 	}
@@ -261,35 +488,48 @@ func (k *Kong) Parse(args []string) (ctx *Context, err error) {
 	if err = k.applyHook(ctx, "AfterApply"); err != nil {
 		return nil, &ParseError{error: err, Context: ctx}
 	}
+	if err = k.applyLifecycleHooks(ctx); err != nil {
+		return nil, &ParseError{error: err, Context: ctx}
+	}
+	if k.completionHistory != "" {
+		// History is a convenience, so failing to record it is not an error.
+		_ = k.recordCompletionHistory(ctx)
+	}
+	if k.forget != nil {
+		// As are remembered values.
+		_ = k.recordRemembered(ctx)
+	}
 	return ctx, nil
 }
 
 func (k *Kong) applyHook(ctx *Context, name string) error {
 	for _, trace := range ctx.Path {
 		var value reflect.Value
+		var tag *Tag
 		switch {
 		case trace.App != nil:
-			value = trace.App.Target
+			value, tag = trace.App.Target, trace.App.Tag
 		case trace.Argument != nil:
-			value = trace.Argument.Target
+			value, tag = trace.Argument.Target, trace.Argument.Tag
 		case trace.Command != nil:
-			value = trace.Command.Target
+			value, tag = trace.Command.Target, trace.Command.Tag
 		case trace.Positional != nil:
-			value = trace.Positional.Target
+			value, tag = trace.Positional.Target, trace.Positional.Tag
 		case trace.Flag != nil:
-			value = trace.Flag.Value.Target
+			value, tag = trace.Flag.Value.Target, trace.Flag.Value.Tag
 		default:
 			panic("unsupported Path")
 		}
-		method := getMethod(value, name)
-		if !method.IsValid() {
-			continue
-		}
 		binds := k.bindings.clone()
 		binds.add(ctx, trace)
 		binds.add(trace.Node().Vars().CloneWith(k.vars))
 		binds.merge(ctx.bindings)
-		if err := callMethod(name, value, method, binds); err != nil {
+		if method := getMethod(value, name); method.IsValid() {
+			if err := callMethod(name, value, method, binds); err != nil {
+				return err
+			}
+		}
+		if err := k.callNamedHooks(name, tag, value, binds); err != nil {
 			return err
 		}
 	}
@@ -307,17 +547,26 @@ func (k *Kong) applyHookToDefaultFlags(ctx *Context, node *Node, name string) er
 		if !ok {
 			return next(nil)
 		}
-		binds := k.bindings.clone().add(ctx).add(node.Vars().CloneWith(k.vars))
+		var binds bindings
 		for _, flag := range node.Flags {
 			if flag.Default == "" || ctx.values[flag.Value].IsValid() || !flag.Target.IsValid() {
 				continue
 			}
 			method := getMethod(flag.Target, name)
-			if !method.IsValid() {
+			if !method.IsValid() && (flag.Tag == nil || len(flag.Tag.Hooks[name]) == 0) {
 				continue
 			}
+			// Bindings are only built for nodes with hooks, as most flags have none.
+			if binds == nil {
+				binds = k.bindings.clone().add(ctx).add(node.Vars().CloneWith(k.vars))
+			}
 			path := &Path{Flag: flag}
-			if err := callMethod(name, flag.Target, method, binds.clone().add(path)); err != nil {
+			if method.IsValid() {
+				if err := callMethod(name, flag.Target, method, binds.clone().add(path)); err != nil {
+					return next(err)
+				}
+			}
+			if err := k.callNamedHooks(name, flag.Tag, flag.Target, binds.clone().add(path)); err != nil {
 				return next(err)
 			}
 		}
@@ -353,22 +602,50 @@ func (k *Kong) Errorf(format string, args ...interface{}) *Kong {
 }
 
 // Fatalf writes a message to Kong.Stderr with the application name prefixed then exits with a non-zero status.
+//
+// If the NoExit() option is set, the message is written but Fatalf returns rather than exiting.
 func (k *Kong) Fatalf(format string, args ...interface{}) {
 	k.Errorf(format, args...)
-	k.Exit(1)
+	if !k.noExit {
+		k.Exit(1)
+	}
+}
+
+// Terminate with a zero exit status, or return sentinel if the NoExit() option is set.
+func (k *Kong) exitOr(sentinel error) error {
+	if k.noExit {
+		return sentinel
+	}
+	k.Exit(0)
+	return nil
+}
+
+// Terminate with status, or return an *ExitError if the NoExit() option is set.
+func (k *Kong) exitWith(status int) error {
+	if k.noExit {
+		return &ExitError{Status: status}
+	}
+	k.Exit(status)
+	return nil
 }
 
 // FatalIfErrorf terminates with an error message if err != nil.
 func (k *Kong) FatalIfErrorf(err error, args ...interface{}) {
-	if err == nil {
+	// Help and version requests under NoExit() are outcomes, not failures.
+	if err == nil || errors.Is(err, ErrHelpRequested) || errors.Is(err, ErrVersionRequested) {
 		return
 	}
 	msg := err.Error()
 	if len(args) > 0 {
 		msg = fmt.Sprintf(args[0].(string), args[1:]...) + ": " + err.Error()
 	}
+	kind, node := HintError, k.Model.Node
 	// Maybe display usage information.
 	if err, ok := err.(*ParseError); ok {
+		kind = HintParseError
+		if selected := err.Context.Selected(); selected != nil {
+			node = selected
+		}
 		switch k.usageOnError {
 		case fullUsage:
 			_ = k.help(k.helpOptions, err.Context)
@@ -378,7 +655,11 @@ func (k *Kong) FatalIfErrorf(err error, args ...interface{}) {
 			fmt.Fprintln(k.Stdout)
 		}
 	}
-	k.Fatalf("%s", msg)
+	k.Errorf("%s", msg)
+	k.writeHints(k.Stderr, kind, node, err)
+	if !k.noExit {
+		k.Exit(1)
+	}
 }
 
 // LoadConfig from path using the loader configured via Configuration(loader).
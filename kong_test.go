@@ -1,8 +1,12 @@
 package kong_test
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"reflect"
+	"regexp"
 	"strings"
 	"testing"
 
@@ -46,6 +50,53 @@ func TestPositionalArguments(t *testing.T) {
 	})
 }
 
+func TestVariadicPositionalFollowedByRequired(t *testing.T) {
+	type cli struct {
+		Verbose bool     `short:"v"`
+		Src     []string `arg:""`
+		Dst     string   `arg:""`
+	}
+	var c cli
+	_, err := mustNew(t, &c).Parse([]string{"-v", "a", "b", "c", "dest"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, c.Src)
+	require.Equal(t, "dest", c.Dst)
+	require.True(t, c.Verbose)
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"a", "dest"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, c.Src)
+	require.Equal(t, "dest", c.Dst)
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"dest"})
+	require.EqualError(t, err, "missing positional arguments <src>")
+
+	// Every argument is reserved for <dst>, leaving the required <src> empty, including when the parser
+	// is reused after <src> was set.
+	c = cli{}
+	p := mustNew(t, &c)
+	_, err = p.Parse([]string{"a", "dest"})
+	require.NoError(t, err)
+	_, err = p.Parse([]string{"dest"})
+	require.EqualError(t, err, "missing positional arguments <src>")
+	_, err = p.Parse([]string{"-v", "dest"})
+	require.EqualError(t, err, "missing positional arguments <src>")
+
+	// Flags may be given between the positionals.
+	for _, args := range [][]string{
+		{"a", "b", "-v", "dest"},
+		{"a", "-v", "b", "dest"},
+		{"a", "b", "dest", "-v"},
+	} {
+		c = cli{}
+		_, err = mustNew(t, &c).Parse(args)
+		require.NoError(t, err, args)
+		require.Equal(t, cli{Verbose: true, Src: []string{"a", "b"}, Dst: "dest"}, c, args)
+	}
+}
+
 func TestBranchingArgument(t *testing.T) {
 	/*
 		app user create <id> <first> <last>
@@ -83,6 +134,107 @@ func TestBranchingArgument(t *testing.T) {
 	})
 }
 
+func TestBranchingArgumentTypedKeys(t *testing.T) {
+	var cli struct {
+		Job struct {
+			List struct{} `cmd:""`
+			ID   struct {
+				ID     int      `arg:""`
+				Cancel struct{} `cmd:""`
+			} `arg:""`
+		} `cmd:""`
+		Env struct {
+			Env struct {
+				Env    string   `arg:"" enum:"dev,prod"`
+				Deploy struct{} `cmd:""`
+			} `arg:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"job", "list"})
+	require.NoError(t, err)
+	require.Equal(t, "job list", ctx.Command())
+
+	ctx, err = p.Parse([]string{"job", "12", "cancel"})
+	require.NoError(t, err)
+	require.Equal(t, "job <id> cancel", ctx.Command())
+	require.Equal(t, 12, cli.Job.ID.ID)
+
+	_, err = p.Parse([]string{"job", "abc", "cancel"})
+	require.EqualError(t, err, `unexpected argument abc (<id>: expected a valid 64 bit int but got "abc")`)
+
+	_, err = p.Parse([]string{"job", "lst"})
+	require.EqualError(t, err, `unexpected argument lst (<id>: expected a valid 64 bit int but got "lst"), did you mean "list"?`)
+
+	ctx, err = p.Parse([]string{"env", "prod", "deploy"})
+	require.NoError(t, err)
+	require.Equal(t, "env <env> deploy", ctx.Command())
+	require.Equal(t, "prod", cli.Env.Env.Env)
+
+	_, err = p.Parse([]string{"env", "staging", "deploy"})
+	require.EqualError(t, err, `unexpected argument staging (<env> must be one of "dev","prod" but got "staging")`)
+}
+
+func TestSiblingBranchingArguments(t *testing.T) {
+	var cli struct {
+		Show struct {
+			SHA struct {
+				SHA   string `arg:"" type:"sha"`
+				Patch bool
+			} `arg:""`
+			Tag struct {
+				Tag string `arg:"" enum:"latest,stable"`
+			} `arg:""`
+			Ref struct {
+				Ref string `arg:""`
+			} `arg:""`
+		} `cmd:""`
+	}
+	sha := regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+	p := mustNew(t, &cli, kong.NamedMapper("sha", kong.MapperFunc(func(ctx *kong.DecodeContext, target reflect.Value) error {
+		var value string
+		if err := ctx.Scan.PopValueInto("sha", &value); err != nil {
+			return err
+		}
+		if !sha.MatchString(value) {
+			return fmt.Errorf("%q is not a commit SHA", value)
+		}
+		target.SetString(value)
+		return nil
+	})))
+	ctx, err := p.Parse([]string{"show", "3f2a9c1", "--patch"})
+	require.NoError(t, err)
+	require.Equal(t, "show <sha>", ctx.Command())
+	require.Equal(t, "3f2a9c1", cli.Show.SHA.SHA)
+	require.True(t, cli.Show.SHA.Patch)
+
+	ctx, err = p.Parse([]string{"show", "stable"})
+	require.NoError(t, err)
+	require.Equal(t, "show <tag>", ctx.Command())
+	require.Equal(t, "stable", cli.Show.Tag.Tag)
+
+	ctx, err = p.Parse([]string{"show", "main"})
+	require.NoError(t, err)
+	require.Equal(t, "show <ref>", ctx.Command())
+	require.Equal(t, "main", cli.Show.Ref.Ref)
+}
+
+func TestSiblingBranchingArgumentsCombinedError(t *testing.T) {
+	var cli struct {
+		Get struct {
+			ID struct {
+				ID int `arg:""`
+			} `arg:""`
+			Level struct {
+				Level string `arg:"" enum:"debug,info"`
+			} `arg:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"get", "warn"})
+	require.EqualError(t, err, `unexpected argument warn (<id>: expected a valid 64 bit int but got "warn"; <level> must be one of "debug","info" but got "warn")`)
+}
+
 func TestResetWithDefaults(t *testing.T) {
 	var cli struct {
 		Flag            string
@@ -761,6 +913,68 @@ func TestHooksCalledForDefault(t *testing.T) {
 	require.Equal(t, []string{"before:default", "after:default"}, ctx.values)
 }
 
+func TestNamedHooks(t *testing.T) {
+	type sessionCmd struct {
+		User string `arg:""`
+	}
+	var cli struct {
+		Token   string     `default:"anonymous" afterapply:"trim,record"`
+		Session sessionCmd `cmd:"" beforeapply:"record"`
+	}
+	calls := []string{}
+	p := mustNew(t, &cli,
+		kong.NamedHook("trim", func(token *string) error {
+			*token = strings.TrimPrefix(*token, "token-")
+			return nil
+		}),
+		kong.NamedHook("record", func(path *kong.Path) error {
+			if path.Flag != nil {
+				calls = append(calls, path.Flag.Name)
+			} else {
+				calls = append(calls, path.Node().Name)
+			}
+			return nil
+		}))
+	_, err := p.Parse([]string{"session", "alice", "--token=token-abc"})
+	require.NoError(t, err)
+	require.Equal(t, "abc", cli.Token)
+	require.Equal(t, []string{"session", "token"}, calls)
+
+	calls = []string{}
+	_, err = p.Parse([]string{"session", "bob"})
+	require.NoError(t, err)
+	require.Equal(t, "anonymous", cli.Token)
+	require.Equal(t, []string{"session", "token"}, calls)
+
+	_, err = kong.New(&cli)
+	require.EqualError(t, err, `unknown hook "trim", use kong.NamedHook("trim", fn)`)
+
+	_, err = kong.New(&cli, kong.NamedHook("trim", func() {}))
+	require.EqualError(t, err, `named hook "trim" must be a function returning exactly "error" but got func()`)
+}
+
+func TestMisplacedCommandSuggestion(t *testing.T) {
+	var cli struct {
+		Server struct {
+			Start struct{} `cmd:""`
+			Stop  struct{} `cmd:""`
+		} `cmd:""`
+		Worker struct {
+			Stop struct{} `cmd:"" aliases:"halt"`
+		} `cmd:""`
+		Debug struct {
+			Start struct{} `cmd:""`
+		} `cmd:"" hidden:""`
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"start"})
+	require.EqualError(t, err, `unexpected argument start, did you mean "server start"?`)
+	_, err = p.Parse([]string{"stop"})
+	require.EqualError(t, err, `unexpected argument stop, did you mean one of "server stop", "worker stop"?`)
+	_, err = p.Parse([]string{"server", "halt"})
+	require.EqualError(t, err, `unexpected argument halt, did you mean "worker stop"?`)
+}
+
 func TestEnum(t *testing.T) {
 	var cli struct {
 		Flag string `enum:"a,b,c" required:""`
@@ -814,6 +1028,34 @@ func TestDefaultValueIsHyphen(t *testing.T) {
 	require.Equal(t, "-", cli.Flag)
 }
 
+func TestDefaultsReappliedOnRepeatedParse(t *testing.T) {
+	var cli struct {
+		Name   string            `default:"bob"`
+		Count  int               `default:"3"`
+		Tags   []string          `default:"a,b"`
+		Labels map[string]string `default:"k=v"`
+		Envar  string            `env:"KONG_TEST_REPEATED" default:"none"`
+		Plain  string
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"--name=alice", "--count=1", "--plain=x"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", cli.Name)
+	cli.Tags[0] = "mutated"
+	cli.Labels["k"] = "mutated"
+
+	restore := tempEnv(map[string]string{"KONG_TEST_REPEATED": "set"})
+	defer restore()
+	_, err = p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "bob", cli.Name)
+	require.Equal(t, 3, cli.Count)
+	require.Equal(t, []string{"a", "b"}, cli.Tags)
+	require.Equal(t, map[string]string{"k": "v"}, cli.Labels)
+	require.Equal(t, "set", cli.Envar)
+	require.Equal(t, "", cli.Plain)
+}
+
 func TestDefaultEnumValidated(t *testing.T) {
 	var cli struct {
 		Flag string `default:"invalid" enum:"valid"`
@@ -919,6 +1161,24 @@ func TestXorRequiredMany(t *testing.T) {
 	require.EqualError(t, err, "missing flags: --one or --two or --three")
 }
 
+func TestAnd(t *testing.T) {
+	var cli struct {
+		Username string `and:"auth"`
+		Password string `and:"auth"`
+		Verbose  bool
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"--username=alice", "--password=secret"})
+	require.NoError(t, err)
+
+	_, err = p.Parse([]string{"--verbose"})
+	require.NoError(t, err)
+
+	p = mustNew(t, &cli)
+	_, err = p.Parse([]string{"--password=secret"})
+	require.EqualError(t, err, "--password must be used with --username")
+}
+
 func TestEnumSequence(t *testing.T) {
 	var cli struct {
 		State []string `enum:"a,b,c" default:"a"`
@@ -929,6 +1189,20 @@ func TestEnumSequence(t *testing.T) {
 	require.Equal(t, []string{"a"}, cli.State)
 }
 
+func TestEnumMap(t *testing.T) {
+	var cli struct {
+		Levels map[string]string `enum:"debug,info" default:"app=info"`
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"--levels=db=debug;web=info"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"db": "debug", "web": "info"}, cli.Levels)
+
+	p = mustNew(t, &cli)
+	_, err = p.Parse([]string{"--levels=db=warn"})
+	require.EqualError(t, err, `--levels must be one of "debug","info" but got "warn"`)
+}
+
 func TestIssue40EnumAcrossCommands(t *testing.T) {
 	var cli struct {
 		One struct {
@@ -1128,6 +1402,37 @@ func TestDefaultCommandPrecedence(t *testing.T) {
 	require.EqualError(t, err, "unknown flag --flag")
 }
 
+type selectorCLI struct {
+	UI     struct{} `cmd:""`
+	Server struct{} `cmd:"" default:"1"`
+
+	selected string
+}
+
+func (s *selectorCLI) SelectDefaultCommand(ctx *kong.Context) string {
+	return s.selected
+}
+
+func TestDefaultCommandSelector(t *testing.T) {
+	cli := selectorCLI{selected: "ui"}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "ui", ctx.Command())
+
+	ctx, err = p.Parse([]string{"server"})
+	require.NoError(t, err)
+	require.Equal(t, "server", ctx.Command())
+
+	cli.selected = ""
+	_, err = p.Parse(nil)
+	require.EqualError(t, err, `expected one of "ui",  "server"`)
+
+	cli.selected = "missing"
+	_, err = p.Parse(nil)
+	require.EqualError(t, err, `default command "missing" is not a sub-command of test`)
+}
+
 func TestLoneHpyhen(t *testing.T) {
 	var cli struct {
 		Flag string
@@ -1348,6 +1653,34 @@ func TestIgnoreRegexEmpty(t *testing.T) {
 	require.Contains(t, "regex input cannot be empty", err.Error())
 }
 
+type ignoredPosition struct {
+	Line int
+}
+
+func TestIgnoreFieldsByTagTypeAndFunc(t *testing.T) {
+	var cli struct {
+		Flag     bool
+		Internal bool            `ignore-group:"internal"`
+		Debug    bool            `kong:"ignore-group=internal"`
+		Pos      ignoredPosition `kong:"embed"`
+		EndPos   *ignoredPosition
+		Secret   string `hidden:""`
+	}
+	p := mustNew(t, &cli,
+		kong.IgnoreGroups("internal"),
+		kong.IgnoreTypes(ignoredPosition{}, (*ignoredPosition)(nil)),
+		kong.IgnoreFieldsFunc(func(field reflect.StructField) bool {
+			return field.Name == "Secret"
+		}))
+	_, err := p.Parse([]string{"--flag"})
+	require.NoError(t, err)
+	require.True(t, cli.Flag)
+	for _, flag := range []string{"--internal", "--debug", "--line", "--end-pos", "--secret"} {
+		_, err = p.Parse([]string{flag})
+		require.EqualError(t, err, "unknown flag "+flag)
+	}
+}
+
 type optionWithErr struct{}
 
 func (o *optionWithErr) Apply(k *kong.Kong) error {
@@ -1365,3 +1698,562 @@ func TestOptionReturnsErr(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, "option returned err", err.Error())
 }
+
+func TestFlagAs(t *testing.T) {
+	var cli struct {
+		Verbose bool
+		Level   int `default:"2"`
+		Cmd     struct {
+			Name string
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"--verbose", "cmd", "--name=foo"})
+	require.NoError(t, err)
+
+	verbose, err := kong.FlagAs[bool](ctx, "verbose")
+	require.NoError(t, err)
+	require.True(t, verbose)
+
+	level, err := kong.FlagAs[int](ctx, "level")
+	require.NoError(t, err)
+	require.Equal(t, 2, level)
+
+	name, err := kong.FlagAs[string](ctx, "name")
+	require.NoError(t, err)
+	require.Equal(t, "foo", name)
+
+	_, err = kong.FlagAs[string](ctx, "level")
+	require.EqualError(t, err, "--level is of type int, not string")
+
+	_, err = kong.FlagAs[string](ctx, "missing")
+	require.EqualError(t, err, "unknown flag --missing")
+}
+
+func TestStrictFlags(t *testing.T) {
+	type cli struct {
+		Debug bool `short:"d"`
+		Run   struct {
+			Rm bool
+		} `cmd:""`
+	}
+	var c cli
+	_, err := mustNew(t, &c, kong.StrictFlags()).Parse([]string{"--debug", "run", "--rm"})
+	require.NoError(t, err)
+	require.True(t, c.Debug)
+	require.True(t, c.Run.Rm)
+
+	c = cli{}
+	_, err = mustNew(t, &c, kong.StrictFlags()).Parse([]string{"run", "--rm", "-d"})
+	require.EqualError(t, err, `-d must be specified before "run"`)
+
+	c = cli{}
+	_, err = mustNew(t, &c, kong.StrictFlags()).Parse([]string{"--rm", "run"})
+	require.EqualError(t, err, "unknown flag --rm")
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"run", "--rm", "-d"})
+	require.NoError(t, err)
+}
+
+func TestShortOnlyFlags(t *testing.T) {
+	type cli struct {
+		Extract bool   `short:"x" shortonly:"" help:"Extract files from an archive."`
+		File    string `short:"f" shortonly:"" placeholder:"ARCHIVE" help:"Use archive file."`
+		Verbose bool   `short:"v" help:"Verbosely list files processed."`
+	}
+	var c cli
+	_, err := mustNew(t, &c).Parse([]string{"-xvf", "a.tar"})
+	require.NoError(t, err)
+	require.Equal(t, cli{Extract: true, File: "a.tar", Verbose: true}, c)
+
+	_, err = mustNew(t, &cli{}).Parse([]string{"--extract"})
+	require.EqualError(t, err, "unknown flag --extract")
+
+	_, err = mustNew(t, &cli{}).Parse([]string{"-f"})
+	require.EqualError(t, err, "-f: expected string value but got \"EOL\" (<EOL>)")
+
+	w := &strings.Builder{}
+	p := mustNew(t, &cli{}, kong.Writers(w, w), kong.Exit(func(int) { panic(true) }))
+	require.PanicsWithValue(t, true, func() {
+		_, err := p.Parse([]string{"--help"})
+		require.NoError(t, err)
+	})
+	require.Contains(t, w.String(), `
+  -x               Extract files from an archive.
+  -f ARCHIVE       Use archive file.
+  -v, --verbose    Verbosely list files processed.
+`)
+
+	completions := []string{}
+	for _, completion := range mustNew(t, &cli{}).Complete([]string{"-"}) {
+		completions = append(completions, completion.Value)
+	}
+	require.Equal(t, []string{"--help", "-h", "-x", "-f", "--verbose", "-v"}, completions)
+
+	type invalid struct {
+		Extract bool `shortonly:""`
+	}
+	_, err = kong.New(&invalid{})
+	require.EqualError(t, err, "invalid.Extract: shortonly requires a short flag name")
+}
+
+func TestMultiRuneShortFlags(t *testing.T) {
+	type cli struct {
+		Rf      bool     `short:"rf" help:"Remove recursively."`
+		Verbose bool     `short:"v"`
+		Linker  []string `short:"Wl" sep:"none" help:"Pass options to the linker."`
+		Warning []string `short:"W"`
+		Output  string   `short:"o"`
+	}
+	for _, test := range []struct {
+		args     []string
+		expected cli
+	}{
+		{[]string{"-rf"}, cli{Rf: true}},
+		{[]string{"-rfv"}, cli{Rf: true, Verbose: true}},
+		{[]string{"-Wall", "-Wl,--as-needed"}, cli{Warning: []string{"all"}, Linker: []string{",--as-needed"}}},
+		{[]string{"-Wl", "out.map", "-oout"}, cli{Linker: []string{"out.map"}, Output: "out"}},
+	} {
+		var c cli
+		_, err := mustNew(t, &c, kong.MultiRuneShortFlags()).Parse(test.args)
+		require.NoError(t, err, "%q", test.args)
+		require.Equal(t, test.expected, c, "%q", test.args)
+	}
+
+	w := &strings.Builder{}
+	p := mustNew(t, &cli{}, kong.MultiRuneShortFlags(), kong.Writers(w, w), kong.Exit(func(int) { panic(true) }))
+	require.PanicsWithValue(t, true, func() {
+		_, err := p.Parse([]string{"--help"})
+		require.NoError(t, err)
+	})
+	require.Contains(t, w.String(), "  -rf, --rf                    Remove recursively.\n")
+	require.Contains(t, w.String(), "  -Wl, --linker=LINKER         Pass options to the linker.\n")
+
+	_, err := kong.New(&cli{})
+	require.EqualError(t, err, `cli.Rf: invalid short flag name "rf": invalid rune`)
+}
+
+func TestPOSIXStrict(t *testing.T) {
+	type cli struct {
+		Force  bool   `short:"f"`
+		Output string `short:"o"`
+		Rm     struct {
+			Recursive bool     `short:"r"`
+			Files     []string `arg:""`
+		} `cmd:""`
+	}
+	var c cli
+	_, err := mustNew(t, &c, kong.POSIXStrict()).Parse([]string{"-f", "rm", "-r", "a", "-f", "--", "-o"})
+	require.NoError(t, err)
+	require.True(t, c.Force)
+	require.True(t, c.Rm.Recursive)
+	require.Equal(t, []string{"a", "-f", "--", "-o"}, c.Rm.Files)
+
+	c = cli{}
+	_, err = mustNew(t, &c, kong.POSIXStrict()).Parse([]string{"-oout", "-o", "out", "rm", "--", "-r"})
+	require.NoError(t, err)
+	require.Equal(t, "out", c.Output)
+	require.False(t, c.Rm.Recursive)
+	require.Equal(t, []string{"-r"}, c.Rm.Files)
+
+	c = cli{}
+	_, err = mustNew(t, &c, kong.POSIXStrict()).Parse([]string{"-o=out", "rm", "a"})
+	require.EqualError(t, err, `unexpected "=" after short flag -o, use -oVALUE or -o VALUE`)
+
+	c = cli{}
+	_, err = mustNew(t, &c, kong.POSIXStrict()).Parse([]string{"-fo=out", "rm", "a"})
+	require.EqualError(t, err, `unexpected "=" after short flag -o, use -oVALUE or -o VALUE`)
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"rm", "a", "-r"})
+	require.NoError(t, err)
+	require.True(t, c.Rm.Recursive)
+	require.Equal(t, []string{"a"}, c.Rm.Files)
+}
+
+func TestParseArgs(t *testing.T) {
+	type cli struct {
+		Name  string
+		Greet struct{} `cmd:""`
+	}
+	var first cli
+	p := mustNew(t, &first)
+	var second cli
+	ctx, err := p.ParseArgs([]string{"--name=bob", "greet"}, &second)
+	require.NoError(t, err)
+	require.Equal(t, "greet", ctx.Command())
+	require.Equal(t, []string{"--name=bob", "greet"}, ctx.Args)
+	require.Equal(t, ctx.Args, ctx.ExpandedArgs)
+	require.Equal(t, "bob", second.Name)
+	require.Equal(t, "", first.Name)
+
+	_, err = p.ParseArgs([]string{"--unknown"}, &second)
+	require.EqualError(t, err, "unknown flag --unknown")
+}
+
+func TestParseWithVars(t *testing.T) {
+	var cli struct {
+		Region  string `default:"${region}" help:"Region to use (default ${default})."`
+		Project string `default:"${project=none}"`
+	}
+	w := &strings.Builder{}
+	p := mustNew(t, &cli, kong.Vars{"region": "us-east-1"}, kong.Writers(w, w), kong.Exit(func(int) {}))
+	_, err := p.ParseWithVars(nil, kong.Vars{"region": "eu-west-1", "project": "kong"})
+	require.NoError(t, err)
+	require.Equal(t, "eu-west-1", cli.Region)
+	require.Equal(t, "kong", cli.Project)
+
+	_, err = p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "us-east-1", cli.Region)
+	require.Equal(t, "none", cli.Project)
+
+	_, err = p.ParseWithVars([]string{"--help"}, kong.Vars{"region": "ap-south-1"})
+	require.NoError(t, err)
+	require.Contains(t, w.String(), "Region to use (default ap-south-1).")
+}
+
+func TestParseWithVarsIsolated(t *testing.T) {
+	var cli struct {
+		Region  string `default:"${region}"`
+		Project string `default:"${project=none}"`
+		Hooked  string `default:"${region}" afterapply:"record"`
+	}
+	seen := []string{}
+	p := mustNew(t, &cli, kong.Vars{"region": "us-east-1"},
+		kong.NamedHook("record", func(value *string, vars kong.Vars) error {
+			seen = append(seen, *value+"/"+vars["region"])
+			return nil
+		}))
+	_, err := p.ParseWithVars(nil, kong.Vars{"region": "eu-west-1", "project": "kong"})
+	require.NoError(t, err)
+	require.Equal(t, "eu-west-1", cli.Region)
+	require.Equal(t, "kong", cli.Project)
+
+	// Variables of the previous invocation don't leak into the next, or into the parser.
+	_, err = p.ParseWithVars(nil, kong.Vars{"project": "other"})
+	require.NoError(t, err)
+	require.Equal(t, "us-east-1", cli.Region)
+	require.Equal(t, "other", cli.Project)
+
+	_, err = p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "us-east-1", cli.Region)
+	require.Equal(t, "none", cli.Project)
+	require.Equal(t, []string{"eu-west-1/eu-west-1", "us-east-1/us-east-1", "us-east-1/us-east-1"}, seen)
+}
+
+type writerCmd struct{}
+
+func (writerCmd) Run(w io.Writer, buffered *bufio.Writer) error {
+	fmt.Fprint(w, "unbuffered ")
+	fmt.Fprint(buffered, "buffered")
+	return nil
+}
+
+func TestRunOutputWriterBindings(t *testing.T) {
+	var cli struct {
+		Write writerCmd `cmd:""`
+	}
+	w := &strings.Builder{}
+	p := mustNew(t, &cli, kong.Writers(w, w))
+	ctx, err := p.Parse([]string{"write"})
+	require.NoError(t, err)
+	err = ctx.Run()
+	require.NoError(t, err)
+	require.Equal(t, "unbuffered buffered", w.String())
+}
+
+func TestUserAliases(t *testing.T) {
+	type cli struct {
+		Debug  bool
+		Deploy struct {
+			Env     string   `required:""`
+			Service string   `arg:""`
+			Extra   []string `arg:"" optional:""`
+		} `cmd:"" aliases:"d"`
+	}
+	aliases := kong.UserAliases(map[string]string{
+		"deploy-prod": "deploy --env prod $1",
+		"deploy-all":  "deploy --env=$2 $1 $@",
+		"d":           "should not shadow the command alias",
+		"broken":      `deploy "unterminated`,
+	})
+	var c cli
+	p := mustNew(t, &c, aliases)
+	ctx, err := p.Parse([]string{"--debug", "deploy-prod", "api", "x"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"--debug", "deploy-prod", "api", "x"}, ctx.Args)
+	require.Equal(t, []string{"--debug", "deploy", "--env", "prod", "api", "x"}, ctx.ExpandedArgs)
+	require.Equal(t, "prod", c.Deploy.Env)
+	require.Equal(t, "api", c.Deploy.Service)
+	require.Equal(t, []string{"x"}, c.Deploy.Extra)
+
+	c = cli{}
+	p = mustNew(t, &c, aliases)
+	ctx, err = p.Parse([]string{"deploy-all", "web", "staging", "a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploy", "--env=staging", "web", "a", "b"}, ctx.ExpandedArgs)
+
+	c = cli{}
+	p = mustNew(t, &c, aliases)
+	_, err = p.Parse([]string{"d", "--env=dev", "api"})
+	require.NoError(t, err)
+	require.Equal(t, "dev", c.Deploy.Env)
+
+	_, err = p.Parse([]string{"deploy-all", "web"})
+	require.EqualError(t, err, `alias "deploy-all" expects at least 2 argument(s) but got 1`)
+
+	_, err = p.Parse([]string{"broken"})
+	require.Error(t, err)
+}
+
+func TestNoExit(t *testing.T) {
+	var cli struct {
+		Version kong.VersionFlag
+		Flag    bool
+	}
+	stdout := &bytes.Buffer{}
+	p, err := kong.New(&cli,
+		kong.NoExit(),
+		kong.Exit(func(int) { t.Fatal("unexpected exit()") }),
+		kong.Writers(stdout, stdout),
+		kong.Vars{"version": "1.2.3"},
+	)
+	require.NoError(t, err)
+
+	_, err = p.Parse([]string{"--help"})
+	require.True(t, errors.Is(err, kong.ErrHelpRequested))
+	var parseErr *kong.ParseError
+	require.True(t, errors.As(err, &parseErr))
+	require.Contains(t, stdout.String(), "Usage:")
+
+	stdout.Reset()
+	_, err = p.Parse([]string{"--version"})
+	require.True(t, errors.Is(err, kong.ErrVersionRequested))
+	require.Equal(t, "1.2.3\n", stdout.String())
+
+	// Fatal errors are reported without exiting, and help requests are not errors.
+	stdout.Reset()
+	p.FatalIfErrorf(err)
+	require.Empty(t, stdout.String())
+	_, err = p.Parse([]string{"--unknown"})
+	p.FatalIfErrorf(err)
+	require.Contains(t, stdout.String(), "error: unknown flag --unknown")
+}
+
+type aggregateValidated string
+
+func (a aggregateValidated) Validate() error {
+	if a == "" {
+		return nil
+	}
+	return errors.New("must be empty")
+}
+
+func TestAggregateErrors(t *testing.T) {
+	type aggregateCLI struct {
+		Name   string             `required:""`
+		Region string             `required:""`
+		Level  string             `enum:"debug,info" default:"info"`
+		Check  aggregateValidated `name:"check"`
+		A      bool               `xor:"ab"`
+		B      bool               `xor:"ab"`
+		Path   string             `arg:""`
+	}
+	var cli aggregateCLI
+	_, err := mustNew(t, &cli, kong.AggregateErrors()).Parse([]string{"--level=trace", "--check=x", "--a", "--b"})
+	require.EqualError(t, err, `5 validation errors:
+  - --level must be one of "debug","info" but got "trace"
+  - --check: must be empty
+  - missing flags: --name=STRING, --region=STRING
+  - expected "<path>"
+  - --a and --b can't be used together`)
+	var errs kong.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 5)
+
+	cli = aggregateCLI{}
+	_, err = mustNew(t, &cli, kong.AggregateErrors()).Parse([]string{"--name=x", "--region=y", "path"})
+	require.NoError(t, err)
+
+	cli = aggregateCLI{}
+	_, err = mustNew(t, &cli, kong.AggregateErrors()).Parse([]string{"--name=x", "--region=y"})
+	require.EqualError(t, err, `expected "<path>"`)
+
+	cli = aggregateCLI{}
+	_, err = mustNew(t, &cli).Parse([]string{"--level=trace", "--check=x"})
+	require.EqualError(t, err, `--level must be one of "debug","info" but got "trace"`)
+}
+
+type deployer interface {
+	Run() error
+}
+
+type ossDeploy struct {
+	Env string `arg:""`
+
+	ran bool
+}
+
+func (o *ossDeploy) Run() error {
+	o.ran = true
+	return nil
+}
+
+type enterpriseDeploy struct {
+	Env    string `arg:""`
+	Region string `default:"us-east-1"`
+}
+
+func (e *enterpriseDeploy) Run() error { return nil }
+
+func TestImplement(t *testing.T) {
+	type cliType struct {
+		Deploy deployer `cmd:"" help:"Deploy the application."`
+	}
+	var cli cliType
+	ctx, err := mustNew(t, &cli, kong.Implement((*deployer)(nil), &ossDeploy{})).Parse([]string{"deploy", "prod"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	require.Equal(t, &ossDeploy{Env: "prod", ran: true}, cli.Deploy)
+
+	cli = cliType{}
+	_, err = mustNew(t, &cli, kong.Implement((*deployer)(nil), &enterpriseDeploy{})).Parse([]string{"deploy", "prod", "--region=eu-west-1"})
+	require.NoError(t, err)
+	require.Equal(t, &enterpriseDeploy{Env: "prod", Region: "eu-west-1"}, cli.Deploy)
+
+	cli = cliType{Deploy: &enterpriseDeploy{}}
+	_, err = mustNew(t, &cli, kong.Implement((*deployer)(nil), &ossDeploy{})).Parse([]string{"deploy", "dev"})
+	require.NoError(t, err)
+	require.Equal(t, &enterpriseDeploy{Env: "dev", Region: "us-east-1"}, cli.Deploy)
+
+	cli = cliType{}
+	_, err = kong.New(&cli)
+	require.EqualError(t, err, "cliType.Deploy: no implementation of kong_test.deployer, use kong.Implement()")
+
+	_, err = kong.New(&cli, kong.Implement((*deployer)(nil), &struct{}{}))
+	require.EqualError(t, err, "*struct {} does not implement kong_test.deployer")
+}
+
+func TestCommandLine(t *testing.T) {
+	var cli struct {
+		Sudo    bool   `short:"s"`
+		Config  string `short:"c"`
+		Verbose bool   `short:"v"`
+		Deploy  struct {
+			Env   string `arg:""`
+			Force bool
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"-s", "--config", "a.json", "deploy", "prod", "-v", "-cb.json", "--force"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"deploy", "prod", "--force"}, ctx.CommandLine("sudo", "c", "verbose"))
+	require.Equal(t, []string{"-s", "deploy", "prod", "-v", "--force"}, ctx.CommandLine("config"))
+	ctx, err = p.Parse([]string{"--config=a.json", "-sv", "deploy", "prod"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"-sv", "deploy", "prod"}, ctx.CommandLine("config", "sudo"))
+}
+
+type addCmd struct {
+	Name  string `arg:""`
+	Force bool
+}
+
+type addCmds []addCmd
+
+func (a addCmds) Run(results *[]string) error {
+	for _, add := range a {
+		*results = append(*results, fmt.Sprintf("%s:%v", add.Name, add.Force))
+	}
+	return nil
+}
+
+func TestRepeatedCommand(t *testing.T) {
+	var cli struct {
+		Verbose bool
+		Add     addCmds `cmd:"" aliases:"a" help:"Add an item."`
+		Remove  struct {
+			Name string `arg:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"add", "foo", "--force", "a", "bar", "--verbose", "add", "baz"})
+	require.NoError(t, err)
+	require.Equal(t, addCmds{{Name: "foo", Force: true}, {Name: "bar"}, {Name: "baz"}}, cli.Add)
+	require.True(t, cli.Verbose)
+	results := []string{}
+	require.NoError(t, ctx.Run(&results))
+	require.Equal(t, []string{"foo:true", "bar:false", "baz:false"}, results)
+
+	_, err = p.Parse([]string{"add", "foo"})
+	require.NoError(t, err)
+	require.Equal(t, addCmds{{Name: "foo"}}, cli.Add)
+
+	// Only the repeated command itself may follow an invocation.
+	_, err = p.Parse([]string{"add", "foo", "remove", "bar"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected argument remove")
+}
+
+type tagCmds []struct {
+	Name string `arg:""`
+}
+
+func (tagCmds) Run(ctx *kong.Context) error { return nil }
+
+func TestRepeatedCommandRunnable(t *testing.T) {
+	var cli struct {
+		Add addCmds `cmd:""`
+		Tag tagCmds `cmd:""`
+	}
+	_, err := kong.New(&cli, kong.Name("test"), kong.RequireRunnable())
+	require.EqualError(t, err, `"test add" is not runnable: couldn't find binding of type *[]string for parameter 0 of kong_test.addCmds.Run(), use kong.Bind(*[]string)`)
+	_, err = kong.New(&cli, kong.RequireRunnable(), kong.Bind(&[]string{}))
+	require.NoError(t, err)
+
+	var runners struct {
+		Tag tagCmds `cmd:""`
+	}
+	_, err = kong.New(&runners, kong.Runners())
+	require.NoError(t, err)
+}
+
+func TestProfileDefaults(t *testing.T) {
+	type cli struct {
+		Level   string `enum:"debug,info,warn" default:"${profile==prod ? 'warn' : 'debug'}"`
+		Workers int    `default:"1" profile-default:"prod=8" profile-default:"staging=2"`
+	}
+	for _, test := range []struct {
+		profile string
+		level   string
+		workers int
+	}{
+		{"", "debug", 1},
+		{"staging", "debug", 2},
+		{"prod", "warn", 8},
+	} {
+		t.Run(test.profile, func(t *testing.T) {
+			var actual cli
+			p := mustNew(t, &actual, kong.Profile(test.profile))
+			_, err := p.Parse(nil)
+			require.NoError(t, err)
+			require.Equal(t, test.level, actual.Level)
+			require.Equal(t, test.workers, actual.Workers)
+		})
+	}
+
+	var invalid struct {
+		Level string `enum:"debug,info" default:"info" profile-default:"prod=warn"`
+	}
+	_, err := kong.New(&invalid)
+	require.EqualError(t, err, `default value "warn" for --level in profile "prod" must be one of debug,info`)
+
+	var undecodable struct {
+		Workers int `default:"1" profile-default:"staging=2" profile-default:"prod=eight"`
+	}
+	_, err = kong.New(&undecodable)
+	require.EqualError(t, err, `default value "eight" in profile "prod": --workers: expected a valid 64 bit int but got "eight"`)
+}
@@ -0,0 +1,283 @@
+package kong
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AppState is the small amount of state persisted between runs by the FirstRun() and UpdateCheck()
+// options, and for flags tagged with remember:"".
+type AppState struct {
+	// When the application was first run.
+	FirstRun time.Time `json:"first_run"`
+	// When the latest version was last checked for.
+	LastUpdateCheck time.Time `json:"last_update_check,omitempty"`
+	// Latest version found by the last check.
+	LatestVersion string `json:"latest_version,omitempty"`
+	// Last values of flags tagged with remember:"", keyed by flag name.
+	Remembered map[string]string `json:"remembered,omitempty"`
+}
+
+// A VersionSource returns the latest available version of the application, eg. from a release API.
+type VersionSource func() (string, error)
+
+// FirstRun calls hook, eg. to show a welcome message or a help hint, the first time the application is
+// run by a user.
+//
+// First runs are detected using a state file stored in the user's configuration directory under the
+// application name. See StateFile() to override its location.
+func FirstRun(hook func(ctx *Context) error) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.firstRunHook = hook
+		return nil
+	})
+}
+
+// UpdateCheck prints a notice to stderr when source reports a newer version than current.
+//
+// The source is queried at most once per interval, with the result recorded in the state file shared
+// with FirstRun(). Failures to check are ignored, so that a missing network connection does not break
+// the application, and a source that takes longer than a second is abandoned rather than delaying it.
+func UpdateCheck(current string, source VersionSource, interval time.Duration) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.updateCheck = &updateCheck{current: current, source: source, interval: interval}
+		return nil
+	})
+}
+
+// StateFile overrides the location of the state file used by FirstRun() and UpdateCheck().
+//
+// ~ and variable expansion will occur on the provided path.
+func StateFile(path string) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.stateFile = path
+		return nil
+	})
+}
+
+type updateCheck struct {
+	current  string
+	source   VersionSource
+	interval time.Duration
+}
+
+// How long the UpdateCheck() source may take before it is abandoned.
+var updateCheckTimeout = time.Second
+
+// Query the source for the latest version, giving up after updateCheckTimeout. As a VersionSource can't
+// be cancelled, an abandoned query is left to finish in the background.
+func (u *updateCheck) latest() (string, error) {
+	type result struct {
+		version string
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		version, err := u.source()
+		done <- result{version, err}
+	}()
+	timer := time.NewTimer(updateCheckTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.version, r.err
+
+	case <-timer.C:
+		return "", errors.Errorf("update check timed out after %s", updateCheckTimeout)
+	}
+}
+
+// Path to the state file, defaulting to <config dir>/<app name>/state.json.
+func (k *Kong) statePath() (string, error) {
+	if k.stateFile != "" {
+		return interpolate(ExpandPath(k.stateFile), k.vars, nil)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, k.Model.Name, "state.json"), nil
+}
+
+// Load the state file, returning true if it does not exist yet.
+func (k *Kong) loadState() (state AppState, missing bool, err error) {
+	path, err := k.statePath()
+	if err != nil {
+		return state, false, err
+	}
+	data, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+		return state, true, nil
+	case err != nil:
+		return state, false, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, false, fmt.Errorf("%s: %s", path, err)
+	}
+	return state, false, nil
+}
+
+func (k *Kong) saveState(state AppState) error {
+	path, err := k.statePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Run the FirstRun() and UpdateCheck() hooks, after the command-line has been applied.
+//
+// The state file is a convenience, so failures to read or write it, eg. with a read-only home
+// directory, are ignored rather than failing the command. Only errors returned by the FirstRun() hook
+// are returned.
+func (k *Kong) applyLifecycleHooks(ctx *Context) error {
+	if k.firstRunHook == nil && k.updateCheck == nil {
+		return nil
+	}
+	state, firstRun, err := k.loadState()
+	if err != nil {
+		return nil // nolint: nilerr
+	}
+	if firstRun {
+		state.FirstRun = time.Now()
+	}
+	if firstRun && k.firstRunHook != nil {
+		if err := k.firstRunHook(ctx); err != nil {
+			return err
+		}
+	}
+	changed := firstRun
+	if check := k.updateCheck; check != nil {
+		if time.Since(state.LastUpdateCheck) >= check.interval {
+			state.LastUpdateCheck = time.Now()
+			if latest, err := check.latest(); err == nil {
+				state.LatestVersion = latest
+			}
+			changed = true
+		}
+		if newer, err := versionNewer(state.LatestVersion, check.current); err == nil && newer {
+			fmt.Fprintf(k.Stderr, "A new version of %s is available: %s (current version is %s)\n",
+				k.Model.Name, state.LatestVersion, check.current)
+		}
+	}
+	if changed {
+		_ = k.saveState(state)
+	}
+	return nil
+}
+
+// Returns true if version "a" is newer than "b", by semantic versioning precedence.
+//
+// An error is returned if either is not a semantic version, eg. "dev", as they can't be ordered.
+func versionNewer(a, b string) (bool, error) {
+	av, err := parseSemver(a)
+	if err != nil {
+		return false, err
+	}
+	bv, err := parseSemver(b)
+	if err != nil {
+		return false, err
+	}
+	return av.compare(bv) > 0, nil
+}
+
+// A semantic version, as described at https://semver.org.
+type semver struct {
+	numbers    [3]uint64
+	prerelease []string
+}
+
+// Parse a semantic version with an optional "v" prefix. The minor and patch versions may be omitted,
+// eg. "v1.2".
+func parseSemver(version string) (semver, error) {
+	v := semver{}
+	s := strings.TrimPrefix(version, "v")
+	// Build metadata doesn't affect precedence.
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.prerelease = strings.Split(s[i+1:], ".")
+		s = s[:i]
+		for _, identifier := range v.prerelease {
+			if identifier == "" {
+				return v, errors.Errorf("invalid version %q", version)
+			}
+		}
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > len(v.numbers) {
+		return v, errors.Errorf("invalid version %q", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return v, errors.Errorf("invalid version %q", version)
+		}
+		v.numbers[i] = n
+	}
+	return v, nil
+}
+
+// Compare v to other, returning -1, 0 or 1 if v has lower, equal or higher precedence.
+func (v semver) compare(other semver) int {
+	for i := range v.numbers {
+		if v.numbers[i] != other.numbers[i] {
+			return compareUint(v.numbers[i], other.numbers[i])
+		}
+	}
+	// A pre-release has lower precedence than the release itself.
+	switch {
+	case len(v.prerelease) == 0 && len(other.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(other.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.prerelease) && i < len(other.prerelease); i++ {
+		a, b := v.prerelease[i], other.prerelease[i]
+		an, aerr := strconv.ParseUint(a, 10, 64)
+		bn, berr := strconv.ParseUint(b, 10, 64)
+		switch {
+		case aerr == nil && berr == nil:
+			if an != bn {
+				return compareUint(an, bn)
+			}
+		// Numeric identifiers have lower precedence than alphanumeric ones.
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		case a != b:
+			return strings.Compare(a, b)
+		}
+	}
+	return compareUint(uint64(len(v.prerelease)), uint64(len(other.prerelease)))
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
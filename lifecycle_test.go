@@ -0,0 +1,215 @@
+package kong_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestFirstRunAndUpdateCheck(t *testing.T) {
+	var cli struct {
+		Flag bool
+	}
+	state := filepath.Join(t.TempDir(), "app", "state.json")
+	firstRuns := 0
+	checks := 0
+	latest := "v1.3.0"
+	stderr := &bytes.Buffer{}
+	p := mustNew(t, &cli,
+		kong.Writers(&bytes.Buffer{}, stderr),
+		kong.StateFile(state),
+		kong.FirstRun(func(ctx *kong.Context) error {
+			firstRuns++
+			return nil
+		}),
+		kong.UpdateCheck("v1.2.10", func() (string, error) {
+			checks++
+			return latest, nil
+		}, time.Hour),
+	)
+	for i := 0; i < 3; i++ {
+		_, err := p.Parse(nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, firstRuns)
+	require.Equal(t, 1, checks)
+	notice := "A new version of test is available: v1.3.0 (current version is v1.2.10)\n"
+	require.Equal(t, strings.Repeat(notice, 3), stderr.String())
+
+	// Failed checks are throttled, and do not break the application.
+	stderr.Reset()
+	p = mustNew(t, &cli,
+		kong.Writers(&bytes.Buffer{}, stderr),
+		kong.StateFile(filepath.Join(t.TempDir(), "state.json")),
+		kong.UpdateCheck("v1.2.10", func() (string, error) {
+			checks++
+			return "", errors.New("offline")
+		}, time.Hour),
+	)
+	for i := 0; i < 2; i++ {
+		_, err := p.Parse(nil)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, checks)
+	require.Empty(t, stderr.String())
+}
+
+func TestLifecycleStateFileUnusable(t *testing.T) {
+	var cli struct {
+		Flag bool
+	}
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0600))
+	for name, state := range map[string]string{
+		"unwritable": filepath.Join(file, "state.json"),
+		"unreadable": dir,
+		"no home":    "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			options := []kong.Option{
+				kong.Writers(&bytes.Buffer{}, &bytes.Buffer{}),
+				kong.FirstRun(func(ctx *kong.Context) error { return nil }),
+				kong.UpdateCheck("v1.0.0", func() (string, error) { return "", errors.New("offline") }, time.Hour),
+			}
+			if state != "" {
+				options = append(options, kong.StateFile(state))
+			} else {
+				t.Setenv("HOME", "")
+				t.Setenv("XDG_CONFIG_HOME", "")
+			}
+			_, err := mustNew(t, &cli, options...).Parse([]string{"--flag"})
+			require.NoError(t, err)
+			require.True(t, cli.Flag)
+		})
+	}
+}
+
+func TestRememberFlags(t *testing.T) {
+	type rememberCLI struct {
+		Project string `remember:""`
+		Region  string `default:"us-east-1" remember:""`
+		Verbose bool
+	}
+	state := filepath.Join(t.TempDir(), "state.json")
+	parse := func(args ...string) rememberCLI {
+		t.Helper()
+		var cli rememberCLI
+		_, err := mustNew(t, &cli, kong.StateFile(state)).Parse(args)
+		require.NoError(t, err)
+		return cli
+	}
+	require.Equal(t, rememberCLI{Region: "us-east-1"}, parse())
+	require.Equal(t, rememberCLI{Project: "kong", Region: "us-east-1"}, parse("--project=kong"))
+	require.Equal(t, rememberCLI{Project: "kong", Region: "eu-west-1"}, parse("--region=eu-west-1"))
+	require.Equal(t, rememberCLI{Project: "kong", Region: "eu-west-1", Verbose: true}, parse("--verbose"))
+	require.Equal(t, rememberCLI{Region: "us-east-1"}, parse("--forget"))
+	require.Equal(t, rememberCLI{Region: "us-east-1"}, parse())
+
+	var cli struct {
+		Project string `remember:""`
+		Forget  bool
+	}
+	_, err := kong.New(&cli)
+	require.EqualError(t, err, "flags are remembered but the --forget flag is already in use")
+}
+
+func TestContexts(t *testing.T) {
+	type contextsCLI struct {
+		Region string `default:"us-east-1"`
+		Debug  bool
+
+		Serve struct{} `cmd:"" default:"1"`
+	}
+	state := filepath.Join(t.TempDir(), "state.json")
+	run := func(args ...string) (contextsCLI, string) {
+		t.Helper()
+		var cli contextsCLI
+		stdout := &bytes.Buffer{}
+		ctx, err := mustNew(t, &cli, kong.Writers(stdout, stdout), kong.StateFile(state), kong.Contexts("TEST")).Parse(args)
+		require.NoError(t, err)
+		if strings.HasPrefix(ctx.Command(), "context") {
+			require.NoError(t, ctx.Run())
+		}
+		return cli, stdout.String()
+	}
+	run("context", "set", "prod", "region=eu-west-1", "debug=true")
+	run("context", "set", "dev")
+	_, out := run("context", "list")
+	require.Equal(t, "  dev\n  prod\n", out)
+	cli, _ := run()
+	require.Equal(t, contextsCLI{Region: "us-east-1"}, cli)
+
+	_, out = run("context", "use", "prod")
+	require.Equal(t, "Switched to context \"prod\"\n", out)
+	_, out = run("context", "list")
+	require.Equal(t, "  dev\n* prod\n", out)
+	_, out = run("context", "show")
+	require.Equal(t, "--debug=true\n--region=eu-west-1\n", out)
+	cli, _ = run()
+	require.Equal(t, contextsCLI{Region: "eu-west-1", Debug: true}, cli)
+	cli, _ = run("--region=ap-south-1")
+	require.Equal(t, contextsCLI{Region: "ap-south-1", Debug: true}, cli)
+
+	t.Setenv("TEST_CONTEXT", "dev")
+	cli, _ = run()
+	require.Equal(t, contextsCLI{Region: "us-east-1"}, cli)
+}
+
+func TestContextsRunners(t *testing.T) {
+	var cli struct {
+		Serve runnerCmd `cmd:""`
+	}
+	state := kong.StateFile(filepath.Join(t.TempDir(), "state.json"))
+	_, err := kong.New(&cli, state, kong.Contexts("TEST"), kong.Runners())
+	require.NoError(t, err)
+	_, err = kong.New(&cli, state, kong.Contexts("TEST"), kong.RequireRunnable())
+	require.NoError(t, err)
+}
+
+func TestContextsUnknownActiveContext(t *testing.T) {
+	var cli struct {
+		Region string
+		Debug  bool
+
+		Serve struct{} `cmd:""`
+	}
+	state := kong.StateFile(filepath.Join(t.TempDir(), "state.json"))
+	t.Setenv("TEST_CONTEXT", "missing")
+	_, err := mustNew(t, &cli, state, kong.Contexts("TEST")).Parse([]string{"serve", "--debug"})
+	require.EqualError(t, err, `unknown context "missing", switch to another with "test context use <name>"`)
+
+	stdout := &bytes.Buffer{}
+	_, err = mustNew(t, &cli, state, kong.Contexts("TEST"), kong.NoExit(), kong.Writers(stdout, stdout)).Parse([]string{"serve", "--help"})
+	require.True(t, errors.Is(err, kong.ErrHelpRequested), "%v", err)
+	require.Contains(t, stdout.String(), "Usage: test serve")
+
+	ctx, err := mustNew(t, &cli, state, kong.Contexts("TEST"), kong.Writers(stdout, stdout)).Parse([]string{"context", "set", "dev"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	t.Setenv("TEST_CONTEXT", "")
+	ctx, err = mustNew(t, &cli, state, kong.Contexts("TEST"), kong.Writers(stdout, stdout)).Parse([]string{"context", "use", "dev"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+}
+
+func TestContextsWithoutConfigDir(t *testing.T) {
+	var cli struct {
+		Debug bool
+
+		Serve struct{} `cmd:""`
+	}
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	_, err := mustNew(t, &cli, kong.Contexts("TEST")).Parse([]string{"serve", "--debug"})
+	require.NoError(t, err)
+	require.True(t, cli.Debug)
+}
@@ -0,0 +1,225 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteManPages writes a man page in section 1 for the application and each of its visible commands to dir,
+// named after the command path, eg. "myapp.1" and "myapp-serve.1".
+//
+// Pages are rendered from the help and detail of each command, its positional arguments and flags with
+// their defaults, enum values and environment variables, and its example:"" tags. The application's
+// version is taken from the "version" variable, if any.
+func (k *Kong) WriteManPages(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil { // nolint: gosec
+		return err
+	}
+	return k.writeManPages(dir, k.Model.Node)
+}
+
+func (k *Kong) writeManPages(dir string, node *Node) error {
+	w, err := os.Create(filepath.Join(dir, docPageName(node)+".1"))
+	if err != nil {
+		return err
+	}
+	if err = k.WriteManPage(w, node); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	for _, child := range k.docChildren(node) {
+		if err := k.writeManPages(dir, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteManPage writes the man page of node, the application or one of its commands, in roff.
+func (k *Kong) WriteManPage(w io.Writer, node *Node) error {
+	m := &manWriter{w: &strings.Builder{}}
+	name := docPageName(node)
+	source := k.Model.Name
+	if version := k.vars["version"]; version != "" {
+		source += " " + version
+	}
+	m.printf(".TH %s 1 \"\" %s \"User Commands\"\n", roffQuote(strings.ToUpper(name)), roffQuote(source))
+
+	m.printf(".SH NAME\n")
+	if help := completionHelp(node.Help); help != "" {
+		m.printf("%s \\- %s\n", roffEscape(name), roffEscape(help))
+	} else {
+		m.printf("%s\n", roffEscape(name))
+	}
+
+	m.printf(".SH SYNOPSIS\n")
+	synopsis := []string{`\fB` + roffEscape(docCommandPath(node)) + `\fR`}
+	for _, part := range docSynopsis(node) {
+		if part == "[flags]" {
+			synopsis = append(synopsis, `[\fIflags\fR]`)
+		} else {
+			synopsis = append(synopsis, `\fI`+roffEscape(part)+`\fR`)
+		}
+	}
+	m.printf("%s\n", strings.Join(synopsis, " "))
+
+	if node.Help != "" || node.Detail != "" {
+		m.printf(".SH DESCRIPTION\n")
+		m.text(strings.TrimSpace(node.Help + "\n\n" + node.Detail))
+	}
+
+	if len(node.Positional) > 0 {
+		m.printf(".SH ARGUMENTS\n")
+		for _, positional := range node.Positional {
+			m.printf(".TP\n\\fI%s\\fR\n", roffEscape(positional.Summary()))
+			m.value(positional)
+		}
+	}
+
+	flags, inherited := k.docFlags(node)
+	m.flags("OPTIONS", flags)
+	m.flags(`"OPTIONS INHERITED FROM PARENT COMMANDS"`, inherited)
+
+	children := []*Node{}
+	groups := k.docCommands(node)
+	if len(groups) > 0 {
+		m.printf(".SH COMMANDS\n")
+	}
+	for _, group := range groups {
+		m.subsection(group.Title, group.Description)
+		for _, child := range group.Commands {
+			m.printf(".TP\n\\fB%s\\fR\n", roffEscape(strings.Join(append([]string{child.Name}, child.Aliases...), ", ")))
+			if help := completionHelp(child.Help); help != "" {
+				m.printf("%s\n", roffEscape(help))
+			}
+			m.printf("See \\fB%s\\fR(1).\n", roffEscape(docPageName(child)))
+			children = append(children, child)
+		}
+	}
+
+	if len(node.Tag.Examples) > 0 {
+		m.printf(".SH EXAMPLES\n")
+		for _, example := range node.Tag.Examples {
+			m.printf(".PP\n.RS 4\n.nf\n%s\n.fi\n.RE\n", roffEscape(example))
+		}
+	}
+
+	seeAlso := []string{}
+	if node.Parent != nil {
+		seeAlso = append(seeAlso, `\fB`+roffEscape(docPageName(node.Parent))+`\fR(1)`)
+	}
+	for _, child := range children {
+		seeAlso = append(seeAlso, `\fB`+roffEscape(docPageName(child))+`\fR(1)`)
+	}
+	if len(seeAlso) > 0 {
+		m.printf(".SH \"SEE ALSO\"\n%s\n", strings.Join(seeAlso, ", "))
+	}
+	_, err := io.WriteString(w, m.w.String())
+	return err
+}
+
+type manWriter struct {
+	w *strings.Builder
+}
+
+func (m *manWriter) printf(format string, args ...interface{}) {
+	fmt.Fprintf(m.w, format, args...)
+}
+
+// Write text as paragraphs separated by blank lines.
+func (m *manWriter) text(text string) {
+	for i, paragraph := range strings.Split(text, "\n\n") {
+		if i > 0 {
+			m.printf(".PP\n")
+		}
+		m.printf("%s\n", roffEscape(strings.TrimSpace(paragraph)))
+	}
+}
+
+// Write a section describing groups of flags, if any.
+func (m *manWriter) flags(title string, groups []docFlagGroup) {
+	if len(groups) == 0 {
+		return
+	}
+	m.printf(".SH %s\n", title)
+	for _, group := range groups {
+		m.subsection(group.Title, group.Description)
+		for _, flag := range group.Flags {
+			m.printf(".TP\n%s\n", manFlagSpec(flag))
+			m.value(flag.Value)
+		}
+	}
+}
+
+// Write a subsection for a group of flags or commands, if it has a title.
+func (m *manWriter) subsection(title, description string) {
+	if title == "" {
+		return
+	}
+	m.printf(".SS %s\n", roffQuote(title))
+	if description != "" {
+		m.text(description)
+	}
+}
+
+// Write the help of value, followed by its default, enum values and environment variables.
+func (m *manWriter) value(value *Value) {
+	if value.Help != "" {
+		m.text(value.Help)
+	}
+	details := []string{}
+	if def := docDefault(value); def != "" {
+		details = append(details, `Default: \fB`+roffEscape(def)+`\fR.`)
+	}
+	if enums := docEnum(value); len(enums) > 0 {
+		for i, enum := range enums {
+			enums[i] = `\fB` + roffEscape(enum) + `\fR`
+		}
+		details = append(details, "One of: "+strings.Join(enums, ", ")+".")
+	}
+	if value.Tag.Env != "" {
+		details = append(details, `Environment variable: \fB`+roffEscape(value.Tag.Env)+`\fR.`)
+	}
+	if len(details) > 0 {
+		if value.Help != "" {
+			m.printf(".br\n")
+		}
+		m.printf("%s\n", strings.Join(details, " "))
+	}
+}
+
+// The term of a flag in a man page, eg. \fB\-p\fR, \fB\-\-port\fR=\fIPORT\fR.
+func manFlagSpec(flag *Flag) string {
+	names := []string{}
+	for _, name := range docFlagNames(flag) {
+		names = append(names, `\fB`+roffEscape(name)+`\fR`)
+	}
+	spec := strings.Join(names, ", ")
+	if sep, placeholder := docFlagPlaceHolder(flag); placeholder != "" {
+		spec += sep + `\fI` + roffEscape(placeholder) + `\fR`
+	}
+	return spec
+}
+
+// Escape s for roff, so that backslashes, hyphens and leading control characters are printed as is.
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Quote s as a roff macro argument.
+func roffQuote(s string) string {
+	return `"` + strings.ReplaceAll(roffEscape(s), `"`, `\(dq`) + `"`
+}
@@ -0,0 +1,148 @@
+package kong_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type manCLI struct {
+	Debug bool `short:"d" help:"Enable debug mode."`
+
+	Serve struct {
+		Port    int           `short:"p" default:"8080" env:"APP_PORT" help:"Port to listen on."`
+		Timeout time.Duration `unit:"s" default:"30" help:"Request timeout."`
+		Format  string        `enum:"json,text" default:"json" help:"Log format."`
+		Color   bool          `negatable:"" help:"Colorize output."`
+		Root    string        `arg:"" optional:"" help:"Directory to serve."`
+	} `cmd:"" aliases:"s" help:"Start the server." example:"app serve --port=80 ./public" example:"app serve -p 8081"`
+
+	Internal struct{} `cmd:"" hidden:""`
+}
+
+func TestWriteManPage(t *testing.T) {
+	var cli manCLI
+	p := mustNew(t, &cli, kong.Name("app"), kong.Vars{"version": "1.2.3"})
+	w := &bytes.Buffer{}
+	require.NoError(t, p.WriteManPage(w, p.Model.Children[0]))
+	require.Equal(t, `.TH "APP\-SERVE" 1 "" "app 1.2.3" "User Commands"
+.SH NAME
+app\-serve \- Start the server.
+.SH SYNOPSIS
+\fBapp serve\fR [\fIflags\fR] \fI[<root>]\fR
+.SH DESCRIPTION
+Start the server.
+.SH ARGUMENTS
+.TP
+\fI[<root>]\fR
+Directory to serve.
+.SH OPTIONS
+.TP
+\fB\-p\fR, \fB\-\-port\fR=\fIINT\fR
+Port to listen on.
+.br
+Default: \fB8080\fR. Environment variable: \fBAPP_PORT\fR.
+.TP
+\fB\-\-timeout\fR=\fISECONDS\fR
+Request timeout.
+.br
+Default: \fB30s\fR.
+.TP
+\fB\-\-format\fR=\fISTRING\fR
+Log format.
+.br
+Default: \fBjson\fR. One of: \fBjson\fR, \fBtext\fR.
+.TP
+\fB\-\-[no\-]color\fR
+Colorize output.
+.SH "OPTIONS INHERITED FROM PARENT COMMANDS"
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Show context\-sensitive help.
+.TP
+\fB\-d\fR, \fB\-\-debug\fR
+Enable debug mode.
+.SH EXAMPLES
+.PP
+.RS 4
+.nf
+app serve \-\-port=80 ./public
+.fi
+.RE
+.PP
+.RS 4
+.nf
+app serve \-p 8081
+.fi
+.RE
+.SH "SEE ALSO"
+\fBapp\fR(1)
+`, w.String())
+}
+
+func TestWriteManPages(t *testing.T) {
+	var cli manCLI
+	p := mustNew(t, &cli, kong.Name("app"))
+	dir := filepath.Join(t.TempDir(), "man1")
+	require.NoError(t, p.WriteManPages(dir))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	names := []string{}
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	require.Equal(t, []string{"app-serve.1", "app.1"}, names)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.1"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), ".TH \"APP\" 1 \"\" \"app\" \"User Commands\"\n")
+	require.Contains(t, string(data), ".SH COMMANDS\n.TP\n\\fBserve, s\\fR\nStart the server.\nSee \\fBapp\\-serve\\fR(1).\n")
+}
+
+func TestWriteManPageGroupsAndSorting(t *testing.T) {
+	var cli struct {
+		Format string `help:"Output format." group:"output"`
+
+		Destroy struct{} `cmd:"" help:"Destroy everything."`
+		Create  struct{} `cmd:"" help:"Create something."`
+		Migrate struct{} `cmd:"" help:"Migrate the database." category:"Maintenance"`
+	}
+	p := mustNew(t, &cli, kong.Name("app"),
+		kong.ExplicitGroups([]kong.Group{{Key: "output", Title: "Output:", Description: "How results are printed."}}),
+		kong.ConfigureHelp(kong.HelpOptions{
+			SortCommands: func(a, b *kong.Node) bool { return a.Name < b.Name },
+		}))
+	w := &bytes.Buffer{}
+	require.NoError(t, p.WriteManPage(w, p.Model.Node))
+	require.Contains(t, w.String(), `.SH OPTIONS
+.TP
+\fB\-h\fR, \fB\-\-help\fR
+Show context\-sensitive help.
+.SS "Output"
+How results are printed.
+.TP
+\fB\-\-format\fR=\fISTRING\fR
+Output format.
+.SH COMMANDS
+.TP
+\fBcreate\fR
+Create something.
+See \fBapp\-create\fR(1).
+.TP
+\fBdestroy\fR
+Destroy everything.
+See \fBapp\-destroy\fR(1).
+.SS "Maintenance"
+.TP
+\fBmigrate\fR
+Migrate the database.
+See \fBapp\-migrate\fR(1).
+.SH "SEE ALSO"
+\fBapp\-create\fR(1), \fBapp\-destroy\fR(1), \fBapp\-migrate\fR(1)
+`)
+}
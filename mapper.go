@@ -1,7 +1,9 @@
 package kong
 
 import (
+	"bytes"
 	"encoding"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -136,19 +138,21 @@ func (m MapperFunc) Decode(ctx *DecodeContext, target reflect.Value) error { //
 
 // A Registry contains a set of mappers and supporting lookup methods.
 type Registry struct {
-	names  map[string]Mapper
-	types  map[reflect.Type]Mapper
-	kinds  map[reflect.Kind]Mapper
-	values map[reflect.Value]Mapper
+	names      map[string]Mapper
+	types      map[reflect.Type]Mapper
+	kinds      map[reflect.Kind]Mapper
+	values     map[reflect.Value]Mapper
+	transforms map[string]TransformFunc
 }
 
 // NewRegistry creates a new (empty) Registry.
 func NewRegistry() *Registry {
 	return &Registry{
-		names:  map[string]Mapper{},
-		types:  map[reflect.Type]Mapper{},
-		kinds:  map[reflect.Kind]Mapper{},
-		values: map[reflect.Value]Mapper{},
+		names:      map[string]Mapper{},
+		types:      map[reflect.Type]Mapper{},
+		kinds:      map[reflect.Kind]Mapper{},
+		values:     map[reflect.Value]Mapper{},
+		transforms: map[string]TransformFunc{},
 	}
 }
 
@@ -207,13 +211,42 @@ func (r *Registry) ForType(typ reflect.Type) Mapper {
 			return &jsonUnmarshalerAdapter{}
 		}
 	}
-	// Finally try registered kinds.
+	// Integer EnumTypes are decoded from the names of their constants.
+	if enumValues(typ) != nil && typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64 {
+		return enumTypeMapper{}
+	}
+	// Then try registered kinds.
 	if mapper, ok = r.kinds[typ.Kind()]; ok {
 		return mapper
 	}
+	// Pointers to any other supported type are decoded into a newly allocated value.
+	if typ.Kind() == reflect.Ptr {
+		if elem := r.ForType(typ.Elem()); elem != nil {
+			return &ptrMapper{elem}
+		}
+	}
 	return nil
 }
 
+// ptrMapper decodes into a newly allocated value of the pointed-to type, so a pointer field is nil until set.
+type ptrMapper struct {
+	elem Mapper
+}
+
+func (p *ptrMapper) Decode(ctx *DecodeContext, target reflect.Value) error {
+	value := reflect.New(target.Type().Elem())
+	if err := p.elem.Decode(ctx, value.Elem()); err != nil {
+		return err
+	}
+	target.Set(value)
+	return nil
+}
+
+func (p *ptrMapper) IsBool() bool {
+	m, ok := p.elem.(BoolMapper)
+	return ok && m.IsBool()
+}
+
 // RegisterKind registers a Mapper for a reflect.Kind.
 func (r *Registry) RegisterKind(kind reflect.Kind, mapper Mapper) *Registry {
 	r.kinds[kind] = mapper
@@ -250,7 +283,8 @@ func (r *Registry) RegisterValue(ptr interface{}, mapper Mapper) *Registry {
 
 // RegisterDefaults registers Mappers for all builtin supported Go types and some common stdlib types.
 func (r *Registry) RegisterDefaults() *Registry {
-	return r.RegisterKind(reflect.Int, intDecoder(bits.UintSize)).
+	return r.RegisterDefaultTransforms().
+		RegisterKind(reflect.Int, intDecoder(bits.UintSize)).
 		RegisterKind(reflect.Int8, intDecoder(8)).
 		RegisterKind(reflect.Int16, intDecoder(16)).
 		RegisterKind(reflect.Int32, intDecoder(32)).
@@ -266,7 +300,7 @@ func (r *Registry) RegisterDefaults() *Registry {
 			err := ctx.Scan.PopValueInto("string", target.Addr().Interface())
 			return err
 		})).
-		RegisterKind(reflect.Bool, boolMapper{}).
+		RegisterKind(reflect.Bool, defaultBoolMapper).
 		RegisterKind(reflect.Slice, sliceDecoder(r)).
 		RegisterKind(reflect.Map, mapDecoder(r)).
 		RegisterType(reflect.TypeOf(time.Time{}), timeDecoder()).
@@ -276,26 +310,38 @@ func (r *Registry) RegisterDefaults() *Registry {
 		RegisterName("path", pathMapper(r)).
 		RegisterName("existingfile", existingFileMapper(r)).
 		RegisterName("existingdir", existingDirMapper(r)).
+		RegisterName("linesfile", linesFileMapper(r)).
+		RegisterName("csvfile", csvFileMapper(r)).
 		RegisterName("counter", counterMapper())
 }
 
-type boolMapper struct{}
+type boolMapper struct {
+	truthy []string
+	falsy  []string
+}
 
-func (boolMapper) Decode(ctx *DecodeContext, target reflect.Value) error {
+var defaultBoolMapper = boolMapper{
+	truthy: []string{"true", "1", "yes"},
+	falsy:  []string{"false", "0", "no"},
+}
+
+func (b boolMapper) Decode(ctx *DecodeContext, target reflect.Value) error {
 	if ctx.Scan.Peek().Type == FlagValueToken {
 		token := ctx.Scan.Pop()
 		switch v := token.Value.(type) {
 		case string:
 			v = strings.ToLower(v)
-			switch v {
-			case "true", "1", "yes":
+			switch {
+			case containsString(b.truthy, v):
 				target.SetBool(true)
 
-			case "false", "0", "no":
+			case containsString(b.falsy, v):
 				target.SetBool(false)
 
 			default:
-				return errors.Errorf("bool value must be true, 1, yes, false, 0 or no but got %q", v)
+				all := append(append([]string{}, b.truthy...), b.falsy...)
+				return findPotentialCandidates(v, all, "bool value must be %s or %s but got %q",
+					strings.Join(all[:len(all)-1], ", "), all[len(all)-1], v)
 			}
 
 		case bool:
@@ -435,6 +481,46 @@ func floatDecoder(bits int) MapperFunc {
 	}
 }
 
+// Decode consecutive groups of values into the fields of each struct element of a slice, for the "tuple" tag.
+func decodeTuples(r *Registry, ctx *DecodeContext, scan *Scanner, target reflect.Value) error {
+	size := ctx.Value.Tag.Tuple
+	el := target.Type().Elem()
+	if err := checkTupleType(target.Type(), size); err != nil {
+		return err
+	}
+	tokens := scan.PopWhile(func(t Token) bool { return !t.IsEOL() })
+	if len(tokens)%size != 0 {
+		return errors.Errorf("expected values in groups of %d but got %d", size, len(tokens))
+	}
+	for i := 0; i < len(tokens); i += size {
+		tuple := reflect.New(el).Elem()
+		for j := 0; j < size; j++ {
+			field := tuple.Field(j)
+			decoder := r.ForType(field.Type())
+			if decoder == nil {
+				return errors.Errorf("no mapper for %s field %s", el, el.Field(j).Name)
+			}
+			if err := decoder.Decode(ctx.WithScanner(ScanFromTokens(tokens[i+j])), field); err != nil {
+				return err
+			}
+		}
+		target.Set(reflect.Append(target, tuple))
+	}
+	return nil
+}
+
+func checkTupleType(typ reflect.Type, size int) error {
+	if typ.Kind() != reflect.Slice || typ.Elem().Kind() != reflect.Struct || typ.Elem().NumField() != size {
+		return errors.Errorf("tuple:\"%d\" requires a slice of structs with %d fields, not %s", size, size, typ)
+	}
+	for i := 0; i < size; i++ {
+		if field := typ.Elem().Field(i); field.PkgPath != "" {
+			return errors.Errorf("tuple field %s.%s must be exported", typ.Elem(), field.Name)
+		}
+	}
+	return nil
+}
+
 func mapDecoder(r *Registry) MapperFunc {
 	return func(ctx *DecodeContext, target reflect.Value) error {
 		if target.IsNil() {
@@ -539,13 +625,19 @@ func sliceDecoder(r *Registry) MapperFunc {
 			tokens := ctx.Scan.PopWhile(func(t Token) bool { return t.IsValue() })
 			childScanner = ScanFromTokens(tokens...)
 		}
+		if ctx.Value.Tag.Tuple > 0 {
+			return decodeTuples(r, ctx, childScanner, target)
+		}
 		childDecoder := r.ForNamedType(ctx.Value.Tag.Type, el)
 		if childDecoder == nil {
 			return errors.Errorf("no mapper for element type of %s", target.Type())
 		}
+		childCtx := ctx.WithScanner(childScanner)
+		childValue := reflect.New(el).Elem()
+		zero := reflect.Zero(el)
 		for !childScanner.Peek().IsEOL() {
-			childValue := reflect.New(el).Elem()
-			err := childDecoder.Decode(ctx.WithScanner(childScanner), childValue)
+			childValue.Set(zero)
+			err := childDecoder.Decode(childCtx, childValue)
 			if err != nil {
 				return errors.WithStack(err)
 			}
@@ -655,6 +747,110 @@ func existingDirMapper(r *Registry) MapperFunc {
 	}
 }
 
+// Decodes each non-blank line of a file, or stdin if the path is "-", into an element of a slice. Lines
+// starting with "#" are comments.
+func linesFileMapper(r *Registry) MapperFunc {
+	return func(ctx *DecodeContext, target reflect.Value) error {
+		if target.Kind() != reflect.Slice {
+			return errors.Errorf("\"linesfile\" type must be applied to a slice not %s", target.Type())
+		}
+		path, data, err := readFileValue(ctx)
+		if err != nil {
+			return err
+		}
+		decoder := r.ForType(target.Type().Elem())
+		if decoder == nil {
+			return errors.Errorf("no mapper for element type of %s", target.Type())
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			if err := appendElement(ctx, decoder, target, line); err != nil {
+				return errors.Wrapf(err, "%s:%d", path, i+1)
+			}
+		}
+		return nil
+	}
+}
+
+// Decodes the fields of a CSV file, or stdin if the path is "-", into the elements of a slice. The fields
+// of each record are decoded into an element of their own if the elements are slices, eg. [][]string, or
+// are otherwise flattened. Blank lines and lines starting with "#" are skipped.
+func csvFileMapper(r *Registry) MapperFunc {
+	return func(ctx *DecodeContext, target reflect.Value) error {
+		if target.Kind() != reflect.Slice {
+			return errors.Errorf("\"csvfile\" type must be applied to a slice not %s", target.Type())
+		}
+		path, data, err := readFileValue(ctx)
+		if err != nil {
+			return err
+		}
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.Comment = '#'
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		records, err := reader.ReadAll()
+		if err != nil {
+			return errors.Wrap(err, path)
+		}
+		el := target.Type().Elem()
+		for i, record := range records {
+			if el.Kind() == reflect.Slice && el.Elem().Kind() != reflect.Uint8 {
+				fields := reflect.New(el).Elem()
+				if err := decodeFields(r, ctx, fields, record); err != nil {
+					return errors.Wrapf(err, "%s: record %d", path, i+1)
+				}
+				target.Set(reflect.Append(target, fields))
+				continue
+			}
+			if err := decodeFields(r, ctx, target, record); err != nil {
+				return errors.Wrapf(err, "%s: record %d", path, i+1)
+			}
+		}
+		return nil
+	}
+}
+
+// Read the file whose path is the next value, or stdin if it's "-".
+func readFileValue(ctx *DecodeContext) (string, []byte, error) {
+	var path string
+	if err := ctx.Scan.PopValueInto("file", &path); err != nil {
+		return "", nil, err
+	}
+	if path == "-" {
+		data, err := ioutil.ReadAll(os.Stdin)
+		return path, data, err
+	}
+	path = ExpandPath(path)
+	data, err := ioutil.ReadFile(path) // nolint: gosec
+	return path, data, err
+}
+
+func decodeFields(r *Registry, ctx *DecodeContext, target reflect.Value, fields []string) error {
+	decoder := r.ForType(target.Type().Elem())
+	if decoder == nil {
+		return errors.Errorf("no mapper for element type of %s", target.Type())
+	}
+	for _, field := range fields {
+		if err := appendElement(ctx, decoder, target, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decode value with decoder and append it to the slice target.
+func appendElement(ctx *DecodeContext, decoder Mapper, target reflect.Value, value string) error {
+	el := reflect.New(target.Type().Elem()).Elem()
+	if err := decoder.Decode(ctx.WithScanner(Scan(value)), el); err != nil {
+		return err
+	}
+	target.Set(reflect.Append(target, el))
+	return nil
+}
+
 func counterMapper() MapperFunc {
 	return func(ctx *DecodeContext, target reflect.Value) error {
 		if ctx.Scan.Peek().Type == FlagValueToken {
@@ -812,3 +1008,12 @@ func jsonTranscode(in, out interface{}) error {
 	}
 	return errors.Wrapf(json.Unmarshal(data, out), "%#v -> %T", in, out)
 }
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -8,7 +8,9 @@ import (
 	"math"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -246,6 +248,76 @@ func TestPassthroughStopsParsing(t *testing.T) {
 	}, actual)
 }
 
+func TestPassthroughStartsAtUnknownFlag(t *testing.T) {
+	type cli struct {
+		Verbose bool     `short:"v"`
+		Args    []string `arg:"" optional:"" passthrough:""`
+	}
+	for _, test := range []struct {
+		args     []string
+		expected cli
+	}{
+		{[]string{"-v", "--run=TestFoo", "-v", "./..."}, cli{Verbose: true, Args: []string{"--run=TestFoo", "-v", "./..."}}},
+		{[]string{"--verbose", "-count", "1"}, cli{Verbose: true, Args: []string{"-count", "1"}}},
+		{[]string{"--timeout", "10s"}, cli{Args: []string{"--timeout", "10s"}}},
+	} {
+		var actual cli
+		_, err := mustNew(t, &actual).Parse(test.args)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, actual)
+	}
+
+	var strict struct {
+		Verbose bool
+		Args    []string `arg:"" optional:""`
+	}
+	_, err := mustNew(t, &strict).Parse([]string{"--timeout", "10s"})
+	require.EqualError(t, err, "unknown flag --timeout")
+}
+
+func TestPassthroughReportsInvalidKnownFlag(t *testing.T) {
+	var cli struct {
+		Count int
+		Args  []string `arg:"" optional:"" passthrough:""`
+	}
+	_, err := mustNew(t, &cli).Parse([]string{"--count=abc", "foo"})
+	require.EqualError(t, err, `--count: expected a valid 64 bit int but got "abc"`)
+}
+
+func TestPassthroughCommand(t *testing.T) {
+	type cli struct {
+		Verbose bool `short:"v"`
+		Exec    struct {
+			Interactive bool     `short:"i"`
+			Pod         string   `arg:""`
+			Command     []string `arg:"" optional:""`
+		} `cmd:"" passthrough:""`
+		Run struct {
+			Interactive bool     `short:"i"`
+			Args        []string `arg:"" optional:""`
+		} `cmd:""`
+	}
+	var actual cli
+	_, err := mustNew(t, &actual).Parse([]string{"exec", "-i", "web", "ls", "-l", "-i", "--all"})
+	require.NoError(t, err)
+	require.True(t, actual.Exec.Interactive)
+	require.Equal(t, "web", actual.Exec.Pod)
+	require.Equal(t, []string{"ls", "-l", "-i", "--all"}, actual.Exec.Command)
+
+	// Siblings keep normal parsing.
+	actual = cli{}
+	_, err = mustNew(t, &actual).Parse([]string{"run", "a", "b", "-i"})
+	require.NoError(t, err)
+	require.True(t, actual.Run.Interactive)
+	require.Equal(t, []string{"a", "b"}, actual.Run.Args)
+
+	var invalid struct {
+		Flag string `passthrough:""`
+	}
+	_, err = kong.New(&invalid)
+	require.Error(t, err)
+}
+
 type mappedValue struct {
 	decoded string
 }
@@ -412,7 +484,7 @@ func TestFileMapper(t *testing.T) {
 	_ = cli.File.Close()
 	_, err = p.Parse([]string{"testdata/missing.txt"})
 	require.Error(t, err)
-	require.Contains(t, err.Error(), "missing.txt: no such file or directory")
+	require.Contains(t, strings.ToLower(err.Error()), "missing.txt: no such file or directory")
 	_, err = p.Parse([]string{"-"})
 	require.NoError(t, err)
 	require.Equal(t, os.Stdin, cli.File)
@@ -495,3 +567,247 @@ func (t testMapperVarsContributor) Decode(ctx *kong.DecodeContext, target reflec
 	target.SetString("hi")
 	return nil
 }
+
+func TestTuplePositionals(t *testing.T) {
+	type hostPort struct {
+		Host string
+		Port int
+	}
+	type cli struct {
+		Peers []hostPort `arg:"" tuple:"2"`
+	}
+	var c cli
+	_, err := mustNew(t, &c).Parse([]string{"a", "80", "b", "443"})
+	require.NoError(t, err)
+	require.Equal(t, []hostPort{{"a", 80}, {"b", 443}}, c.Peers)
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"a", "80", "b"})
+	require.EqualError(t, err, "<peers> ...: expected values in groups of 2 but got 3")
+
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"a", "port"})
+	require.Error(t, err)
+
+	var invalid struct {
+		Peers []hostPort `arg:"" tuple:"3"`
+	}
+	_, err = kong.New(&invalid)
+	require.Error(t, err)
+}
+
+func TestPointerFields(t *testing.T) {
+	type cli struct {
+		Port    *int      `help:"Port."`
+		Verbose *bool     `negatable:""`
+		Level   *string   `enum:"debug,info" default:"info"`
+		Tags    []*string `sep:","`
+		Name    *string   `env:"POINTER_NAME"`
+	}
+	var c cli
+	p := mustNew(t, &c)
+	_, err := p.Parse([]string{})
+	require.NoError(t, err)
+	require.Nil(t, c.Port)
+	require.Nil(t, c.Verbose)
+	require.Nil(t, c.Name)
+	require.Equal(t, "info", *c.Level)
+
+	c = cli{}
+	p = mustNew(t, &c)
+	_, err = p.Parse([]string{"--port=0", "--no-verbose", "--tags=a,b", "--level=debug"})
+	require.NoError(t, err)
+	require.Equal(t, 0, *c.Port)
+	require.False(t, *c.Verbose)
+	require.Equal(t, "debug", *c.Level)
+	require.Len(t, c.Tags, 2)
+	require.Equal(t, "a", *c.Tags[0])
+	require.Equal(t, "b", *c.Tags[1])
+
+	c = cli{}
+	p = mustNew(t, &c)
+	_, err = p.Parse([]string{"--level=trace"})
+	require.Error(t, err)
+
+	c = cli{}
+	p = mustNew(t, &c, kong.Resolvers(kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name == "port" {
+			return 8080, nil
+		}
+		return nil, nil
+	})))
+	os.Setenv("POINTER_NAME", "env")
+	defer os.Unsetenv("POINTER_NAME")
+	_, err = p.Parse([]string{"--verbose"})
+	require.NoError(t, err)
+	require.Equal(t, 8080, *c.Port)
+	require.True(t, *c.Verbose)
+	require.Equal(t, "env", *c.Name)
+
+	w := &strings.Builder{}
+	c = cli{}
+	p = mustNew(t, &c, kong.Writers(w, w), kong.Exit(func(int) {}))
+	_, _ = p.Parse([]string{"--help"})
+	require.Contains(t, w.String(), "--port=INT")
+	require.Contains(t, w.String(), "--[no-]verbose")
+}
+
+func TestBoolStrings(t *testing.T) {
+	var cli struct {
+		Debug bool  `env:"BOOL_STRINGS_DEBUG"`
+		Color *bool `default:"off"`
+	}
+	p := mustNew(t, &cli, kong.BoolStrings([]string{"on", "yes"}, []string{"off", "no"}))
+	os.Setenv("BOOL_STRINGS_DEBUG", "ON")
+	defer os.Unsetenv("BOOL_STRINGS_DEBUG")
+	_, err := p.Parse([]string{})
+	require.NoError(t, err)
+	require.True(t, cli.Debug)
+	require.False(t, *cli.Color)
+
+	_, err = p.Parse([]string{"--color=true"})
+	require.EqualError(t, err, `--color: bool value must be on, yes, off or no but got "true"`)
+
+	_, err = p.Parse([]string{"--color=of"})
+	require.EqualError(t, err, `--color: bool value must be on, yes, off or no but got "of", did you mean one of "on", "off", "no"?`)
+
+	var defaults struct {
+		Debug bool
+	}
+	_, err = mustNew(t, &defaults).Parse([]string{"--debug=maybe"})
+	require.EqualError(t, err, `--debug: bool value must be true, 1, yes, false, 0 or no but got "maybe"`)
+}
+
+func TestTransforms(t *testing.T) {
+	var cli struct {
+		Region string   `transform:"trimspace,lower" env:"TRANSFORM_REGION"`
+		Home   string   `transform:"expandenv" default:"$TRANSFORM_HOME/app"`
+		Tags   []string `transform:"upper"`
+		Name   string   `arg:"" transform:"reverse"`
+	}
+	reverse := func(s string) string {
+		r := []rune(s)
+		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+			r[i], r[j] = r[j], r[i]
+		}
+		return string(r)
+	}
+	os.Setenv("TRANSFORM_REGION", "  US-East ")
+	os.Setenv("TRANSFORM_HOME", "/home/kong")
+	defer os.Unsetenv("TRANSFORM_REGION")
+	defer os.Unsetenv("TRANSFORM_HOME")
+	p := mustNew(t, &cli, kong.NamedTransform("reverse", reverse))
+	_, err := p.Parse([]string{"--tags=a,b", "--tags", "c", "olleh"})
+	require.NoError(t, err)
+	require.Equal(t, "us-east", cli.Region)
+	require.Equal(t, "/home/kong/app", cli.Home)
+	require.Equal(t, []string{"A", "B", "C"}, cli.Tags)
+	require.Equal(t, "hello", cli.Name)
+
+	var invalid struct {
+		Name string `transform:"unknown"`
+	}
+	_, err = kong.New(&invalid)
+	require.Error(t, err)
+}
+
+func TestIndirection(t *testing.T) {
+	type cli struct {
+		Token  string `transform:"trimspace"`
+		Debug  bool
+		Port   int      `env:"INDIRECT_PORT"`
+		Hosts  []string `default:"env://INDIRECT_HOSTS"`
+		Target string   `arg:"" optional:""`
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte(" s3cret \n"), 0600))
+	os.Setenv("INDIRECT_PORT", "env://INDIRECT_PORT_VALUE")
+	os.Setenv("INDIRECT_PORT_VALUE", "8080")
+	os.Setenv("INDIRECT_HOSTS", "a,b")
+	defer os.Unsetenv("INDIRECT_PORT")
+	defer os.Unsetenv("INDIRECT_PORT_VALUE")
+	defer os.Unsetenv("INDIRECT_HOSTS")
+	options := []kong.Option{
+		kong.Indirection("file", kong.FileIndirection),
+		kong.Indirection("env", kong.EnvIndirection),
+	}
+
+	var c cli
+	_, err := mustNew(t, &c, options...).Parse([]string{"--token", "file://" + path, "--debug", "file://" + path})
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", c.Token)
+	require.True(t, c.Debug)
+	require.Equal(t, 8080, c.Port)
+	require.Equal(t, []string{"a", "b"}, c.Hosts)
+	require.Equal(t, " s3cret ", c.Target)
+
+	c = cli{}
+	_, err = mustNew(t, &c, options...).Parse([]string{"--token=file://" + filepath.Join(dir, "missing")})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "--token: failed to load file://")
+
+	// Schemes are only resolved when registered.
+	os.Unsetenv("INDIRECT_PORT")
+	c = cli{}
+	_, err = mustNew(t, &c).Parse([]string{"--token=file://" + path})
+	require.NoError(t, err)
+	require.Equal(t, "file://"+path, c.Token)
+
+	if runtime.GOOS == "js" || runtime.GOOS == "wasip1" {
+		_, err = kong.ExecIndirection("echo 'hello world'")
+		require.EqualError(t, err, "echo 'hello world': executing commands is not supported on "+runtime.GOOS)
+		return
+	}
+	output, err := kong.ExecIndirection("echo 'hello world'")
+	require.NoError(t, err)
+	require.Equal(t, "hello world", output)
+}
+
+func TestLinesFileMapper(t *testing.T) {
+	dir := t.TempDir()
+	hosts := filepath.Join(dir, "hosts")
+	require.NoError(t, ioutil.WriteFile(hosts, []byte("# Web servers.\nweb1\n\n  web2  \n#web3\n"), 0600))
+	ports := filepath.Join(dir, "ports")
+	require.NoError(t, ioutil.WriteFile(ports, []byte("80\n443\nhttp\n"), 0600))
+	var cli struct {
+		Hosts []string `type:"linesfile"`
+		Ports []int    `type:"linesfile"`
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"--hosts", hosts})
+	require.NoError(t, err)
+	require.Equal(t, []string{"web1", "web2"}, cli.Hosts)
+
+	_, err = p.Parse([]string{"--ports", ports})
+	require.EqualError(t, err, `--ports: `+ports+`:3: expected a valid 64 bit int but got "http"`)
+
+	_, err = p.Parse([]string{"--hosts", filepath.Join(dir, "missing")})
+	require.Error(t, err)
+
+	var invalid struct {
+		Hosts string `type:"linesfile"`
+	}
+	_, err = mustNew(t, &invalid).Parse([]string{"--hosts", hosts})
+	require.EqualError(t, err, `--hosts: "linesfile" type must be applied to a slice not string`)
+}
+
+func TestCSVFileMapper(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts.csv")
+	require.NoError(t, ioutil.WriteFile(path, []byte("# Name, port.\nweb1, 80\n\n\"web,2\", 443\n"), 0600))
+	var cli struct {
+		Fields  []string   `type:"csvfile"`
+		Records [][]string `type:"csvfile"`
+	}
+	_, err := mustNew(t, &cli).Parse([]string{"--fields", path, "--records", path})
+	require.NoError(t, err)
+	require.Equal(t, []string{"web1", "80", "web,2", "443"}, cli.Fields)
+	require.Equal(t, [][]string{{"web1", "80"}, {"web,2", "443"}}, cli.Records)
+
+	var ints struct {
+		Ports []int `type:"csvfile"`
+	}
+	_, err = mustNew(t, &ints).Parse([]string{"--ports", path})
+	require.EqualError(t, err, `--ports: `+path+`: record 1: expected a valid 64 bit int but got "web1"`)
+}
@@ -0,0 +1,120 @@
+package kong
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMarkdown writes a Markdown reference for the application and all of its visible commands as a
+// single document, with a section per command.
+//
+// Each section has the usage of the command, tables of its positional arguments and flags with their
+// defaults and environment variables, its subcommands and its example:"" tags.
+func (k *Kong) WriteMarkdown(w io.Writer) error {
+	return k.WriteDocs(w, MarkdownRenderer{})
+}
+
+// WriteMarkdownPages writes a Markdown page for the application and each of its visible commands to dir,
+// named after the command path, eg. "myapp.md" and "myapp-serve.md", and linked to each other.
+func (k *Kong) WriteMarkdownPages(dir string) error {
+	return k.WriteDocPages(dir, MarkdownRenderer{})
+}
+
+// MarkdownRenderer renders reference documentation as GitHub flavoured Markdown.
+type MarkdownRenderer struct{}
+
+// Extension implements DocRenderer.
+func (MarkdownRenderer) Extension() string { return ".md" }
+
+// Render implements DocRenderer.
+func (MarkdownRenderer) Render(w io.Writer, doc *CommandDoc) error {
+	b := &strings.Builder{}
+	printf := func(format string, args ...interface{}) { fmt.Fprintf(b, format, args...) }
+	heading := strings.Repeat("#", doc.Level)
+	subheading := heading + "#"
+	printf("%s %s\n", heading, doc.Path)
+	if doc.Help != "" {
+		printf("\n%s\n", doc.Help)
+	}
+
+	printf("\n%s Usage\n\n```\n%s\n```\n", subheading, doc.Usage)
+
+	if len(doc.Arguments) > 0 {
+		printf("\n%s Arguments\n\n", subheading)
+		printf("| Argument | Description | Default |\n")
+		printf("|----------|-------------|---------|\n")
+		for _, arg := range doc.Arguments {
+			printf("| %s | %s | %s |\n", markdownCode(arg.Name), markdownDescription(arg), markdownCode(arg.Default))
+		}
+	}
+
+	for _, group := range doc.Flags {
+		markdownFlags(b, subheading+" "+docGroupHeading(group.Title, "Flags"), group)
+	}
+	for _, group := range doc.InheritedFlags {
+		markdownFlags(b, subheading+" "+docInheritedHeading(group.Title), group)
+	}
+
+	for _, group := range doc.Commands {
+		printf("\n%s %s\n\n", subheading, docGroupHeading(group.Title, "Commands"))
+		if group.Description != "" {
+			printf("%s\n\n", group.Description)
+		}
+		printf("| Command | Description |\n")
+		printf("|---------|-------------|\n")
+		for _, command := range group.Commands {
+			link := command.Page
+			if link == "" {
+				// GitHub's anchor for the heading of the command.
+				link = "#" + command.ID
+			}
+			printf("| [%s](%s) | %s |\n", markdownCode(command.Path), link, markdownCell(command.Help))
+		}
+	}
+
+	if len(doc.Examples) > 0 {
+		printf("\n%s Examples\n\n```\n%s\n```\n", subheading, strings.Join(doc.Examples, "\n"))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Write a table of a group of flags under heading.
+func markdownFlags(b *strings.Builder, heading string, group *FlagGroupDoc) {
+	fmt.Fprintf(b, "\n%s\n\n", heading)
+	if group.Description != "" {
+		fmt.Fprintf(b, "%s\n\n", group.Description)
+	}
+	fmt.Fprintf(b, "| Flag | Description | Default | Environment |\n")
+	fmt.Fprintf(b, "|------|-------------|---------|-------------|\n")
+	for _, flag := range group.Flags {
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", markdownCode(flag.Name), markdownDescription(flag), markdownCode(flag.Default), markdownCode(flag.Env))
+	}
+}
+
+// The help of a value for a table cell, followed by its enum values if any.
+func markdownDescription(value *ValueDoc) string {
+	description := markdownCell(value.Help)
+	if len(value.Enum) > 0 {
+		enums := make([]string, len(value.Enum))
+		for i, enum := range value.Enum {
+			enums[i] = markdownCode(enum)
+		}
+		description = strings.TrimSpace(description + " One of: " + strings.Join(enums, ", ") + ".")
+	}
+	return description
+}
+
+// s as inline code, or nothing if s is empty.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
+
+// Escape s for a table cell, which must be on a single line.
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
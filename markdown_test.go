@@ -0,0 +1,168 @@
+package kong_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	var cli manCLI
+	p := mustNew(t, &cli, kong.Name("app"))
+	w := &bytes.Buffer{}
+	require.NoError(t, p.WriteMarkdown(w))
+	// Backticks are written as ' so the expected document can be a raw string.
+	expected := strings.ReplaceAll(`# app
+
+## Usage
+
+'''
+app [flags] <command>
+'''
+
+## Flags
+
+| Flag | Description | Default | Environment |
+|------|-------------|---------|-------------|
+| '-h, --help' | Show context-sensitive help. |  |  |
+| '-d, --debug' | Enable debug mode. |  |  |
+
+## Commands
+
+| Command | Description |
+|---------|-------------|
+| ['app serve'](#app-serve) | Start the server. |
+
+## app serve
+
+Start the server.
+
+### Usage
+
+'''
+app serve [flags] [<root>]
+'''
+
+### Arguments
+
+| Argument | Description | Default |
+|----------|-------------|---------|
+| '[<root>]' | Directory to serve. |  |
+
+### Flags
+
+| Flag | Description | Default | Environment |
+|------|-------------|---------|-------------|
+| '-p, --port=INT' | Port to listen on. | '8080' | 'APP_PORT' |
+| '--timeout=SECONDS' | Request timeout. | '30s' |  |
+| '--format=STRING' | Log format. One of: 'json', 'text'. | 'json' |  |
+| '--[no-]color' | Colorize output. |  |  |
+
+### Inherited flags
+
+| Flag | Description | Default | Environment |
+|------|-------------|---------|-------------|
+| '-h, --help' | Show context-sensitive help. |  |  |
+| '-d, --debug' | Enable debug mode. |  |  |
+
+### Examples
+
+'''
+app serve --port=80 ./public
+app serve -p 8081
+'''
+`, "'", "`")
+	require.Equal(t, expected, w.String())
+}
+
+func TestWriteMarkdownPages(t *testing.T) {
+	var cli manCLI
+	p := mustNew(t, &cli, kong.Name("app"))
+	dir := t.TempDir()
+	require.NoError(t, p.WriteMarkdownPages(dir))
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "| [`app serve`](app-serve.md) | Start the server. |\n")
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app-serve.md"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "# app serve\n\nStart the server.\n\n## Usage\n")
+}
+
+func TestWriteMarkdownGroupsAndSorting(t *testing.T) {
+	var cli struct {
+		Verbose bool   `help:"Verbose output."`
+		Format  string `help:"Output format." group:"output"`
+		Color   bool   `help:"Colorize output." group:"output"`
+
+		Destroy struct{} `cmd:"" help:"Destroy everything."`
+		Create  struct{} `cmd:"" help:"Create something."`
+		Login   struct{} `cmd:"" help:"Log in." group:"auth"`
+		Migrate struct{} `cmd:"" help:"Migrate the database." category:"Maintenance"`
+	}
+	p := mustNew(t, &cli, kong.Name("app"),
+		kong.ExplicitGroups([]kong.Group{
+			{Key: "output", Title: "Output:", Description: "How results are printed."},
+			{Key: "auth", Title: "Authentication:"},
+		}),
+		kong.ConfigureHelp(kong.HelpOptions{
+			SortFlags:    func(a, b *kong.Flag) bool { return a.Name < b.Name },
+			SortCommands: func(a, b *kong.Node) bool { return a.Name < b.Name },
+		}))
+	dir := t.TempDir()
+	require.NoError(t, p.WriteMarkdownPages(dir))
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.md"))
+	require.NoError(t, err)
+	expected := strings.ReplaceAll(`# app
+
+## Usage
+
+'''
+app [flags] <command>
+'''
+
+## Flags
+
+| Flag | Description | Default | Environment |
+|------|-------------|---------|-------------|
+| '-h, --help' | Show context-sensitive help. |  |  |
+| '--verbose' | Verbose output. |  |  |
+
+## Output
+
+How results are printed.
+
+| Flag | Description | Default | Environment |
+|------|-------------|---------|-------------|
+| '--color' | Colorize output. |  |  |
+| '--format=STRING' | Output format. |  |  |
+
+## Commands
+
+| Command | Description |
+|---------|-------------|
+| ['app create'](app-create.md) | Create something. |
+| ['app destroy'](app-destroy.md) | Destroy everything. |
+
+## Authentication
+
+| Command | Description |
+|---------|-------------|
+| ['app login'](app-login.md) | Log in. |
+
+## Maintenance
+
+| Command | Description |
+|---------|-------------|
+| ['app migrate'](app-migrate.md) | Migrate the database. |
+`, "'", "`")
+	require.Equal(t, expected, string(data))
+}
@@ -21,6 +21,11 @@ type Application struct {
 	*Node
 	// Help flag, if the NoDefaultHelp() option is not specified.
 	HelpFlag *Flag
+	// Named presets of flag values, selected with the --preset flag.
+	Presets []*Preset
+
+	// Precomputed plan for resetting values to their defaults, built on first use.
+	resetPlan []resetStep
 }
 
 // Argument represents a branching positional argument.
@@ -47,7 +52,9 @@ type Node struct {
 	Help       string // Short help displayed in summaries.
 	Detail     string // Detailed help displayed when describing command/arg alone.
 	Group      *Group
+	Category   string // Category of a command, displayed as a separate section in its parent's help.
 	Hidden     bool
+	Repeated   bool // Leaf command that may be repeated, eg. "add foo add bar", collecting each invocation into a slice.
 	Flags      []*Flag
 	Positional []*Positional
 	Children   []*Node
@@ -57,6 +64,11 @@ type Node struct {
 	Aliases    []string
 
 	Argument *Value // Populated when Type is ArgumentNode.
+
+	// Slice each invocation of a Repeated command is appended to. Target holds the invocation being parsed.
+	invocations reflect.Value
+	// Compiled check:"" expression, if any.
+	check *checkExpr
 }
 
 func (*Node) node() {}
@@ -226,6 +238,49 @@ func (n *Node) ClosestGroup() *Group {
 	}
 }
 
+// FlagGroupKind is the kind of constraint applied to the flags of a FlagGroup.
+type FlagGroupKind string
+
+// Kinds of FlagGroup.
+const (
+	// At most one flag in the group may be used, from the xor:"" tag.
+	XorGroup FlagGroupKind = "xor"
+	// Either all or none of the flags in the group must be used, from the and:"" tag.
+	AndGroup FlagGroupKind = "and"
+)
+
+// A FlagGroup is a set of flags constrained by a shared xor:"" or and:"" tag.
+type FlagGroup struct {
+	Kind  FlagGroupKind
+	Name  string
+	Flags []*Flag
+}
+
+// FlagGroups returns the xor and and groups of the flags of this node, in the order they are first
+// referenced.
+//
+// Groups are restricted to a single command, so flags inherited from parents are not included.
+func (n *Node) FlagGroups() []*FlagGroup {
+	out := []*FlagGroup{}
+	index := map[FlagGroupKind]map[string]*FlagGroup{XorGroup: {}, AndGroup: {}}
+	add := func(kind FlagGroupKind, names []string, flag *Flag) {
+		for _, name := range names {
+			group, ok := index[kind][name]
+			if !ok {
+				group = &FlagGroup{Kind: kind, Name: name}
+				index[kind][name] = group
+				out = append(out, group)
+			}
+			group.Flags = append(group.Flags, flag)
+		}
+	}
+	for _, flag := range n.Flags {
+		add(XorGroup, flag.Xor, flag)
+		add(AndGroup, flag.And, flag)
+	}
+	return out
+}
+
 // A Value is either a flag or a variable positional argument.
 type Value struct {
 	Flag         *Flag // Nil if positional argument.
@@ -238,10 +293,17 @@ type Value struct {
 	Tag          *Tag
 	Target       reflect.Value
 	Required     bool
-	Set          bool   // Set to true when this value is set through some mechanism.
-	Format       string // Formatting directive, if applicable.
-	Position     int    // Position (for positional arguments).
-	Passthrough  bool   // Set to true to stop flag parsing when encountered.
+	Set          bool          // Set to true when this value is set through some mechanism, including defaults. See Context.IsSet().
+	Format       string        // Formatting directive, if applicable.
+	Unit         string        // Unit of numeric and duration values, if any, eg. "ms".
+	Position     int           // Position (for positional arguments).
+	Passthrough  bool          // Set to true to stop flag parsing when encountered.
+	Transform    TransformFunc // Applied to raw string values before they are mapped, if non-nil.
+
+	// Loaders for indirection URIs, keyed by scheme. See Indirection().
+	indirections map[string]IndirectLoader
+	// Compiled check:"" expression, if any.
+	check *checkExpr
 }
 
 // EnumMap returns a map of the enums in this value.
@@ -256,6 +318,9 @@ func (v *Value) EnumMap() map[string]bool {
 
 // ShortSummary returns a human-readable summary of the value, not including any placeholders/defaults.
 func (v *Value) ShortSummary() string {
+	if v.Flag != nil && v.Tag.ShortOnly {
+		return "-" + v.Flag.shortName()
+	}
 	if v.Flag != nil {
 		return fmt.Sprintf("--%s", v.Name)
 	}
@@ -271,6 +336,12 @@ func (v *Value) ShortSummary() string {
 
 // Summary returns a human-readable summary of the value.
 func (v *Value) Summary() string {
+	if v.Flag != nil && v.Tag.ShortOnly {
+		if v.IsBool() || v.IsCounter() {
+			return "-" + v.Flag.shortName()
+		}
+		return fmt.Sprintf("-%s %s", v.Flag.shortName(), v.Flag.FormatPlaceHolder())
+	}
 	if v.Flag != nil {
 		if v.IsBool() {
 			return fmt.Sprintf("--%s", v.Name)
@@ -307,7 +378,7 @@ func (v *Value) IsBool() bool {
 	if m, ok := v.Mapper.(BoolMapper); ok && m.IsBool() {
 		return true
 	}
-	return v.Target.Kind() == reflect.Bool
+	return v.Target.Kind() == reflect.Bool || (v.Target.Kind() == reflect.Ptr && v.Target.Type().Elem().Kind() == reflect.Bool)
 }
 
 // IsCounter returns true if the value is a counter.
@@ -317,6 +388,16 @@ func (v *Value) IsCounter() bool {
 
 // Parse tokens into value, parse, and validate, but do not write to the field.
 func (v *Value) Parse(scan *Scanner, target reflect.Value) (err error) {
+	if len(v.indirections) > 0 {
+		if err = v.resolveIndirection(scan); err != nil {
+			return errors.Wrap(err, v.ShortSummary())
+		}
+	}
+	if v.Transform != nil {
+		previous := scan.transform
+		scan.transform = v.Transform
+		defer func() { scan.transform = previous }()
+	}
 	err = v.Mapper.Decode(&DecodeContext{Value: v, Scan: scan}, target)
 	if err != nil {
 		return errors.Wrap(err, v.ShortSummary())
@@ -372,6 +453,7 @@ type Flag struct {
 	*Value
 	Group       *Group // Logical grouping when displaying. May also be used by configuration loaders to group options logically.
 	Xor         []string
+	And         []string
 	PlaceHolder string
 	Env         string
 	Short       rune
@@ -380,9 +462,12 @@ type Flag struct {
 }
 
 func (f *Flag) String() string {
+	if f.Tag.ShortOnly {
+		return f.Summary()
+	}
 	out := "--" + f.Name
-	if f.Short != 0 {
-		out = fmt.Sprintf("-%c, %s", f.Short, out)
+	if short := f.shortName(); short != "" {
+		out = fmt.Sprintf("-%s, %s", short, out)
 	}
 	if !f.IsBool() && !f.IsCounter() {
 		out += "=" + f.FormatPlaceHolder()
@@ -390,6 +475,19 @@ func (f *Flag) String() string {
 	return out
 }
 
+// The short name of the flag without its dash, which may be multiple characters with MultiRuneShortFlags(),
+// or "" if it has none.
+func (f *Flag) shortName() string {
+	switch {
+	case f.Tag.ShortName != "":
+		return f.Tag.ShortName
+	case f.Short != 0:
+		return string(f.Short)
+	default:
+		return ""
+	}
+}
+
 // FormatPlaceHolder formats the placeholder string for a Flag.
 func (f *Flag) FormatPlaceHolder() string {
 	placeholderHelper, ok := f.Value.Mapper.(PlaceHolderProvider)
@@ -404,6 +502,9 @@ func (f *Flag) FormatPlaceHolder() string {
 		if f.Value.Target.Kind() == reflect.String {
 			return strconv.Quote(f.Default) + tail
 		}
+		if f.Unit != "" && isNumber(f.Default) {
+			return f.Default + f.Unit + tail
+		}
 		return f.Default + tail
 	}
 	if f.PlaceHolder != "" {
@@ -427,6 +528,9 @@ type Group struct {
 	// Description is optional and displayed under the Title when non empty.
 	// It can be used to introduce the group's purpose to the user.
 	Description string
+	// Order controls the position of the group when displayed. Groups are sorted by ascending
+	// Order, with groups of equal Order displayed in order of appearance.
+	Order int
 }
 
 // This is directly from the Go 1.13 source code.
@@ -68,3 +68,30 @@ func TestFlagString(t *testing.T) {
 		require.Equal(t, want, flag.String())
 	}
 }
+
+func TestFlagGroups(t *testing.T) {
+	var cli struct {
+		JSON     bool   `xor:"format"`
+		YAML     bool   `xor:"format"`
+		Username string `and:"auth"`
+		Password string `and:"auth" xor:"secret"`
+		Token    string `xor:"secret"`
+	}
+	p := mustNew(t, &cli)
+	actual := map[string][]string{}
+	for _, group := range p.Model.FlagGroups() {
+		for _, flag := range group.Flags {
+			key := string(group.Kind) + ":" + group.Name
+			actual[key] = append(actual[key], flag.Name)
+		}
+	}
+	require.Equal(t, map[string][]string{
+		"xor:format": {"json", "yaml"},
+		"and:auth":   {"username", "password"},
+		"xor:secret": {"password", "token"},
+	}, actual)
+	groups := p.Model.FlagGroups()
+	require.Equal(t, "format", groups[0].Name)
+	require.Equal(t, "auth", groups[1].Name)
+	require.Equal(t, "secret", groups[2].Name)
+}
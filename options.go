@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -47,6 +48,28 @@ func (v Vars) CloneWith(vars Vars) Vars {
 	return out
 }
 
+// Profile selects the named profile, eg. "prod", that profile-default:"" tags choose defaults for.
+//
+// The profile is also available for interpolation as ${profile}, including in conditionals. eg.
+//
+//     Level string `default:"${profile==prod ? 'warn' : 'debug'}"`
+func Profile(name string) Option {
+	return Vars{"profile": name}
+}
+
+// NoExit guarantees that Kong never calls the Exit function, for libraries and TUIs embedding Kong that
+// must keep control of the process lifecycle.
+//
+// Instead, Kong.Parse() returns ErrHelpRequested or ErrVersionRequested, wrapped in a *ParseError, after
+// help or the version is printed. These can be checked with errors.Is(). Fatalf() and FatalIfErrorf()
+// print their message but return rather than exiting.
+func NoExit() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.noExit = true
+		return nil
+	})
+}
+
 // Exit overrides the function used to terminate. This is useful for testing or interactive use.
 func Exit(exit func(int)) Option {
 	return OptionFunc(func(k *Kong) error {
@@ -89,6 +112,17 @@ func NoDefaultHelp() Option {
 	})
 }
 
+// HelpHidden adds a hidden --help-hidden flag that displays help including hidden flags and commands,
+// and includes them in the candidates of Kong.Complete().
+//
+// This is useful for power users and for debugging what a binary actually supports.
+func HelpHidden() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.helpHidden = true
+		return nil
+	})
+}
+
 // PostBuild provides read/write access to kong.Kong after initial construction of the model is complete but before
 // parsing occurs.
 //
@@ -132,6 +166,27 @@ func KindMapper(kind reflect.Kind, mapper Mapper) Option {
 	})
 }
 
+// BoolStrings replaces the strings accepted as true and false values for booleans, which default to
+// "true", "1" and "yes", and "false", "0" and "no". Matching is case-insensitive.
+//
+//     kong.BoolStrings([]string{"true", "yes", "on", "1"}, []string{"false", "no", "off", "0"})
+func BoolStrings(truthy, falsy []string) Option {
+	return OptionFunc(func(k *Kong) error {
+		if len(truthy) == 0 || len(falsy) == 0 {
+			return errors.Errorf("BoolStrings requires at least one true and one false value")
+		}
+		mapper := boolMapper{}
+		for _, s := range truthy {
+			mapper.truthy = append(mapper.truthy, strings.ToLower(s))
+		}
+		for _, s := range falsy {
+			mapper.falsy = append(mapper.falsy, strings.ToLower(s))
+		}
+		k.registry.RegisterKind(reflect.Bool, mapper)
+		return nil
+	})
+}
+
 // ValueMapper registers a mapper to a field value.
 func ValueMapper(ptr interface{}, mapper Mapper) Option {
 	return OptionFunc(func(k *Kong) error {
@@ -148,6 +203,14 @@ func NamedMapper(name string, mapper Mapper) Option {
 	})
 }
 
+// NamedTransform registers a transform to a name, for use in "transform" tags.
+func NamedTransform(name string, transform TransformFunc) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.registry.RegisterTransform(name, transform)
+		return nil
+	})
+}
+
 // Writers overrides the default writers. Useful for testing or interactive use.
 func Writers(stdout, stderr io.Writer) Option {
 	return OptionFunc(func(k *Kong) error {
@@ -262,7 +325,8 @@ func (g Groups) Apply(k *Kong) error { // nolint: revive
 
 // ExplicitGroups associates `group` field tags with their metadata.
 //
-// It can be used to provide a title or header to a command or flag group.
+// It can be used to provide a title or header to a command or flag group. Groups are
+// displayed in ascending Group.Order, falling back to their order of appearance.
 func ExplicitGroups(groups []Group) Option {
 	return OptionFunc(func(k *Kong) error {
 		k.groups = groups
@@ -304,6 +368,156 @@ func Resolvers(resolvers ...Resolver) Option {
 	})
 }
 
+// NamedResolver registers a resolver that is only consulted for flags that reference it by name with
+// the resolver:"<name>" tag, on the flag itself or on an enclosing command or embedded struct.
+//
+// Named resolvers take precedence over those registered with Resolvers(). This avoids eg. secret
+// stores being queried for every flag, and makes explicit which flags they supply.
+//
+//     var cli struct {
+//         Database struct {
+//             Password string
+//         } `embed:"" prefix:"db-" resolver:"vault"`
+//     }
+//     kong.Parse(&cli, kong.NamedResolver("vault", vaultResolver))
+func NamedResolver(name string, resolver Resolver) Option {
+	return OptionFunc(func(k *Kong) error {
+		if k.namedResolvers == nil {
+			k.namedResolvers = map[string]Resolver{}
+		}
+		k.namedResolvers[name] = resolver
+		return nil
+	})
+}
+
+// StrictFlags requires flags to be specified immediately after the command that defines them.
+//
+// By default flags of parent commands may appear anywhere after the command. With this option flags of a
+// parent must appear before its subcommand, and flags of the subcommand after it, eg.
+//
+//     app --debug run --rm
+//
+// The builtin help flags may still appear anywhere.
+func StrictFlags() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.strictFlags = true
+		return nil
+	})
+}
+
+// POSIXStrict enforces the POSIX utility syntax guidelines when parsing.
+//
+// In this mode:
+//
+// - flags must precede positional arguments: everything after the first positional argument of a command
+//   is itself positional, eg. in "app rm file -f", "-f" is a file name;
+// - short flags do not accept "=", so values must be given as -fVALUE or -f VALUE;
+// - "--" always terminates flag parsing, and is never consumed as a flag value;
+// - flags with optional values, such as bool and counter flags, only accept a value in the long form
+//   with "=", eg. --verbose=false.
+//
+// Flags may still appear after the name of a command, as commands are not operands.
+func POSIXStrict() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.posixStrict = true
+		return nil
+	})
+}
+
+// MultiRuneShortFlags allows short flag names of more than one character, for emulating compiler and linker
+// style interfaces, eg.
+//
+//     Rf      bool     `short:"rf"`
+//     Linker  []string `short:"Wl"`
+//     Warning []string `short:"W"`
+//
+// A word starting with a single dash is matched against the longest multi-character short name that it
+// starts with, which takes precedence over clustering. The rest of the word is the value of the flag if it
+// takes one, eg. "-Wl,--as-needed", or is otherwise clustered as usual, eg. "-rfv" is "-rf -v". Words that
+// don't start with a multi-character short name are clustered as usual, eg. "-Wall" is "-W all".
+// Multi-character short names are only matched at the start of a word, never within a cluster.
+func MultiRuneShortFlags() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.multiRuneShort = true
+		return nil
+	})
+}
+
+// AggregateErrors reports all validation failures at once, such as missing required flags and arguments,
+// invalid enum values, Validate() errors and conflicting flags, rather than only the first.
+//
+// When there is more than one failure, Parse() returns a ParseError wrapping ValidationErrors, which
+// formats them as a bulleted list.
+func AggregateErrors() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.aggregateErrors = true
+		return nil
+	})
+}
+
+// EnvOnly configures the application purely from environment variables, defaults and resolvers, for
+// 12-factor daemons and non-CLI entry points such as Lambda handlers.
+//
+// Parse() skips the scanning and tracing of the command-line entirely, failing if it's given any arguments,
+// and Parse() of an application with commands fails as no command can be selected. Help doesn't list flags,
+// and missing values are reported by environment variable.
+//
+//     kong.Parse(&config, kong.EnvOnly(), kong.DefaultEnvars("MYAPP"))
+func EnvOnly() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.envOnly = true
+		return nil
+	})
+}
+
+// RunTimeout limits how long Context.Run() may take before returning an error.
+//
+// A context.Context with the deadline is bindable to Run() methods, which must honour it by abandoning work
+// once it is done: Context.Run() returns when the deadline passes, and the bound io.Writer and *bufio.Writer
+// reject writes from then on. If a context.Context is already bound, it is used as the parent.
+//
+// If a Run() method panics, the panic is propagated to the caller of Context.Run() as a *PanicError including
+// the stack trace of the goroutine Run() was called in.
+func RunTimeout(timeout time.Duration) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.runTimeout = timeout
+		return nil
+	})
+}
+
+// RecoverPanics converts panics in Run() methods into a *PanicError, rather than crashing.
+func RecoverPanics() Option {
+	return OptionFunc(func(k *Kong) error {
+		k.recoverPanics = true
+		return nil
+	})
+}
+
+// UsageHook sets a function that is called after Context.Run() with the selected command, the names of
+// the flags used, the duration and the exit status, for wiring up anonymous usage telemetry.
+//
+// Flag and argument values are never included.
+func UsageHook(hook UsageHookFunc) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.usageHook = hook
+		return nil
+	})
+}
+
+// ResolverErrorHandler sets a function that is called when a Resolver fails.
+//
+// The handler can downgrade the error to a warning by returning nil, which skips the failing resolver
+// for that flag.
+func ResolverErrorHandler(handler ResolverErrorHandlerFunc) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.resolverErrorHandler = handler
+		return nil
+	})
+}
+
+// Returns true if a field of parent should be skipped when building the model.
+type ignoreFieldFunc func(parent reflect.Type, field reflect.StructField, tag *Tag) bool
+
 // IgnoreFields will cause kong.New() to skip field names that match any
 // of the provided regex patterns. This is useful if you are not able to add a
 // kong="-" struct tag to a struct/element before the call to New.
@@ -322,13 +536,98 @@ func IgnoreFields(regexes ...string) Option {
 				return errors.Wrap(err, "unable to compile regex")
 			}
 
-			k.ignoreFields = append(k.ignoreFields, re)
+			k.ignoreFields = append(k.ignoreFields, func(parent reflect.Type, field reflect.StructField, tag *Tag) bool {
+				return re.MatchString(parent.Name() + "." + field.Name)
+			})
 		}
 
 		return nil
 	})
 }
 
+// IgnoreFieldsFunc will cause kong.New() to skip fields for which ignore returns true.
+func IgnoreFieldsFunc(ignore func(field reflect.StructField) bool) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.ignoreFields = append(k.ignoreFields, func(parent reflect.Type, field reflect.StructField, tag *Tag) bool {
+			return ignore(field)
+		})
+		return nil
+	})
+}
+
+// IgnoreGroups will cause kong.New() to skip fields tagged with any of the
+// provided groups, eg. `ignore-group:"internal"`.
+func IgnoreGroups(groups ...string) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.ignoreFields = append(k.ignoreFields, func(parent reflect.Type, field reflect.StructField, tag *Tag) bool {
+			for _, group := range tag.GetAll("ignore-group") {
+				if containsString(groups, group) {
+					return true
+				}
+			}
+			return false
+		})
+		return nil
+	})
+}
+
+// IgnoreTypes will cause kong.New() to skip fields of the same type as any
+// of the provided values.
+//
+// eg.
+//
+//     kong.IgnoreTypes(lexer.Position{}, (*lexer.Token)(nil))
+func IgnoreTypes(values ...interface{}) Option {
+	return OptionFunc(func(k *Kong) error {
+		types := map[reflect.Type]bool{}
+		for _, value := range values {
+			if value == nil {
+				return errors.New("type input cannot be nil")
+			}
+			types[reflect.TypeOf(value)] = true
+		}
+		k.ignoreFields = append(k.ignoreFields, func(parent reflect.Type, field reflect.StructField, tag *Tag) bool {
+			return types[field.Type]
+		})
+		return nil
+	})
+}
+
+// Implement supplies the implementation of command fields of an interface type, allowing alternative
+// implementations of a command to share the grammar that embeds it, eg. in different builds.
+//
+// "iface" is a nil pointer to the interface, and "impl" is a pointer to the struct implementing it. Each
+// nil field of the interface type tagged with cmd:"" or arg:"" is set to a copy of impl, whose fields
+// are then parsed as if it had been declared in place of the interface.
+//
+// eg.
+//
+//     var cli struct {
+//         Deploy Deployer `cmd:""`
+//     }
+//     kong.Parse(&cli, kong.Implement((*Deployer)(nil), &enterpriseDeploy{}))
+func Implement(iface interface{}, impl interface{}) Option {
+	return OptionFunc(func(k *Kong) error {
+		ifaceType := reflect.TypeOf(iface)
+		if ifaceType == nil || ifaceType.Kind() != reflect.Ptr || ifaceType.Elem().Kind() != reflect.Interface {
+			return errors.Errorf("expected a nil pointer to an interface but got %T", iface)
+		}
+		ifaceType = ifaceType.Elem()
+		implValue := reflect.ValueOf(impl)
+		if implValue.Kind() != reflect.Ptr || implValue.Elem().Kind() != reflect.Struct {
+			return errors.Errorf("implementation of %s must be a pointer to a struct but got %T", ifaceType, impl)
+		}
+		if !implValue.Type().Implements(ifaceType) {
+			return errors.Errorf("%T does not implement %s", impl, ifaceType)
+		}
+		if k.implementations == nil {
+			k.implementations = map[reflect.Type]reflect.Value{}
+		}
+		k.implementations[ifaceType] = implValue
+		return nil
+	})
+}
+
 // ConfigurationLoader is a function that builds a resolver from a file.
 type ConfigurationLoader func(r io.Reader) (Resolver, error)
 
@@ -358,7 +657,7 @@ func Configuration(loader ConfigurationLoader, paths ...string) Option {
 				return errors.Wrap(err, path)
 			}
 			if resolver != nil {
-				k.resolvers = append(k.resolvers, resolver)
+				k.resolvers = append(k.resolvers, newFileResolver(resolver, ExpandPath(path)))
 			}
 		}
 		return nil
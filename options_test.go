@@ -1,8 +1,11 @@
 package kong
 
 import (
+	"errors"
+	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -22,6 +25,38 @@ type impl string
 
 func (impl) Method() {}
 
+func TestUpdateCheckTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { updateCheckTimeout = timeout }(updateCheckTimeout)
+	updateCheckTimeout = 10 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+	check := &updateCheck{current: "v1.0.0", source: func() (string, error) {
+		<-release
+		return "v2.0.0", nil
+	}}
+	start := time.Now()
+	_, err := check.latest()
+	require.EqualError(t, err, "update check timed out after 10ms")
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestNoExitReturnsExitError(t *testing.T) {
+	var cli struct{}
+	exited := -1
+	p, err := New(&cli, Exit(func(status int) { exited = status }))
+	require.NoError(t, err)
+	require.NoError(t, p.exitWith(3))
+	require.Equal(t, 3, exited)
+
+	exited = -1
+	p, err = New(&cli, Exit(func(status int) { exited = status }), NoExit())
+	require.NoError(t, err)
+	err = p.exitWith(3)
+	require.Equal(t, &ExitError{Status: 3}, err)
+	require.Equal(t, 3, exitStatus(err))
+	require.Equal(t, -1, exited)
+}
+
 func TestBindTo(t *testing.T) {
 	type iface interface {
 		Method()
@@ -67,3 +102,36 @@ func TestBindToProvider(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, cli.Called)
 }
+
+func TestOptionBundles(t *testing.T) {
+	var cli struct {
+		LogLevel string
+	}
+	os.Setenv("MY_APP_LOG_LEVEL", "debug")
+	defer os.Unsetenv("MY_APP_LOG_LEVEL")
+	p, err := New(&cli, DefaultsForDaemon("my-app"))
+	require.NoError(t, err)
+	_, err = p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "debug", cli.LogLevel)
+
+	p, err = New(&cli, DefaultsForScripting())
+	require.NoError(t, err)
+	require.True(t, p.helpOptions.Compact)
+	require.True(t, p.strictFlags)
+
+	called := []string{}
+	record := func(name string) Option {
+		return OptionFunc(func(k *Kong) error {
+			called = append(called, name)
+			return nil
+		})
+	}
+	_, err = New(&cli, Bundle("third-party", record("a"), record("b")), record("c"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c"}, called)
+
+	failing := OptionFunc(func(k *Kong) error { return errors.New("failed") })
+	_, err = New(&cli, Bundle("broken", failing))
+	require.EqualError(t, err, "broken: failed")
+}
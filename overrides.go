@@ -0,0 +1,91 @@
+package kong
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseWithOverrides parses args, additionally applying "key=value" overrides to flags.
+//
+// Overrides are applied through the resolver pipeline, after any configured resolvers, such as
+// configuration files, but have lower precedence than flags explicitly set on the command-line.
+//
+// The key is either the name of the flag, or the dot separated path of the command the flag is
+// defined on followed by the flag name, eg.
+//
+//     parser.ParseWithOverrides(os.Args[1:], []string{"verbose=true", "server.port=8080"})
+//
+// Keys that do not match any flag are an error.
+func (k *Kong) ParseWithOverrides(args []string, overrides []string) (*Context, error) {
+	resolver, err := newOverrideResolver(overrides)
+	if err != nil {
+		return nil, err
+	}
+	return k.parse(args, resolver)
+}
+
+type overrideResolver struct {
+	values map[string]string
+}
+
+func newOverrideResolver(overrides []string) (*overrideResolver, error) {
+	values := map[string]string{}
+	for _, override := range overrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, errors.Errorf("invalid override %q, expected key=value", override)
+		}
+		values[parts[0]] = parts[1]
+	}
+	return &overrideResolver{values: values}, nil
+}
+
+func (o *overrideResolver) Validate(app *Application) error {
+	known := map[string]bool{}
+	var walk func(node *Node)
+	walk = func(node *Node) {
+		for _, flag := range node.Flags {
+			known[flag.Name] = true
+			known[overrideKey(node, flag)] = true
+		}
+		for _, child := range node.Children {
+			walk(child)
+		}
+	}
+	walk(app.Node)
+	keys := make([]string, 0, len(o.values))
+	for key := range o.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			return errors.Errorf("unknown override %q", key)
+		}
+	}
+	return nil
+}
+
+func (o *overrideResolver) Resolve(context *Context, parent *Path, flag *Flag) (interface{}, error) {
+	// The fully qualified key takes precedence over the bare flag name.
+	if value, ok := o.values[overrideKey(parent.Node(), flag)]; ok {
+		return value, nil
+	}
+	if value, ok := o.values[flag.Name]; ok {
+		return value, nil
+	}
+	return nil, nil
+}
+
+// overrideKey returns the dot separated command path to a flag, eg. "server.start.port".
+func overrideKey(node *Node, flag *Flag) string {
+	parts := []string{flag.Name}
+	for ; node != nil; node = node.Parent {
+		if node.Type == CommandNode {
+			parts = append([]string{node.Name}, parts...)
+		}
+	}
+	return strings.Join(parts, ".")
+}
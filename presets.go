@@ -0,0 +1,105 @@
+package kong
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Preset is a named collection of flag values, defined with the "preset" tag:
+//
+//     Workers int  `preset:"fast=16" preset:"safe=1"`
+//     Cache   bool `preset:"fast=true"`
+//
+// Presets are selected with the --preset flag. Their values take precedence over other resolvers, such
+// as configuration files, but not over flags explicitly set on the command-line.
+type Preset struct {
+	Name   string
+	Values []PresetValue
+}
+
+// PresetValue is the value of a single flag in a Preset.
+type PresetValue struct {
+	Flag  *Flag
+	Value string
+}
+
+// Summary of the flag values in the preset, eg. "--workers=16 --cache=true".
+func (p *Preset) Summary() string {
+	values := make([]string, 0, len(p.Values))
+	for _, value := range p.Values {
+		values = append(values, "--"+value.Flag.Name+"="+value.Value)
+	}
+	return strings.Join(values, " ")
+}
+
+// Collect presets from flag tags, in order of appearance.
+func collectPresets(node *Node) []*Preset {
+	presets := []*Preset{}
+	index := map[string]*Preset{}
+	_ = Visit(node, func(node Visitable, next Next) error {
+		flag, ok := node.(*Flag)
+		if !ok {
+			return next(nil)
+		}
+		for _, item := range flag.Tag.GetAll("preset") {
+			name := strings.SplitN(item, "=", 2)[0]
+			preset, ok := index[name]
+			if !ok {
+				preset = &Preset{Name: name}
+				index[name] = preset
+				presets = append(presets, preset)
+			}
+			preset.Values = append(preset.Values, PresetValue{Flag: flag, Value: flag.Tag.Presets[name]})
+		}
+		return next(nil)
+	})
+	return presets
+}
+
+func (k *Kong) presetFlag(presets []*Preset) *Flag {
+	var target presetValue
+	value := reflect.ValueOf(&target).Elem()
+	names := make([]string, 0, len(presets))
+	for _, preset := range presets {
+		names = append(names, preset.Name)
+	}
+	flag := &Flag{
+		PlaceHolder: "NAME",
+		Value: &Value{
+			Name:         "preset",
+			Help:         "Apply a named preset of flag values (" + strings.Join(names, ", ") + ").",
+			Target:       value,
+			Tag:          newEmptyTag(),
+			Mapper:       k.registry.ForValue(value),
+			DefaultValue: reflect.ValueOf(""),
+		},
+	}
+	flag.Flag = flag
+	return flag
+}
+
+type presetValue string
+
+// BeforeResolve adds a resolver applying the selected preset.
+func (p presetValue) BeforeResolve(ctx *Context, trace *Path) error {
+	name := string(ctx.FlagValue(trace.Flag).(presetValue))
+	for _, preset := range ctx.Model.Presets {
+		if preset.Name != name {
+			continue
+		}
+		values := map[*Flag]string{}
+		for _, value := range preset.Values {
+			values[value.Flag] = value.Value
+		}
+		ctx.AddResolver(ResolverFunc(func(context *Context, parent *Path, flag *Flag) (interface{}, error) {
+			if value, ok := values[flag]; ok {
+				return value, nil
+			}
+			return nil, nil
+		}))
+		return nil
+	}
+	return errors.Errorf("unknown preset %q", name)
+}
@@ -0,0 +1,140 @@
+package kong
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// CommandLine reconstructs the arguments the application was invoked with, after expansion of
+// aliases, omitting any of the flags named in dropFlags along with their values.
+//
+// Flags are named without leading dashes, and may be referred to by their long name, short name or
+// any of their aliases.
+//
+//     args := ctx.CommandLine("sudo", "config")
+func (c *Context) CommandLine(dropFlags ...string) []string {
+	drop := map[string]*Flag{}
+	for _, flag := range c.Flags() {
+		names := append([]string{flag.Name}, flag.Tag.Aliases...)
+		if short := flag.shortName(); short != "" {
+			names = append(names, short)
+		}
+		for _, name := range names {
+			for _, dropped := range dropFlags {
+				if name == dropped {
+					drop[flag.Name] = flag
+				}
+			}
+		}
+	}
+	// Map of each spelling of a dropped flag, including negations, to the flag.
+	spellings := map[string]*Flag{}
+	for _, flag := range drop {
+		spellings["--"+flag.Name] = flag
+		if flag.Tag.Negatable {
+			spellings["--no-"+flag.Name] = flag
+		}
+		for _, alias := range flag.Tag.Aliases {
+			spellings["--"+alias] = flag
+		}
+		if short := flag.shortName(); short != "" {
+			spellings["-"+short] = flag
+		}
+	}
+	args := []string{}
+	for i := 0; i < len(c.ExpandedArgs); i++ {
+		arg := c.ExpandedArgs[i]
+		if arg == "--" {
+			args = append(args, c.ExpandedArgs[i:]...)
+			break
+		}
+		name, hasValue := arg, false
+		if strings.HasPrefix(arg, "--") {
+			if eq := strings.Index(arg, "="); eq != -1 {
+				name, hasValue = arg[:eq], true
+			}
+		} else if strings.HasPrefix(arg, "-") && len(arg) > 2 {
+			// A short flag with its value attached, eg. -ofile.
+			r, size := utf8.DecodeRuneInString(arg[1:])
+			name, hasValue = "-"+string(r), len(arg) > 1+size
+		}
+		flag, ok := spellings[name]
+		if !ok || (hasValue && !strings.HasPrefix(arg, "--") && (flag.IsBool() || flag.IsCounter())) {
+			// Not dropped, or a cluster of short boolean flags which is preserved as is.
+			args = append(args, arg)
+			continue
+		}
+		if !hasValue && !flag.IsBool() && !flag.IsCounter() && i+1 < len(c.ExpandedArgs) {
+			i++
+		}
+	}
+	return args
+}
+
+// ReExec replaces the running process with a new invocation of the application, with the same
+// command line, as reconstructed by Context.CommandLine(), less any of the flags in dropFlags.
+//
+// Variables in extraEnv are added to the environment of the new process, overriding any existing
+// values. This is useful for CLIs that need to restart themselves with different settings, eg. after
+// a configuration change.
+//
+// On Windows, where a process can't be replaced, the application is run as a child process and
+// the current process exits with its exit status once it completes. If the NoExit() option is set, an
+// *ExitError with the status is returned instead.
+//
+// Otherwise ReExec only returns if the application could not be executed.
+func ReExec(ctx *Context, extraEnv map[string]string, dropFlags ...string) error {
+	return reExec(ctx, false, extraEnv, dropFlags)
+}
+
+// ReExecElevated is like ReExec, but runs the new invocation with elevated privileges, using sudo
+// on Unix and runas on Windows.
+//
+//     if os.Geteuid() != 0 {
+//         return kong.ReExecElevated(ctx, nil, "sudo")
+//     }
+func ReExecElevated(ctx *Context, extraEnv map[string]string, dropFlags ...string) error {
+	return reExec(ctx, true, extraEnv, dropFlags)
+}
+
+func reExec(ctx *Context, elevate bool, extraEnv map[string]string, dropFlags []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return errors.Wrap(err, "failed to find executable")
+	}
+	argv := append([]string{exe}, ctx.CommandLine(dropFlags...)...)
+	env := mergeEnv(os.Environ(), extraEnv)
+	if elevate {
+		argv = elevateCommand(argv, extraEnv)
+	}
+	err = execProcess(argv, env, ctx.Kong.exitWith)
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return err
+	}
+	return errors.Wrapf(err, "failed to re-execute %s", ctx.Model.Name)
+}
+
+// Merge extra variables into an environment of KEY=VALUE pairs, sorted for stability.
+func mergeEnv(environ []string, extra map[string]string) []string {
+	env := make([]string, 0, len(environ)+len(extra))
+	for _, kv := range environ {
+		key := strings.SplitN(kv, "=", 2)[0]
+		if _, ok := extra[key]; !ok {
+			env = append(env, kv)
+		}
+	}
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+extra[key])
+	}
+	return env
+}
@@ -0,0 +1,18 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris && !windows
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris,!windows
+
+package kong
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func elevateCommand(argv []string, extraEnv map[string]string) []string {
+	return argv
+}
+
+func execProcess(argv, env []string, exit func(int) error) error {
+	return errors.Errorf("re-executing is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,32 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package kong
+
+import (
+	"os/exec"
+	"sort"
+	"syscall"
+)
+
+// Prefix argv with sudo. sudo resets the environment, so extra variables are passed on its command-line.
+func elevateCommand(argv []string, extraEnv map[string]string) []string {
+	elevated := []string{"sudo"}
+	keys := make([]string, 0, len(extraEnv))
+	for key := range extraEnv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		elevated = append(elevated, key+"="+extraEnv[key])
+	}
+	return append(elevated, argv...)
+}
+
+func execProcess(argv, env []string, exit func(int) error) error {
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, argv, env)
+}
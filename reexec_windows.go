@@ -0,0 +1,49 @@
+//go:build windows
+// +build windows
+
+package kong
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"syscall"
+)
+
+// Run argv with runas. runas takes the command as a single argument, and does not pass the
+// environment through, so extra variables are set by running the command with cmd.
+func elevateCommand(argv []string, extraEnv map[string]string) []string {
+	line := syscall.EscapeArg(argv[0])
+	for _, arg := range argv[1:] {
+		line += " " + syscall.EscapeArg(arg)
+	}
+	if len(extraEnv) > 0 {
+		keys := make([]string, 0, len(extraEnv))
+		for key := range extraEnv {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		set := ""
+		for _, key := range keys {
+			set += `set "` + key + "=" + extraEnv[key] + `" && `
+		}
+		line = "cmd /c " + set + line
+	}
+	return []string{"runas", "/user:Administrator", line}
+}
+
+// Windows can't replace the running process, so run argv as a child and exit with its status.
+func execProcess(argv, env []string, exit func(int) error) error {
+	cmd := exec.Command(argv[0], argv[1:]...) // nolint: gosec
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return exit(exitErr.ExitCode())
+	} else if err != nil {
+		return err
+	}
+	return exit(0)
+}
@@ -0,0 +1,96 @@
+package kong
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Returns true if any flag in the tree rooted at node is tagged with remember:"".
+func hasRememberedFlags(node *Node) bool {
+	found := false
+	_ = Visit(node, func(node Visitable, next Next) error {
+		if flag, ok := node.(*Flag); ok && flag.Tag.Remember {
+			found = true
+		}
+		return next(nil)
+	})
+	return found
+}
+
+func (k *Kong) forgetFlag() *Flag {
+	var target bool
+	value := reflect.ValueOf(&target).Elem()
+	flag := &Flag{
+		Value: &Value{
+			Name:         "forget",
+			Help:         "Forget remembered flag values.",
+			Target:       value,
+			Tag:          newEmptyTag(),
+			Mapper:       k.registry.ForValue(value),
+			DefaultValue: reflect.ValueOf(false),
+		},
+	}
+	flag.Flag = flag
+	k.forget = flag
+	return flag
+}
+
+// Returns true if --forget was passed on the command-line.
+func (k *Kong) forgetting(ctx *Context) bool {
+	for _, path := range ctx.Path {
+		if path.Flag == k.forget {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolves flags tagged with remember:"" to their last explicitly used values.
+func (k *Kong) rememberResolver() Resolver {
+	return ResolverFunc(func(ctx *Context, parent *Path, flag *Flag) (interface{}, error) {
+		if !flag.Tag.Remember || k.forgetting(ctx) {
+			return nil, nil
+		}
+		// Remembered values are a convenience, so an unreadable state file is ignored.
+		state, _, err := k.loadState()
+		if err != nil {
+			return nil, nil // nolint: nilerr
+		}
+		if value, ok := state.Remembered[flag.Name]; ok {
+			return value, nil
+		}
+		return nil, nil
+	})
+}
+
+// Record the values of remembered flags passed on the command-line, or forget them all with --forget.
+func (k *Kong) recordRemembered(ctx *Context) error {
+	state, _, err := k.loadState()
+	if err != nil {
+		return err
+	}
+	changed := false
+	if k.forgetting(ctx) && len(state.Remembered) > 0 {
+		state.Remembered = nil
+		changed = true
+	}
+	for _, path := range ctx.Path {
+		flag := path.Flag
+		if flag == nil || path.Resolved || !flag.Tag.Remember {
+			continue
+		}
+		target := reflect.Indirect(flag.Target)
+		if !target.IsValid() || !isBasicType(target.Type()) {
+			continue
+		}
+		if state.Remembered == nil {
+			state.Remembered = map[string]string{}
+		}
+		state.Remembered[flag.Name] = fmt.Sprint(target.Interface())
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return k.saveState(state)
+}
@@ -0,0 +1,90 @@
+package kong
+
+import (
+	"reflect"
+)
+
+// Returns true if token names the repeated command node, starting another invocation of it.
+func repeatsCommand(node *Node, token Token) bool {
+	if !node.Repeated {
+		return false
+	}
+	if token.Value == node.Name {
+		return true
+	}
+	for _, alias := range node.Aliases {
+		if token.Value == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// Start another invocation of the repeated command node, setting aside the values parsed by the last.
+func (c *Context) repeatCommand(node *Node) {
+	values := map[*Value]reflect.Value{}
+	own := append([]*Value{}, node.Positional...)
+	for _, flag := range node.Flags {
+		own = append(own, flag.Value)
+	}
+	for _, value := range own {
+		if v, ok := c.values[value]; ok {
+			values[value] = v
+			delete(c.values, value)
+		}
+	}
+	for i := len(c.Path) - 1; i >= 0; i-- {
+		if c.Path[i].Command == node {
+			c.Path[i].invocation = values
+			break
+		}
+	}
+	c.Path = append(c.Path, &Path{
+		Parent:  node.Parent,
+		Command: node,
+		Flags:   node.Flags,
+	})
+}
+
+// Collects the invocations of repeated commands while a Context is applied.
+type invocationCollector struct {
+	c     *Context
+	nodes []*Node
+}
+
+// Called for each occurrence of a repeated command in the path, before its values are applied.
+func (i *invocationCollector) begin(node *Node) {
+	for _, seen := range i.nodes {
+		if seen == node {
+			i.end(node)
+			return
+		}
+	}
+	i.nodes = append(i.nodes, node)
+	// Invocations start from the target as it was before the first was applied, with defaults set.
+	baseline, ok := i.c.repeatBaselines[node]
+	if !ok {
+		baseline = reflect.New(node.Target.Type()).Elem()
+		baseline.Set(node.Target)
+		i.c.repeatBaselines[node] = baseline
+	}
+	node.Target.Set(baseline)
+	node.invocations.Set(reflect.MakeSlice(node.invocations.Type(), 0, 0))
+}
+
+// Append the invocation of node that has been applied to its target, and reset the target for the next.
+func (i *invocationCollector) end(node *Node) {
+	invocation := reflect.New(node.Target.Type()).Elem()
+	invocation.Set(node.Target)
+	node.invocations.Set(reflect.Append(node.invocations, invocation))
+	node.Target.Set(i.c.repeatBaselines[node])
+}
+
+// Append the last invocation of each repeated command.
+func (i *invocationCollector) finish() {
+	for _, node := range i.nodes {
+		invocation := reflect.New(node.Target.Type()).Elem()
+		invocation.Set(node.Target)
+		node.invocations.Set(reflect.Append(node.invocations, invocation))
+	}
+}
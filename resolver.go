@@ -2,8 +2,17 @@ package kong
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 // A Resolver resolves a Flag value from an external source.
@@ -17,6 +26,19 @@ type Resolver interface {
 	Resolve(context *Context, parent *Path, flag *Flag) (interface{}, error)
 }
 
+// A BatchResolver is a Resolver that resolves the values of all the flags of a command in one call, eg.
+// to fetch them from a network service in a single round trip.
+//
+// ResolveBatch is called once for each command in the selected path, with its flags that were not set
+// on the command-line, and returns their values keyed by flag name. Flags without a value are omitted.
+// Resolve is not called for these flags.
+//
+// As results are cached by resolver, implementations must be comparable, eg. pointers.
+type BatchResolver interface {
+	Resolver
+	ResolveBatch(context *Context, parent *Path, flags []*Flag) (map[string]interface{}, error)
+}
+
 // ResolverFunc is a convenience type for non-validating Resolvers.
 type ResolverFunc func(context *Context, parent *Path, flag *Flag) (interface{}, error)
 
@@ -27,6 +49,41 @@ func (r ResolverFunc) Resolve(context *Context, parent *Path, flag *Flag) (inter
 }
 func (r ResolverFunc) Validate(app *Application) error { return nil } // nolint: revive
 
+// Check that every resolver referenced by a resolver:"" tag is registered.
+func (k *Kong) checkNamedResolvers(node *Node) error {
+	return Visit(node, func(node Visitable, next Next) error {
+		var tag *Tag
+		switch node := node.(type) {
+		case *Node:
+			tag = node.Tag
+		case *Value:
+			tag = node.Tag
+		}
+		if tag != nil {
+			for _, name := range tag.Resolvers {
+				if _, ok := k.namedResolvers[name]; !ok {
+					return fmt.Errorf("unknown resolver %q, use kong.NamedResolver(%q, resolver)", name, name)
+				}
+			}
+		}
+		return next(nil)
+	})
+}
+
+// Named resolvers in a stable order.
+func (k *Kong) sortedNamedResolvers() []Resolver {
+	names := make([]string, 0, len(k.namedResolvers))
+	for name := range k.namedResolvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]Resolver, 0, len(names))
+	for _, name := range names {
+		out = append(out, k.namedResolvers[name])
+	}
+	return out
+}
+
 // JSON returns a Resolver that retrieves values from a JSON source.
 //
 // Hyphens in flag names are replaced with underscores.
@@ -37,7 +94,7 @@ func JSON(r io.Reader) (Resolver, error) {
 		return nil, err
 	}
 	var f ResolverFunc = func(context *Context, parent *Path, flag *Flag) (interface{}, error) {
-		name := strings.ReplaceAll(flag.Name, "-", "_")
+		name := configKey(flag)
 		raw, ok := values[name]
 		if ok {
 			return raw, nil
@@ -58,3 +115,233 @@ func JSON(r io.Reader) (Resolver, error) {
 
 	return f, nil
 }
+
+// The key used to look up a flag in configuration files, eg. "log_level" for --log-level.
+//
+// Dots in the key, such as from a prefix, are treated as paths into nested objects.
+func configKey(flag *Flag) string {
+	return strings.ReplaceAll(flag.Name, "-", "_")
+}
+
+// A resolver loaded from a configuration file, so that errors can name the file.
+//
+// The loaded resolver itself is what's passed to ResolverErrorHandler() and recorded in Path.Resolver.
+type fileResolver struct {
+	Resolver
+	path string
+}
+
+// A fileResolver for a BatchResolver, so that it's still resolved in batches.
+type fileBatchResolver struct {
+	*fileResolver
+}
+
+func (f *fileBatchResolver) ResolveBatch(context *Context, parent *Path, flags []*Flag) (map[string]interface{}, error) { // nolint: revive
+	return f.Resolver.(BatchResolver).ResolveBatch(context, parent, flags)
+}
+
+func newFileResolver(resolver Resolver, path string) Resolver {
+	file := &fileResolver{Resolver: resolver, path: path}
+	if _, ok := resolver.(BatchResolver); ok {
+		return &fileBatchResolver{file}
+	}
+	return file
+}
+
+// The resolver loaded by Configuration() and the path of its file, or resolver itself if it wasn't.
+func unwrapFileResolver(resolver Resolver) (Resolver, string) {
+	switch resolver := resolver.(type) {
+	case *fileResolver:
+		return resolver.Resolver, resolver.path
+	case *fileBatchResolver:
+		return resolver.Resolver, resolver.path
+	}
+	return resolver, ""
+}
+
+// ConfigValueError is returned by Context.Resolve() when a resolver supplies a value that can't be
+// mapped to its flag, eg. a string for an integer flag or a scalar for a list.
+type ConfigValueError struct {
+	// Configuration key of the flag, eg. "log_level" for --log-level.
+	Key string
+	// Flag as used on the command-line, eg. "--log-level".
+	Flag string
+	// Configuration file the value came from, if known.
+	File string
+	// Go type of the flag.
+	Expected reflect.Type
+	// Value supplied by the resolver.
+	Value interface{}
+	// Redact is true for flags tagged with redact:"", whose Value is replaced with Redacted in the message.
+	Redact bool
+	// Error returned by the mapper.
+	Err error
+}
+
+func (c *ConfigValueError) Error() string {
+	// Values from other resolvers, eg. environment variables or secret stores, don't have a configuration
+	// key, so they're reported by flag.
+	source := fmt.Sprintf("config key %q", c.Key)
+	switch {
+	case c.File != "":
+		source += " in " + c.File
+	case c.Flag != "":
+		source = c.Flag
+	}
+	// Otherwise the mapper's reason is given, eg. that the value is out of range for the flag's type.
+	var typeErr *json.UnmarshalTypeError
+	if c.Err != nil && !errors.As(c.Err, &typeErr) {
+		reason := strings.TrimPrefix(c.Err.Error(), c.Flag+": ")
+		if c.Redact {
+			reason = strings.ReplaceAll(reason, fmt.Sprintf("%v", c.Value), Redacted)
+		}
+		return fmt.Sprintf("%s: %s", source, reason)
+	}
+	// The value is of the wrong kind altogether, eg. a list for a string flag.
+	value, err := json.Marshal(c.Value)
+	if err != nil {
+		value = []byte(fmt.Sprintf("%v", c.Value))
+	}
+	if c.Redact {
+		value = []byte(Redacted)
+	}
+	return fmt.Sprintf("%s: expected %s but got %s %s", source, c.Expected, configValueType(c.Value), value)
+}
+
+// Unwrap returns the error returned by the mapper.
+func (c *ConfigValueError) Unwrap() error { return c.Err }
+
+// Describe the type of a resolved value in the terms of configuration files.
+func configValueType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	}
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Slice, reflect.Array:
+		return "list"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func newConfigValueError(resolver Resolver, flag *Flag, value interface{}, err error) error {
+	_, file := unwrapFileResolver(resolver)
+	return &ConfigValueError{
+		Key:      configKey(flag),
+		Flag:     flag.ShortSummary(),
+		File:     file,
+		Expected: flag.Target.Type(),
+		Value:    value,
+		Redact:   flag.Tag.Redact,
+		Err:      err,
+	}
+}
+
+// ResolverErrorHandlerFunc decides whether an error returned by a Resolver is fatal.
+//
+// Returning nil downgrades the error, in which case the resolver is skipped for that flag. The returned
+// error otherwise replaces the original error.
+type ResolverErrorHandlerFunc func(ctx *Context, resolver Resolver, flag *Flag, err error) error
+
+// RetryResolver returns a Resolver that retries failed resolutions up to "attempts" times in total.
+//
+// The delay between attempts starts at "backoff" and doubles after each attempt.
+func RetryResolver(resolver Resolver, attempts int, backoff time.Duration) Resolver {
+	return &retryResolver{resolver: resolver, attempts: attempts, backoff: backoff}
+}
+
+type retryResolver struct {
+	resolver Resolver
+	attempts int
+	backoff  time.Duration
+}
+
+func (r *retryResolver) Validate(app *Application) error { return r.resolver.Validate(app) }
+
+func (r *retryResolver) Resolve(context *Context, parent *Path, flag *Flag) (value interface{}, err error) {
+	delay := r.backoff
+	for attempt := 1; ; attempt++ {
+		value, err = r.resolver.Resolve(context, parent, flag)
+		if err == nil || attempt >= r.attempts {
+			return value, err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// FallbackResolver returns a Resolver that tries each resolver in turn until one succeeds.
+//
+// Resolvers are only fallen back from on error, so that eg. a remote resolver can be backed by a local
+// cache. If every resolver fails, the last error is returned.
+func FallbackResolver(resolvers ...Resolver) Resolver {
+	return &fallbackResolver{resolvers: resolvers}
+}
+
+type fallbackResolver struct {
+	resolvers []Resolver
+}
+
+func (f *fallbackResolver) Validate(app *Application) (err error) {
+	for _, resolver := range f.resolvers {
+		if err = resolver.Validate(app); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (f *fallbackResolver) Resolve(context *Context, parent *Path, flag *Flag) (value interface{}, err error) {
+	for _, resolver := range f.resolvers {
+		value, err = resolver.Resolve(context, parent, flag)
+		if err == nil {
+			return value, nil
+		}
+	}
+	return nil, err
+}
+
+// DirectoryResolver returns a Resolver that reads flag values from files in a set of directories, where
+// each file holds the value of one key. This is the layout used by Kubernetes when mounting ConfigMaps and
+// Secrets as volumes.
+//
+// For each flag the files are looked up by flag name (eg. "log-level"), configuration key (eg.
+// "log_level") and, if the flag has one, environment variable name (eg. "LOG_LEVEL"). Directories are
+// searched in order and the first file found is used, with trailing newlines removed. Missing directories
+// and files are ignored.
+//
+// Files are read when the command-line is parsed, so updates to mounted volumes are picked up by
+// subsequent parses.
+func DirectoryResolver(dirs ...string) Resolver {
+	var f ResolverFunc = func(context *Context, parent *Path, flag *Flag) (interface{}, error) {
+		keys := []string{flag.Name, configKey(flag)}
+		if flag.Env != "" {
+			keys = append(keys, flag.Env)
+		}
+		for _, dir := range dirs {
+			dir = ExpandPath(dir)
+			for _, key := range keys {
+				data, err := ioutil.ReadFile(filepath.Join(dir, key))
+				if os.IsNotExist(err) {
+					continue
+				} else if err != nil {
+					return nil, err
+				}
+				return strings.TrimRight(string(data), "\r\n"), nil
+			}
+		}
+		return nil, nil
+	}
+	return f
+}
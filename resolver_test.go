@@ -2,10 +2,13 @@ package kong_test
 
 import (
 	"errors"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -342,3 +345,320 @@ func TestValidatingResolverErrors(t *testing.T) {
 	_, err := mustNew(t, &cli, kong.Resolvers(resolver)).Parse(nil)
 	require.EqualError(t, err, "invalid")
 }
+
+func TestParseWithOverrides(t *testing.T) {
+	type cli struct {
+		Verbose bool
+		Name    string
+		Server  struct {
+			Port int `default:"80"`
+			Host string
+		} `cmd:""`
+	}
+	newParser := func(t *testing.T, c *cli) *kong.Kong {
+		t.Helper()
+		r, err := kong.JSON(strings.NewReader(`{"name": "config", "verbose": false}`))
+		require.NoError(t, err)
+		return mustNew(t, c, kong.Resolvers(r))
+	}
+
+	t.Run("Precedence", func(t *testing.T) {
+		var c cli
+		p := newParser(t, &c)
+		_, err := p.ParseWithOverrides([]string{"--name=cli", "server"}, []string{"verbose=true", "name=override", "server.port=8080"})
+		require.NoError(t, err)
+		require.True(t, c.Verbose)
+		require.Equal(t, "cli", c.Name)
+		require.Equal(t, 8080, c.Server.Port)
+	})
+
+	t.Run("QualifiedKeyWins", func(t *testing.T) {
+		var c cli
+		p := newParser(t, &c)
+		_, err := p.ParseWithOverrides([]string{"server"}, []string{"host=bare", "server.host=qualified"})
+		require.NoError(t, err)
+		require.Equal(t, "qualified", c.Server.Host)
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+		var c cli
+		p := newParser(t, &c)
+		_, err := p.ParseWithOverrides([]string{"server"}, []string{"server.missing=1"})
+		require.EqualError(t, err, `unknown override "server.missing"`)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		var c cli
+		p := newParser(t, &c)
+		_, err := p.ParseWithOverrides([]string{"server"}, []string{"port"})
+		require.EqualError(t, err, `invalid override "port", expected key=value`)
+	})
+}
+
+func TestResolverRetryAndFallback(t *testing.T) {
+	var cli struct {
+		Flag string
+	}
+	failures := 0
+	flaky := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name != "flag" {
+			return nil, nil
+		}
+		if failures < 2 {
+			failures++
+			return nil, errors.New("unavailable")
+		}
+		return "remote", nil
+	})
+	p := mustNew(t, &cli, kong.Resolvers(kong.RetryResolver(flaky, 3, time.Millisecond)))
+	_, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "remote", cli.Flag)
+
+	failing := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		return nil, errors.New("unavailable")
+	})
+	cached := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name != "flag" {
+			return nil, nil
+		}
+		return "cached", nil
+	})
+	p = mustNew(t, &cli, kong.Resolvers(kong.FallbackResolver(kong.RetryResolver(failing, 2, time.Millisecond), cached)))
+	_, err = p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "cached", cli.Flag)
+
+	p = mustNew(t, &cli, kong.Resolvers(kong.FallbackResolver(failing, failing)))
+	_, err = p.Parse(nil)
+	require.EqualError(t, err, "--help: unavailable")
+}
+
+func TestResolverErrorHandler(t *testing.T) {
+	var cli struct {
+		Flag string `default:"default"`
+	}
+	failing := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		return nil, errors.New("unavailable")
+	})
+	warnings := []string{}
+	p := mustNew(t, &cli, kong.Resolvers(failing), kong.ResolverErrorHandler(func(ctx *kong.Context, resolver kong.Resolver, flag *kong.Flag, err error) error {
+		warnings = append(warnings, flag.Name+": "+err.Error())
+		return nil
+	}))
+	_, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "default", cli.Flag)
+	require.Equal(t, []string{"help: unavailable", "flag: unavailable"}, warnings)
+}
+
+func TestContextSource(t *testing.T) {
+	var cli struct {
+		Workers int    `default:"4"`
+		Level   string `env:"KONG_TEST_SOURCE_LEVEL"`
+		Config  string
+		Name    string
+		Unset   string
+		File    string `arg:"" optional:""`
+	}
+	config := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name == "config" {
+			return "from-config", nil
+		}
+		return nil, nil
+	})
+	p, restore := newEnvParser(t, &cli, envMap{"KONG_TEST_SOURCE_LEVEL": "debug"}, kong.Resolvers(config))
+	defer restore()
+	ctx, err := p.Parse([]string{"--name=bob", "file.txt"})
+	require.NoError(t, err)
+
+	require.Equal(t, kong.ValueSource{Kind: kong.SourceDefault}, ctx.Source("workers"))
+	require.Equal(t, kong.ValueSource{Kind: kong.SourceEnv, Env: "KONG_TEST_SOURCE_LEVEL"}, ctx.Source("level"))
+	require.Equal(t, kong.SourceResolver, ctx.Source("config").Kind)
+	require.NotNil(t, ctx.Source("config").Resolver)
+	require.Equal(t, kong.ValueSource{Kind: kong.SourceCommandLine}, ctx.Source("name"))
+	require.Equal(t, kong.ValueSource{Kind: kong.SourceCommandLine}, ctx.Source("file"))
+	require.Equal(t, kong.ValueSource{}, ctx.Source("unset"))
+	require.Equal(t, kong.ValueSource{}, ctx.Source("missing"))
+	require.Equal(t, "command-line", ctx.Source("name").Kind.String())
+}
+
+func TestContextIsSet(t *testing.T) {
+	var cli struct {
+		Workers int `default:"4"`
+		Level   string
+	}
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"--level=debug"})
+	require.NoError(t, err)
+	require.False(t, ctx.IsSet("workers"))
+	require.True(t, ctx.IsSet("level"))
+
+	p = mustNew(t, &cli)
+	ctx, err = p.Parse([]string{"--workers=4"})
+	require.NoError(t, err)
+	require.True(t, ctx.IsSet("workers"))
+	require.Equal(t, 4, cli.Workers)
+}
+
+func TestDirectoryResolver(t *testing.T) {
+	var cli struct {
+		LogLevel string
+		Token    string `env:"API_TOKEN"`
+		Replicas int
+		Region   string `default:"us"`
+	}
+	config := t.TempDir()
+	secrets := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(config, "log-level"), []byte("debug\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(config, "replicas"), []byte("3"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(secrets, "API_TOKEN"), []byte("s3cret\n"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(secrets, "replicas"), []byte("5"), 0600))
+
+	resolver := kong.DirectoryResolver(config, secrets, filepath.Join(config, "missing"))
+	p := mustNew(t, &cli, kong.Resolvers(resolver))
+	_, err := p.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, "debug", cli.LogLevel)
+	require.Equal(t, "s3cret", cli.Token)
+	require.Equal(t, 3, cli.Replicas)
+	require.Equal(t, "us", cli.Region)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(config, "log-level"), []byte("info\n"), 0600))
+	_, err = p.Parse([]string{"--replicas=1"})
+	require.NoError(t, err)
+	require.Equal(t, "info", cli.LogLevel)
+	require.Equal(t, 1, cli.Replicas)
+}
+
+func TestNamedResolver(t *testing.T) {
+	queried := []string{}
+	vault := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		queried = append(queried, flag.Name)
+		return "secret-" + flag.Name, nil
+	})
+	global := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name == "help" {
+			return nil, nil
+		}
+		return "global-" + flag.Name, nil
+	})
+	var cli struct {
+		Token    string `resolver:"vault"`
+		Region   string
+		Database struct {
+			Password string
+		} `embed:"" prefix:"db-" resolver:"vault"`
+		Deploy struct {
+			Key string
+		} `cmd:"" resolver:"vault"`
+	}
+	p := mustNew(t, &cli, kong.Resolvers(global), kong.NamedResolver("vault", vault))
+	_, err := p.Parse([]string{"deploy"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"token", "db-password", "key"}, queried)
+	require.Equal(t, "secret-token", cli.Token)
+	require.Equal(t, "global-region", cli.Region)
+	require.Equal(t, "secret-db-password", cli.Database.Password)
+	require.Equal(t, "secret-key", cli.Deploy.Key)
+
+	_, err = kong.New(&cli)
+	require.EqualError(t, err, `unknown resolver "vault", use kong.NamedResolver("vault", resolver)`)
+}
+
+func TestResolverAggregateErrors(t *testing.T) {
+	var cli struct {
+		One string
+		Two int
+	}
+	failing := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		switch flag.Name {
+		case "one":
+			return nil, errors.New("unavailable")
+		case "two":
+			return "two", nil
+		}
+		return nil, nil
+	})
+	_, err := mustNew(t, &cli, kong.Resolvers(failing)).Parse(nil)
+	require.EqualError(t, err, "--one: unavailable")
+
+	_, err = mustNew(t, &cli, kong.Resolvers(failing), kong.AggregateErrors()).Parse(nil)
+	require.EqualError(t, err, `2 validation errors:
+  - --one: unavailable
+  - --two: expected a valid 64 bit int but got "two"`)
+}
+
+type batchResolver struct {
+	kong.ResolverFunc
+	batches [][]string
+	values  map[string]interface{}
+	err     error
+}
+
+func (b *batchResolver) ResolveBatch(context *kong.Context, parent *kong.Path, flags []*kong.Flag) (map[string]interface{}, error) {
+	names := []string{}
+	for _, flag := range flags {
+		names = append(names, flag.Name)
+	}
+	b.batches = append(b.batches, names)
+	return b.values, b.err
+}
+
+func TestBatchResolver(t *testing.T) {
+	var cli struct {
+		Region string
+		Debug  bool
+		Deploy struct {
+			Replicas int
+			Image    string
+		} `cmd:""`
+	}
+	resolver := &batchResolver{
+		ResolverFunc: func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+			panic("Resolve() should not be called")
+		},
+		values: map[string]interface{}{"region": "eu-west-1", "replicas": 3, "image": "app:1"},
+	}
+	_, err := mustNew(t, &cli, kong.Resolvers(resolver)).Parse([]string{"--debug", "deploy", "--image=app:2"})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"help", "region"}, {"replicas"}}, resolver.batches)
+	require.Equal(t, "eu-west-1", cli.Region)
+	require.Equal(t, 3, cli.Deploy.Replicas)
+	require.Equal(t, "app:2", cli.Deploy.Image)
+}
+
+func TestEnvOnly(t *testing.T) {
+	var cli struct {
+		Addr    string `env:"KONG_ADDR" required:""`
+		Workers int    `env:"KONG_WORKERS" default:"4"`
+		Region  string
+	}
+	resolver := kong.ResolverFunc(func(context *kong.Context, parent *kong.Path, flag *kong.Flag) (interface{}, error) {
+		if flag.Name == "region" {
+			return "us-east", nil
+		}
+		return nil, nil
+	})
+	parser, unsetEnvs := newEnvParser(t, &cli, envMap{"KONG_ADDR": ":8080"}, kong.EnvOnly(), kong.Resolvers(resolver))
+	defer unsetEnvs()
+	_, err := parser.Parse(nil)
+	require.NoError(t, err)
+	require.Equal(t, ":8080", cli.Addr)
+	require.Equal(t, 4, cli.Workers)
+	require.Equal(t, "us-east", cli.Region)
+
+	_, err = parser.Parse([]string{"--addr", ":9090"})
+	require.EqualError(t, err, "unexpected argument --addr, test is configured from the environment only")
+
+	os.Unsetenv("KONG_ADDR")
+	_, err = mustNew(t, &cli, kong.EnvOnly()).Parse(nil)
+	require.EqualError(t, err, "missing values for $KONG_ADDR")
+
+	w := &strings.Builder{}
+	p := mustNew(t, &cli, kong.EnvOnly(), kong.Writers(w, w), kong.Exit(func(int) {}), kong.UsageOnError())
+	_, err = p.Parse(nil)
+	require.Error(t, err)
+	p.FatalIfErrorf(err)
+	require.Equal(t, "Usage: test\n\ntest: error: missing values for $KONG_ADDR\n", w.String())
+}
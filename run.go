@@ -0,0 +1,247 @@
+package kong
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// PanicError is returned by Context.Run() when a Run() method panics and the RecoverPanics() option is set.
+type PanicError struct {
+	// Value passed to panic().
+	Value interface{}
+	// Stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v\n\n%s", p.Value, p.Stack)
+}
+
+// Runner is implemented by commands that are run through the interface rather than by reflecting on
+// their Run() method. See Runners().
+type Runner interface {
+	Run(ctx *Context) error
+}
+
+// Runners requires every leaf command, or one of its ancestors, to implement the Runner interface.
+//
+// This is checked by New(), so that a command that can't be run is a build error rather than a
+// "no Run() method" failure when it is selected.
+func Runners() Option {
+	return PostBuild(func(k *Kong) error {
+		return checkRunners(k.Model.Node)
+	})
+}
+
+func checkRunners(app *Node) error {
+	leaves := app.Leaves(false)
+	if len(leaves) == 0 {
+		leaves = []*Node{app}
+	}
+	for _, leaf := range leaves {
+		runnable := false
+		for node := leaf; node != nil && !runnable; node = node.Parent {
+			_, runnable = runTarget(node).Addr().Interface().(Runner)
+		}
+		if !runnable {
+			return errors.Errorf("%q does not implement kong.Runner", leaf.FullPath())
+		}
+	}
+	return nil
+}
+
+// RequireRunnable checks that every leaf command has a Run() method in its hierarchy, and that the
+// parameters of each Run() method can be satisfied by the bindings available when it is run.
+//
+// This is checked by New(), so values passed to Context.Run() are not known and should be bound with
+// Bind() or similar options instead.
+func RequireRunnable() Option {
+	return PostBuild(func(k *Kong) error {
+		return k.checkRunnable()
+	})
+}
+
+func (k *Kong) checkRunnable() error {
+	// The bindings provided by RunNode(), other than those passed to Run().
+	binds := bindings{}
+	binds.addTo(k.Stdout, (*io.Writer)(nil))
+	binds = binds.add(&bufio.Writer{}, &Context{}, k.vars).merge(k.bindings)
+	if k.runTimeout > 0 {
+		binds.addTo(context.Background(), (*context.Context)(nil))
+	}
+	leaves := k.Model.Leaves(false)
+	if len(leaves) == 0 {
+		leaves = []*Node{k.Model.Node}
+	}
+	for _, leaf := range leaves {
+		leafBinds := binds.clone()
+		for node := leaf; node != nil; node = node.Parent {
+			leafBinds.add(node.Target.Addr().Interface())
+		}
+		found := false
+		for node := leaf; node != nil; node = node.Parent {
+			target := runTarget(node)
+			method := getMethod(target, "Run")
+			if !method.IsValid() {
+				continue
+			}
+			found = true
+			if err := checkRunMethod("Run", target, method, leafBinds); err != nil {
+				return errors.Wrapf(err, "%q is not runnable", leaf.FullPath())
+			}
+		}
+		if !found {
+			return errors.Errorf("%q is not runnable: no Run() method found in its hierarchy", leaf.FullPath())
+		}
+	}
+	return nil
+}
+
+// The value whose Run() method runs node: the slice of invocations of a repeated command, which is run
+// once with all of them, or otherwise its target.
+func runTarget(node *Node) reflect.Value {
+	if node.Repeated {
+		return node.invocations
+	}
+	return node.Target
+}
+
+// CommandUsage describes a completed run of a command, for usage analytics.
+//
+// It deliberately contains no flag or argument values, which may be sensitive.
+type CommandUsage struct {
+	// Selected command, with positional arguments as placeholders, eg. "deploy <service>".
+	Command string
+	// Names of the flags set for the command and its parents, each once, eg. "env". Flags set by resolvers
+	// are included, while those left at their default or set from an environment variable are not.
+	Flags []string
+	// Duration of the Run() methods.
+	Duration time.Duration
+	// Exit status of the run: 0 on success, the result of ExitCode() if the error implements it, or 1.
+	ExitStatus int
+}
+
+// UsageHookFunc is called with the CommandUsage of each run. See UsageHook().
+type UsageHookFunc func(usage CommandUsage)
+
+func (c *Context) reportUsage(start time.Time, err error) {
+	if c.usageHook == nil {
+		return
+	}
+	usage := CommandUsage{Command: c.Command(), Flags: []string{}, Duration: time.Since(start)}
+	seen := map[string]bool{}
+	for _, path := range c.Path {
+		if path.Flag != nil && !seen[path.Flag.Name] {
+			seen[path.Flag.Name] = true
+			usage.Flags = append(usage.Flags, path.Flag.Name)
+		}
+	}
+	usage.ExitStatus = exitStatus(err)
+	c.usageHook(usage)
+}
+
+// Exit status of a run: 0 on success, the result of ExitCode() if err implements it, or 1.
+func exitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	var coder interface{ ExitCode() int }
+	if errors.As(err, &coder) {
+		return coder.ExitCode()
+	}
+	return 1
+}
+
+// The context.Context bound by the application, if any, or context.Background().
+func boundContext(binds bindings) context.Context {
+	if provider, ok := binds[reflect.TypeOf((*context.Context)(nil)).Elem()]; ok {
+		if value, err := provider(); err == nil && value.IsValid() {
+			if ctx, ok := value.Interface().(context.Context); ok {
+				return ctx
+			}
+		}
+	}
+	return context.Background()
+}
+
+// A writer that rejects writes once detached, so that a Run() method still running after RunTimeout() has
+// passed can't write to output its caller has moved on from.
+type detachableWriter struct {
+	mu       sync.Mutex
+	w        io.Writer
+	detached bool
+}
+
+func (d *detachableWriter) Write(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.detached {
+		return 0, errors.New("write after Run() timed out")
+	}
+	return d.w.Write(p)
+}
+
+// Detach the writer, waiting for any write in progress to complete.
+func (d *detachableWriter) detach() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.detached = true
+}
+
+func (d *detachableWriter) isDetached() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.detached
+}
+
+// Apply the RecoverPanics() and RunTimeout() options to run.
+//
+// If run is still running when the timeout passes, output is detached from its caller.
+func (c *Context) guardRun(output *detachableWriter, run func() error) error {
+	if c.recoverPanics {
+		unguarded := run
+		run = func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = &PanicError{Value: r, Stack: debug.Stack()}
+				}
+			}()
+			return unguarded()
+		}
+	}
+	if c.runTimeout <= 0 {
+		return run()
+	}
+	done := make(chan error, 1)
+	panicked := make(chan *PanicError, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicked <- &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+		done <- run()
+	}()
+	timer := time.NewTimer(c.runTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+
+	case r := <-panicked:
+		// Propagate the panic to the caller's goroutine, with the stack of the goroutine it occurred in.
+		panic(r)
+
+	case <-timer.C:
+		output.detach()
+		return errors.Errorf("%s timed out after %s", c.Command(), c.runTimeout)
+	}
+}
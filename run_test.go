@@ -0,0 +1,264 @@
+package kong_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type panicCmd struct{}
+
+func (panicCmd) Run() error { panic("boom") }
+
+type slowCmd struct {
+	written chan error
+}
+
+func (s slowCmd) Run(ctx context.Context, w *bufio.Writer) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("expected a deadline")
+	}
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	fmt.Fprintln(w, "late")
+	s.written <- w.Flush()
+	return ctx.Err()
+}
+
+type runGuardCLI struct {
+	Panic panicCmd `cmd:""`
+	Slow  slowCmd  `cmd:""`
+}
+
+func TestRecoverPanics(t *testing.T) {
+	var cli runGuardCLI
+	p := mustNew(t, &cli, kong.RecoverPanics())
+	ctx, err := p.Parse([]string{"panic"})
+	require.NoError(t, err)
+	err = ctx.Run()
+	var panicErr *kong.PanicError
+	require.True(t, errors.As(err, &panicErr))
+	require.Equal(t, "boom", panicErr.Value)
+	require.Contains(t, err.Error(), "panic: boom\n\ngoroutine")
+
+	p = mustNew(t, &cli)
+	ctx, err = p.Parse([]string{"panic"})
+	require.NoError(t, err)
+	require.PanicsWithValue(t, "boom", func() { _ = ctx.Run() })
+}
+
+func TestRunTimeout(t *testing.T) {
+	cli := runGuardCLI{Slow: slowCmd{written: make(chan error, 1)}}
+	stdout := &bytes.Buffer{}
+	p := mustNew(t, &cli, kong.RunTimeout(10*time.Millisecond), kong.Writers(stdout, stdout))
+	ctx, err := p.Parse([]string{"slow"})
+	require.NoError(t, err)
+	err = ctx.Run()
+	require.EqualError(t, err, "slow timed out after 10ms")
+	// Output written after the timeout is rejected, rather than racing with the caller.
+	require.EqualError(t, <-cli.Slow.written, "write after Run() timed out")
+	require.Empty(t, stdout.String())
+
+	p = mustNew(t, &cli, kong.RunTimeout(10*time.Millisecond))
+	ctx, err = p.Parse([]string{"panic"})
+	require.NoError(t, err)
+	defer func() {
+		panicErr, ok := recover().(*kong.PanicError)
+		require.True(t, ok)
+		require.Equal(t, "boom", panicErr.Value)
+		require.Contains(t, string(panicErr.Stack), "panicCmd.Run")
+	}()
+	_ = ctx.Run()
+	t.Fatal("expected a panic")
+}
+
+type exitCodeError struct{ code int }
+
+func (e exitCodeError) Error() string { return "failed" }
+func (e exitCodeError) ExitCode() int { return e.code }
+
+type failCmd struct{}
+
+func (failCmd) Run() error { return exitCodeError{3} }
+
+type usageCLI struct {
+	Env    string `short:"e"`
+	Dry    bool
+	Deploy struct {
+		Service string `arg:""`
+	} `cmd:""`
+	Fail failCmd `cmd:""`
+}
+
+func (u *usageCLI) Run() error { return nil }
+
+func TestUsageHook(t *testing.T) {
+	var cli usageCLI
+	usages := []kong.CommandUsage{}
+	p := mustNew(t, &cli, kong.UsageHook(func(usage kong.CommandUsage) {
+		usages = append(usages, usage)
+	}))
+	ctx, err := p.Parse([]string{"-e", "prod", "--dry", "deploy", "secret-service"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+
+	ctx, err = p.Parse([]string{"fail"})
+	require.NoError(t, err)
+	require.Error(t, ctx.Run())
+
+	require.Len(t, usages, 2)
+	require.Equal(t, "deploy <service>", usages[0].Command)
+	require.Equal(t, []string{"env", "dry"}, usages[0].Flags)
+	require.Equal(t, 0, usages[0].ExitStatus)
+	require.Equal(t, "fail", usages[1].Command)
+	require.Equal(t, []string{}, usages[1].Flags)
+	require.Equal(t, 3, usages[1].ExitStatus)
+}
+
+func TestAudit(t *testing.T) {
+	var cli struct {
+		usageCLI
+		Token string `env:"AUDIT_TOKEN" redact:""`
+	}
+	t.Setenv("AUDIT_TOKEN", "s3cret")
+	w := &bytes.Buffer{}
+	p := mustNew(t, &cli, kong.Audit(kong.JSONAuditLogger(w)))
+	ctx, err := p.Parse([]string{"-e", "prod", "deploy", "api"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	ctx, err = p.Parse([]string{"fail"})
+	require.NoError(t, err)
+	require.Error(t, ctx.Run())
+
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	require.Len(t, lines, 2)
+	records := make([]kong.AuditRecord, len(lines))
+	for i, line := range lines {
+		require.NoError(t, json.Unmarshal([]byte(line), &records[i]))
+	}
+	require.Equal(t, "deploy <service>", records[0].Command)
+	require.Equal(t, map[string]string{"env": "prod", "token": kong.Redacted}, records[0].Flags)
+	require.Equal(t, 0, records[0].ExitStatus)
+	require.Equal(t, "", records[0].Error)
+	require.Equal(t, "fail", records[1].Command)
+	require.Equal(t, 3, records[1].ExitStatus)
+	require.Equal(t, "failed", records[1].Error)
+	require.NotContains(t, w.String(), "s3cret")
+
+	p = mustNew(t, &cli, kong.Audit(kong.AuditLoggerFunc(func(record *kong.AuditRecord) error {
+		return errors.New("disk full")
+	})))
+	ctx, err = p.Parse([]string{"deploy", "api"})
+	require.NoError(t, err)
+	require.EqualError(t, ctx.Run(), "failed to write audit record: disk full")
+}
+
+type resultStatus struct {
+	Healthy bool
+}
+
+type resultCLI struct {
+	Status struct {
+		Service string `arg:""`
+	} `cmd:""`
+	Invalid invalidResultCmd `cmd:""`
+}
+
+func (r *resultCLI) Run() (*resultStatus, error) {
+	if r.Status.Service == "broken" {
+		return nil, errors.New("broken")
+	}
+	return &resultStatus{Healthy: true}, nil
+}
+
+type invalidResultCmd struct{}
+
+func (invalidResultCmd) Run() (string, bool) { return "", false }
+
+func TestRunResult(t *testing.T) {
+	var cli resultCLI
+	p := mustNew(t, &cli)
+	ctx, err := p.Parse([]string{"status", "api"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	require.Equal(t, &resultStatus{Healthy: true}, ctx.Result)
+
+	ctx, err = p.Parse([]string{"status", "broken"})
+	require.NoError(t, err)
+	require.EqualError(t, ctx.Run(), "broken")
+	require.Nil(t, ctx.Result)
+
+	ctx, err = p.Parse([]string{"invalid"})
+	require.NoError(t, err)
+	require.EqualError(t, ctx.Run(), `return value of kong_test.invalidResultCmd.Run() must be "error" or "(T, error)"`)
+}
+
+type runnerCmd struct {
+	ran bool
+}
+
+func (r *runnerCmd) Run(ctx *kong.Context) error {
+	r.ran = true
+	return nil
+}
+
+func TestRunners(t *testing.T) {
+	var cli struct {
+		Start runnerCmd `cmd:""`
+		Group struct {
+			Stop runnerCmd `cmd:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli, kong.Runners(), kong.Explain())
+	ctx, err := p.Parse([]string{"group", "stop"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	require.True(t, cli.Group.Stop.ran)
+
+	var invalid struct {
+		Start runnerCmd `cmd:""`
+		Group struct {
+			Stop struct{} `cmd:""`
+		} `cmd:""`
+	}
+	_, err = kong.New(&invalid, kong.Name("test"), kong.Runners())
+	require.EqualError(t, err, `"test group stop" does not implement kong.Runner`)
+}
+
+type runnableDB struct{}
+
+type runnableCmd struct{}
+
+func (runnableCmd) Run(db *runnableDB, ctx context.Context) error { return nil }
+
+type unrunnableCLI struct {
+	Start runnableCmd `cmd:""`
+	Stop  struct{}    `cmd:""`
+}
+
+func TestRequireRunnable(t *testing.T) {
+	var cli struct {
+		Start runnableCmd `cmd:""`
+	}
+	_, err := kong.New(&cli, kong.Name("test"), kong.RequireRunnable(),
+		kong.Bind(&runnableDB{}), kong.RunTimeout(time.Second))
+	require.NoError(t, err)
+
+	_, err = kong.New(&cli, kong.Name("test"), kong.RequireRunnable(), kong.Bind(&runnableDB{}))
+	require.EqualError(t, err, `"test start" is not runnable: couldn't find binding of type context.Context for parameter 1 of kong_test.runnableCmd.Run(), use kong.Bind(context.Context)`)
+
+	var unrunnable unrunnableCLI
+	_, err = kong.New(&unrunnable, kong.Name("test"), kong.RequireRunnable(),
+		kong.Bind(&runnableDB{}), kong.RunTimeout(time.Second))
+	require.EqualError(t, err, `"test stop" is not runnable: no Run() method found in its hierarchy`)
+}
@@ -46,18 +46,22 @@ type Token struct {
 }
 
 func (t Token) String() string {
+	if t.Type == EOLToken {
+		return "EOL"
+	}
+	value, ok := t.Value.(string)
+	if !ok {
+		value = fmt.Sprintf("%v", t.Value)
+	}
 	switch t.Type {
 	case FlagToken:
-		return fmt.Sprintf("--%v", t.Value)
+		return "--" + value
 
 	case ShortFlagToken:
-		return fmt.Sprintf("-%v", t.Value)
-
-	case EOLToken:
-		return "EOL"
+		return "-" + value
 
 	default:
-		return fmt.Sprintf("%v", t.Value)
+		return value
 	}
 }
 
@@ -111,21 +115,29 @@ func (t Token) IsValue() bool {
 //
 // 		[{FlagToken, "foo"}, {FlagValueToken, "bar"}]
 type Scanner struct {
+	// Tokens are stored in reverse order, so that the front of the Scanner is the end of the slice. This allows
+	// tokens to be popped and pushed in constant time.
 	args []Token
+	// Applied to string values as they are popped, if non-nil. See Value.Transform.
+	transform TransformFunc
 }
 
 // Scan creates a new Scanner from args with untyped tokens.
 func Scan(args ...string) *Scanner {
-	s := &Scanner{}
-	for _, arg := range args {
-		s.args = append(s.args, Token{Value: arg})
+	s := &Scanner{args: make([]Token, len(args))}
+	for i, arg := range args {
+		s.args[len(args)-1-i] = Token{Value: arg}
 	}
 	return s
 }
 
 // ScanFromTokens creates a new Scanner from a slice of tokens.
 func ScanFromTokens(tokens ...Token) *Scanner {
-	return &Scanner{args: tokens}
+	s := &Scanner{args: make([]Token, len(tokens))}
+	for i, token := range tokens {
+		s.args[len(tokens)-1-i] = token
+	}
+	return s
 }
 
 // Len returns the number of input arguments.
@@ -138,8 +150,13 @@ func (s *Scanner) Pop() Token {
 	if len(s.args) == 0 {
 		return Token{Type: EOLToken}
 	}
-	arg := s.args[0]
-	s.args = s.args[1:]
+	arg := s.args[len(s.args)-1]
+	s.args = s.args[:len(s.args)-1]
+	if s.transform != nil && arg.IsValue() {
+		if value, ok := arg.Value.(string); ok {
+			arg.Value = s.transform(value)
+		}
+	}
 	return arg
 }
 
@@ -171,6 +188,13 @@ func (s *Scanner) PopValueInto(context string, target interface{}) error {
 	if err != nil {
 		return err
 	}
+	// Fast path for the most common case, avoiding a round trip through JSON.
+	if value, ok := t.Value.(string); ok {
+		if target, ok := target.(*string); ok {
+			*target = value
+			return nil
+		}
+	}
 	return jsonTranscode(t.Value, target)
 }
 
@@ -195,7 +219,7 @@ func (s *Scanner) Peek() Token {
 	if len(s.args) == 0 {
 		return Token{Type: EOLToken}
 	}
-	return s.args[0]
+	return s.args[len(s.args)-1]
 }
 
 // Push an untyped Token onto the front of the Scanner.
@@ -212,6 +236,6 @@ func (s *Scanner) PushTyped(arg interface{}, typ TokenType) *Scanner {
 
 // PushToken pushes a preconstructed Token onto the front of the Scanner.
 func (s *Scanner) PushToken(token Token) *Scanner {
-	s.args = append([]Token{token}, s.args...)
+	s.args = append(s.args, token)
 	return s
 }
@@ -0,0 +1,231 @@
+package kong
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A Release is a published version of the application binary for the current platform.
+type Release struct {
+	Version string
+	// URL the binary is downloaded from.
+	URL string
+	// Hex encoded SHA-256 checksum of the binary.
+	Checksum string
+	// Verify is called with the downloaded binary once its checksum has been verified, eg. to check a
+	// signature. It is optional.
+	Verify func(binary []byte) error
+	// HTTP client the binary is downloaded with, defaulting to http.DefaultClient.
+	Client *http.Client
+}
+
+// A ReleaseSource finds the latest Release of the application.
+type ReleaseSource interface {
+	LatestRelease() (*Release, error)
+}
+
+// SelfUpdate adds an "update" command that replaces the running binary with the latest release from
+// source, if it is newer than current.
+//
+// eg.
+//
+//     kong.SelfUpdate(version, &kong.GitHubReleases{
+//         Repo:  "alecthomas/myapp",
+//         Asset: fmt.Sprintf("myapp-%s-%s", runtime.GOOS, runtime.GOARCH),
+//     })
+func SelfUpdate(current string, source ReleaseSource) Option {
+	return OptionFunc(func(k *Kong) error {
+		k.dynamicCommands = append(k.dynamicCommands, &dynamicCommand{
+			name: "update",
+			help: "Update to the latest version.",
+			cmd:  &selfUpdateCmd{current: current, source: source},
+		})
+		return nil
+	})
+}
+
+type selfUpdateCmd struct {
+	Check bool `help:"Only check whether a newer version is available."`
+
+	current string
+	source  ReleaseSource
+}
+
+func (s *selfUpdateCmd) Run(ctx *Context) error {
+	k := ctx.Kong
+	release, err := s.source.LatestRelease()
+	if err != nil {
+		return errors.Wrap(err, "failed to find latest release")
+	}
+	newer, err := versionNewer(release.Version, s.current)
+	if err != nil {
+		return errors.Wrapf(err, "can't compare release %s with the current version %s", release.Version, s.current)
+	}
+	if !newer {
+		fmt.Fprintf(k.Stdout, "%s is up to date (version %s)\n", k.Model.Name, s.current)
+		return nil
+	}
+	if s.Check {
+		fmt.Fprintf(k.Stdout, "A new version of %s is available: %s (current version is %s)\n", k.Model.Name, release.Version, s.current)
+		return nil
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := release.Install(path); err != nil {
+		return err
+	}
+	fmt.Fprintf(k.Stdout, "Updated %s from %s to %s\n", k.Model.Name, s.current, release.Version)
+	return nil
+}
+
+// Install downloads the release, verifies it and atomically replaces the binary at path.
+func (r *Release) Install(path string) error {
+	if r.Checksum == "" {
+		return errors.Errorf("release %s has no checksum", r.Version)
+	}
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	binary, err := httpGet(client, r.URL)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(binary)
+	if actual := hex.EncodeToString(sum[:]); !strings.EqualFold(actual, r.Checksum) {
+		return errors.Errorf("checksum mismatch for %s: expected %s but got %s", r.URL, r.Checksum, actual)
+	}
+	if r.Verify != nil {
+		if err := r.Verify(binary); err != nil {
+			return errors.Wrapf(err, "failed to verify %s", r.URL)
+		}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// Write alongside the existing binary so the rename is atomic.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint: errcheck
+	if _, err := tmp.Write(binary); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return replaceBinary(tmp.Name(), path)
+}
+
+// GitHubReleases is a ReleaseSource for the latest release of a GitHub repository.
+//
+// The checksum of Asset is looked up in ChecksumAsset, a file of "<sha256>  <name>" lines as produced
+// by sha256sum and goreleaser. As the checksums are published with the release, they only check the
+// integrity of the download; set Verify to check its authenticity, eg. against a signature.
+type GitHubReleases struct {
+	// Repository in the form "<owner>/<name>".
+	Repo string
+	// Name of the release asset for the current platform.
+	Asset string
+	// Name of the release asset containing checksums, defaulting to "checksums.txt".
+	ChecksumAsset string
+	// Base URL of the GitHub API, defaulting to "https://api.github.com".
+	API string
+	// HTTP client, defaulting to http.DefaultClient.
+	Client *http.Client
+	// Verify is copied to the Release. See Release.Verify.
+	Verify func(binary []byte) error
+}
+
+// LatestRelease finds the latest release of the repository.
+func (g *GitHubReleases) LatestRelease() (*Release, error) {
+	api := g.API
+	if api == "" {
+		api = "https://api.github.com"
+	}
+	checksumAsset := g.ChecksumAsset
+	if checksumAsset == "" {
+		checksumAsset = "checksums.txt"
+	}
+	client := g.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	data, err := httpGet(client, strings.TrimSuffix(api, "/")+"/repos/"+g.Repo+"/releases/latest")
+	if err != nil {
+		return nil, err
+	}
+	latest := struct {
+		TagName string `json:"tag_name"`
+		Assets  []struct {
+			Name string `json:"name"`
+			URL  string `json:"browser_download_url"`
+		} `json:"assets"`
+	}{}
+	if err := json.Unmarshal(data, &latest); err != nil {
+		return nil, errors.Wrapf(err, "invalid release of %s", g.Repo)
+	}
+	release := &Release{Version: latest.TagName, Client: client, Verify: g.Verify}
+	checksumURL := ""
+	for _, asset := range latest.Assets {
+		switch asset.Name {
+		case g.Asset:
+			release.URL = asset.URL
+		case checksumAsset:
+			checksumURL = asset.URL
+		}
+	}
+	if release.URL == "" {
+		return nil, errors.Errorf("release %s of %s has no asset %q", release.Version, g.Repo, g.Asset)
+	}
+	if checksumURL == "" {
+		return nil, errors.Errorf("release %s of %s has no asset %q", release.Version, g.Repo, checksumAsset)
+	}
+	checksums, err := httpGet(client, checksumURL)
+	if err != nil {
+		return nil, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == g.Asset {
+			release.Checksum = fields[0]
+		}
+	}
+	if release.Checksum == "" {
+		return nil, errors.Errorf("no checksum for %q in %s", g.Asset, checksumAsset)
+	}
+	return release, nil
+}
+
+func httpGet(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("%s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, 1<<30))
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package kong
+
+import "os"
+
+// Replace the binary at path with src.
+func replaceBinary(src, path string) error {
+	return os.Rename(src, path)
+}
@@ -0,0 +1,159 @@
+package kong_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func newReleaseServer(t *testing.T, binary string) *httptest.Server {
+	t.Helper()
+	sum := sha256.Sum256([]byte(binary))
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/app/releases/latest":
+			fmt.Fprintf(w, `{"tag_name": "v1.1.0", "assets": [
+				{"name": "app-linux-amd64", "browser_download_url": "%[1]s/app-linux-amd64"},
+				{"name": "checksums.txt", "browser_download_url": "%[1]s/checksums.txt"}
+			]}`, srv.URL)
+		case "/app-linux-amd64":
+			fmt.Fprint(w, binary)
+		case "/checksums.txt":
+			fmt.Fprintf(w, "%s  app-linux-amd64\n%s  app-darwin-arm64\n", hex.EncodeToString(sum[:]), "abc123")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSelfUpdateGitHubReleases(t *testing.T) {
+	srv := newReleaseServer(t, "new binary")
+	verified := ""
+	source := &kong.GitHubReleases{Repo: "owner/app", Asset: "app-linux-amd64", API: srv.URL,
+		Verify: func(binary []byte) error {
+			verified = string(binary)
+			return nil
+		}}
+	release, err := source.LatestRelease()
+	require.NoError(t, err)
+	require.Equal(t, "v1.1.0", release.Version)
+	require.Equal(t, srv.URL+"/app-linux-amd64", release.URL)
+
+	path := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, ioutil.WriteFile(path, []byte("old binary"), 0700))
+	require.NoError(t, release.Install(path))
+	require.Equal(t, "new binary", verified)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "new binary", string(data))
+
+	release.Verify = func(binary []byte) error { return errors.New("bad signature") }
+	err = release.Install(path)
+	require.EqualError(t, err, "failed to verify "+srv.URL+"/app-linux-amd64: bad signature")
+
+	release.Checksum = "abc123"
+	err = release.Install(path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "checksum mismatch")
+
+	source.Asset = "app-windows-amd64"
+	_, err = source.LatestRelease()
+	require.EqualError(t, err, `release v1.1.0 of owner/app has no asset "app-windows-amd64"`)
+}
+
+type staticReleaseSource kong.Release
+
+func (s staticReleaseSource) LatestRelease() (*kong.Release, error) {
+	release := kong.Release(s)
+	return &release, nil
+}
+
+func TestSelfUpdateCommand(t *testing.T) {
+	var cli struct{}
+	stdout := &bytes.Buffer{}
+	p := mustNew(t, &cli, kong.Writers(stdout, stdout), kong.SelfUpdate("v1.0.0", staticReleaseSource{Version: "v1.1.0"}))
+	ctx, err := p.Parse([]string{"update", "--check"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	require.Equal(t, "A new version of test is available: v1.1.0 (current version is v1.0.0)\n", stdout.String())
+
+	stdout.Reset()
+	p = mustNew(t, &cli, kong.Writers(stdout, stdout), kong.SelfUpdate("v1.1.0", staticReleaseSource{Version: "v1.1.0"}))
+	ctx, err = p.Parse([]string{"update"})
+	require.NoError(t, err)
+	require.NoError(t, ctx.Run())
+	require.Equal(t, "test is up to date (version v1.1.0)\n", stdout.String())
+}
+
+func TestSelfUpdateVersionPrecedence(t *testing.T) {
+	var cli struct{}
+	for _, test := range []struct {
+		current string
+		latest  string
+		newer   bool
+		err     string
+	}{
+		{current: "v1.2.3-dirty", latest: "v1.2.0"},
+		{current: "v1.2.0", latest: "v1.2.0-rc.1"},
+		{current: "v1.2.0-rc.1", latest: "v1.2.0", newer: true},
+		{current: "v1.2.0-rc.2", latest: "v1.2.0-rc.10", newer: true},
+		{current: "v1.2.0-alpha", latest: "v1.2.0-alpha.1", newer: true},
+		{current: "v1.2.0-1", latest: "v1.2.0-alpha", newer: true},
+		{current: "v1.2.0", latest: "v1.2.0+build.5"},
+		{current: "v1.2", latest: "v1.10.0", newer: true},
+		{current: "dev", latest: "v1.2.0", err: `can't compare release v1.2.0 with the current version dev: invalid version "dev"`},
+		{current: "v1.2.0", latest: "nightly", err: `can't compare release nightly with the current version v1.2.0: invalid version "nightly"`},
+	} {
+		t.Run(test.current+" "+test.latest, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			p := mustNew(t, &cli, kong.Writers(stdout, stdout), kong.SelfUpdate(test.current, staticReleaseSource{Version: test.latest}))
+			ctx, err := p.Parse([]string{"update", "--check"})
+			require.NoError(t, err)
+			err = ctx.Run()
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.newer, strings.HasPrefix(stdout.String(), "A new version"), stdout.String())
+		})
+	}
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestSelfUpdateUsesClient(t *testing.T) {
+	srv := newReleaseServer(t, "new binary")
+	transport := &countingTransport{}
+	source := &kong.GitHubReleases{Repo: "owner/app", Asset: "app-linux-amd64", API: srv.URL, Client: &http.Client{Transport: transport}}
+	release, err := source.LatestRelease()
+	require.NoError(t, err)
+	require.Equal(t, 2, transport.requests)
+
+	path := filepath.Join(t.TempDir(), "app")
+	require.NoError(t, ioutil.WriteFile(path, []byte("old binary"), 0700))
+	require.NoError(t, release.Install(path))
+	require.Equal(t, 3, transport.requests)
+}
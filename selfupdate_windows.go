@@ -0,0 +1,21 @@
+//go:build windows
+// +build windows
+
+package kong
+
+import "os"
+
+// Replace the binary at path with src. A running executable can't be replaced on Windows, but it can be
+// renamed, so it's moved aside first and left to be removed by the next update.
+func replaceBinary(src, path string) error {
+	old := path + ".old"
+	_ = os.Remove(old)
+	if err := os.Rename(path, old); err != nil {
+		return err
+	}
+	if err := os.Rename(src, path); err != nil {
+		_ = os.Rename(old, path)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package kong
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+)
+
+// GrammarOverride is the metadata of a command, argument or flag in a grammar sidecar file, which
+// overrides its struct tags. Empty fields leave the tags unchanged.
+type GrammarOverride struct {
+	Help        string `json:"help,omitempty" yaml:"help,omitempty"`
+	Detail      string `json:"detail,omitempty" yaml:"detail,omitempty"`           // Commands and arguments only.
+	Default     string `json:"default,omitempty" yaml:"default,omitempty"`         // Flags and positional arguments only.
+	Enum        string `json:"enum,omitempty" yaml:"enum,omitempty"`               // Flags and positional arguments only.
+	Group       string `json:"group,omitempty" yaml:"group,omitempty"`             // Commands and flags only.
+	PlaceHolder string `json:"placeholder,omitempty" yaml:"placeholder,omitempty"` // Flags only.
+}
+
+// A SidecarDecoder decodes the contents of a grammar sidecar file into v, eg. json.Unmarshal or
+// yaml.Unmarshal.
+type SidecarDecoder func(data []byte, v interface{}) error
+
+// GrammarSidecar overrides the help, defaults, enums, groups and placeholders of the grammar with those in
+// a sidecar file, so they can be maintained outside of the Go source, eg. by technical writers.
+//
+// The file is read from fsys, typically an embed.FS, and decoded into a map of GrammarOverride keyed by
+// the dotted path of command, argument and flag names, eg. in YAML:
+//
+//     debug:
+//       help: Enable debug logging.
+//     serve:
+//       help: Start the server.
+//     serve.port:
+//       help: Port to listen on.
+//       default: "8080"
+//
+// Overrides are applied before interpolation, so may refer to variables such as ${default}. Paths that
+// don't exist in the grammar are an error.
+func GrammarSidecar(fsys fs.FS, path string, decode SidecarDecoder) Option {
+	return PostBuild(func(k *Kong) error {
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+		overrides := map[string]GrammarOverride{}
+		if err := decode(data, &overrides); err != nil {
+			return fmt.Errorf("%s: %s", path, err)
+		}
+		targets := map[string]Visitable{}
+		collectSidecarTargets(k.Model.Node, "", targets)
+		keys := make([]string, 0, len(overrides))
+		for key := range overrides {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			target, ok := targets[key]
+			if !ok {
+				return fmt.Errorf("%s: unknown command, argument or flag %q", path, key)
+			}
+			if err := applyGrammarOverride(k, target, overrides[key]); err != nil {
+				return fmt.Errorf("%s: %s: %s", path, key, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Collect the commands, arguments and flags under node by their dotted paths.
+func collectSidecarTargets(node *Node, prefix string, targets map[string]Visitable) {
+	add := func(name string, target Visitable) {
+		if _, ok := targets[prefix+name]; !ok {
+			targets[prefix+name] = target
+		}
+	}
+	for _, flag := range node.Flags {
+		add(flag.Name, flag)
+	}
+	for _, positional := range node.Positional {
+		add(positional.Name, positional)
+	}
+	for _, child := range node.Children {
+		add(child.Name, child)
+		collectSidecarTargets(child, prefix+child.Name+".", targets)
+	}
+}
+
+func applyGrammarOverride(k *Kong, target Visitable, override GrammarOverride) error {
+	unsupported := []string{}
+	check := func(field, value string, supported bool) {
+		if value != "" && !supported {
+			unsupported = append(unsupported, field)
+		}
+	}
+	switch target := target.(type) {
+	case *Node:
+		check("default", override.Default, target.Type == ArgumentNode)
+		check("enum", override.Enum, target.Type == ArgumentNode)
+		check("placeholder", override.PlaceHolder, false)
+		if len(unsupported) > 0 {
+			break
+		}
+		if override.Help != "" {
+			target.Help = override.Help
+		}
+		if override.Detail != "" {
+			target.Detail = override.Detail
+		}
+		if override.Group != "" {
+			target.Group = buildGroupForKey(k, override.Group)
+		}
+		if target.Argument != nil {
+			if override.Help != "" {
+				target.Argument.Help = override.Help
+			}
+			overrideValue(target.Argument, override)
+		}
+
+	case *Flag:
+		check("detail", override.Detail, false)
+		if len(unsupported) > 0 {
+			break
+		}
+		if override.Help != "" {
+			target.Help = override.Help
+		}
+		if override.Group != "" {
+			target.Group = buildGroupForKey(k, override.Group)
+		}
+		if override.PlaceHolder != "" {
+			target.PlaceHolder = override.PlaceHolder
+		}
+		overrideValue(target.Value, override)
+
+	case *Value:
+		check("detail", override.Detail, false)
+		check("group", override.Group, false)
+		check("placeholder", override.PlaceHolder, false)
+		if len(unsupported) > 0 {
+			break
+		}
+		if override.Help != "" {
+			target.Help = override.Help
+		}
+		overrideValue(target, override)
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%s can't be set here", strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
+func overrideValue(value *Value, override GrammarOverride) {
+	if override.Default != "" {
+		value.Default = override.Default
+	}
+	if override.Enum != "" {
+		value.Enum = override.Enum
+	}
+}
@@ -0,0 +1,67 @@
+package kong_test
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestGrammarSidecar(t *testing.T) {
+	var cli struct {
+		Debug bool `help:"Debug."`
+
+		Serve struct {
+			Port   int    `help:"Port."`
+			Format string `enum:"json,text" default:"json"`
+			Root   string `arg:"" optional:""`
+		} `cmd:"" help:"Serve."`
+	}
+	fsys := fstest.MapFS{"help.json": {Data: []byte(`{
+		"debug": {"help": "Enable debug logging."},
+		"serve": {"help": "Start the server.", "detail": "Serves files over HTTP.", "group": "Server"},
+		"serve.port": {"help": "Port to listen on (default: ${default}).", "default": "8080", "placeholder": "PORT"},
+		"serve.format": {"enum": "json,text,html"},
+		"serve.root": {"help": "Directory to serve.", "default": "."}
+	}`)}}
+	p := mustNew(t, &cli, kong.GrammarSidecar(fsys, "help.json", json.Unmarshal))
+	_, err := p.Parse([]string{"serve", "--format=html"})
+	require.NoError(t, err)
+	require.Equal(t, 8080, cli.Serve.Port)
+	require.Equal(t, "html", cli.Serve.Format)
+	require.Equal(t, ".", cli.Serve.Root)
+
+	require.Equal(t, "Enable debug logging.", p.Model.Flags[1].Help)
+	serve := p.Model.Children[0]
+	require.Equal(t, "Start the server.", serve.Help)
+	require.Equal(t, "Serves files over HTTP.", serve.Detail)
+	require.Equal(t, "Server", serve.Group.Key)
+	require.Equal(t, "Port to listen on (default: 8080).", serve.Flags[0].Help)
+	require.Equal(t, "PORT", serve.Flags[0].PlaceHolder)
+	require.Equal(t, "Directory to serve.", serve.Positional[0].Help)
+}
+
+func TestGrammarSidecarErrors(t *testing.T) {
+	var cli struct {
+		Debug bool
+		Serve struct {
+			Root string `arg:""`
+		} `cmd:""`
+	}
+	for _, test := range []struct {
+		sidecar string
+		err     string
+	}{
+		{`{"serve.prot": {"help": "Port."}}`, `help.json: unknown command, argument or flag "serve.prot"`},
+		{`{"serve": {"default": "x", "placeholder": "X"}}`, `help.json: serve: default, placeholder can't be set here`},
+		{`{"serve.root": {"group": "Server"}}`, `help.json: serve.root: group can't be set here`},
+		{`{"debug": `, `help.json: unexpected end of JSON input`},
+	} {
+		fsys := fstest.MapFS{"help.json": {Data: []byte(test.sidecar)}}
+		_, err := kong.New(&cli, kong.GrammarSidecar(fsys, "help.json", json.Unmarshal))
+		require.EqualError(t, err, test.err, test.sidecar)
+	}
+}
@@ -0,0 +1,182 @@
+package kong
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// A Snapshot is a serialisable record of a parse result: the selected command, and the value and source
+// of each flag and positional argument that was set. It allows queued or asynchronous executors to
+// persist what the user asked for, and run it later with Kong.Restore().
+type Snapshot struct {
+	// Names of the selected commands, and of branching arguments as "<name>", from the root.
+	Command []string `json:"command"`
+	// Values that were set, in the order they were applied.
+	Values []SnapshotValue `json:"values"`
+}
+
+// A SnapshotValue is the value of a flag or positional argument in a Snapshot.
+type SnapshotValue struct {
+	// Name of the value, as "--name" for flags and "<name>" for positional and branching arguments.
+	Name   string          `json:"name"`
+	Value  json.RawMessage `json:"value"`
+	Source ValueSourceKind `json:"source"`
+	// Environment variable the value came from, if Source is SourceEnv.
+	Env string `json:"env,omitempty"`
+}
+
+// Snapshot records the selected command and the values of its flags and positional arguments.
+//
+// Values are encoded with encoding/json, so they must round-trip through it. Resolvers are recorded as
+// the source of a value, but not which resolver it was.
+//
+//     snapshot, err := ctx.Snapshot()
+//     data, err := json.Marshal(snapshot)
+func (c *Context) Snapshot() (*Snapshot, error) {
+	snapshot := &Snapshot{Command: []string{}, Values: []SnapshotValue{}}
+	values := []*Value{}
+	for _, path := range c.Path {
+		switch {
+		case path.Command != nil:
+			if path.Command.Repeated {
+				return nil, errors.Errorf("can't snapshot repeated command %s", path.Command.Name)
+			}
+			snapshot.Command = append(snapshot.Command, path.Command.Name)
+		case path.Argument != nil:
+			snapshot.Command = append(snapshot.Command, "<"+path.Argument.Name+">")
+			values = append(values, path.Argument.Argument)
+		}
+		if node := path.Node(); node != nil {
+			values = append(values, node.Positional...)
+		}
+	}
+	for _, flag := range c.Flags() {
+		values = append(values, flag.Value)
+	}
+	for _, value := range values {
+		source := c.valueSource(value)
+		if source.Kind == SourceUnset || (value.Flag != nil && isActionFlag(value.Flag)) {
+			continue
+		}
+		data, err := json.Marshal(value.Target.Interface())
+		if err != nil {
+			return nil, errors.Wrapf(err, "can't snapshot %s", value.ShortSummary())
+		}
+		snapshot.Values = append(snapshot.Values, SnapshotValue{
+			Name:   snapshotName(value),
+			Value:  data,
+			Source: source.Kind,
+			Env:    source.Env,
+		})
+	}
+	return snapshot, nil
+}
+
+// Restore a Context from a Snapshot, as if the command-line it was taken from had been parsed again.
+//
+// The recorded values are applied as is, rather than being resolved afresh from environment variables,
+// defaults and resolvers, and are validated. Hooks are not called, so anything bound by them must be
+// bound again before calling Context.Run().
+//
+//     ctx, err := parser.Restore(snapshot)
+//     err = ctx.Run()
+func (k *Kong) Restore(snapshot *Snapshot) (*Context, error) {
+	ctx := &Context{
+		Kong:     k,
+		Path:     []*Path{{App: k.Model, Flags: k.Model.Flags}},
+		values:   map[*Value]reflect.Value{},
+		scan:     Scan(),
+		bindings: bindings{},
+
+		repeatBaselines: map[*Node]reflect.Value{},
+	}
+	if err := ctx.Reset(); err != nil {
+		return nil, err
+	}
+	node := k.Model.Node
+	for _, name := range snapshot.Command {
+		child := snapshotChild(node, name)
+		if child == nil {
+			return nil, errors.Errorf("unknown command %q in snapshot", name)
+		}
+		path := &Path{Parent: node, Flags: child.Flags}
+		if child.Type == ArgumentNode {
+			path.Argument = child
+		} else {
+			path.Command = child
+		}
+		ctx.Path = append(ctx.Path, path)
+		node = child
+	}
+	for _, recorded := range snapshot.Values {
+		value, parent := ctx.snapshotValue(recorded.Name)
+		if value == nil {
+			return nil, errors.Errorf("unknown value %s in snapshot", recorded.Name)
+		}
+		target := reflect.New(value.Target.Type())
+		if err := json.Unmarshal(recorded.Value, target.Interface()); err != nil {
+			return nil, errors.Wrapf(err, "invalid value of %s in snapshot", recorded.Name)
+		}
+		switch {
+		case recorded.Source == SourceEnv || recorded.Source == SourceDefault:
+			value.Apply(target.Elem())
+			ctx.sources[value] = ValueSource{Kind: recorded.Source, Env: recorded.Env}
+		case value.Flag != nil:
+			ctx.values[value] = target.Elem()
+			ctx.Path = append(ctx.Path, &Path{Parent: parent, Flag: value.Flag, Resolved: recorded.Source == SourceResolver})
+		case parent.Argument == value:
+			ctx.values[value] = target.Elem()
+		default:
+			ctx.values[value] = target.Elem()
+			ctx.Path = append(ctx.Path, &Path{Parent: parent, Positional: value})
+		}
+	}
+	if _, err := ctx.Apply(); err != nil {
+		return nil, err
+	}
+	if err := ctx.Validate(); err != nil {
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func snapshotName(value *Value) string {
+	if value.Flag != nil {
+		return "--" + value.Name
+	}
+	return "<" + value.Name + ">"
+}
+
+func snapshotChild(node *Node, name string) *Node {
+	for _, child := range node.Children {
+		if (child.Type == CommandNode && child.Name == name) || (child.Type == ArgumentNode && "<"+child.Name+">" == name) {
+			return child
+		}
+	}
+	return nil
+}
+
+// Find a value in the selected command path by its name in a Snapshot, and the node it belongs to.
+func (c *Context) snapshotValue(name string) (*Value, *Node) {
+	for _, path := range c.Path {
+		node := path.Node()
+		if node == nil {
+			continue
+		}
+		values := append([]*Value{}, node.Positional...)
+		if node.Argument != nil {
+			values = append(values, node.Argument)
+		}
+		for _, flag := range node.Flags {
+			values = append(values, flag.Value)
+		}
+		for _, value := range values {
+			if snapshotName(value) == name {
+				return value, node
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,60 @@
+package kong_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+type snapshotCLI struct {
+	Debug  bool     `env:"SNAPSHOT_DEBUG"`
+	Region string   `default:"us-east-1"`
+	Tags   []string `help:"Tags."`
+	Deploy struct {
+		Env struct {
+			Env      string   `arg:""`
+			Services []string `arg:"" optional:""`
+			Replicas int      `required:""`
+		} `arg:""`
+	} `cmd:""`
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	t.Setenv("SNAPSHOT_DEBUG", "true")
+	var cli snapshotCLI
+	ctx, err := mustNew(t, &cli).Parse([]string{"deploy", "prod", "api", "web", "--replicas=3", "--tags=a,b"})
+	require.NoError(t, err)
+	command := ctx.Command()
+	snapshot, err := ctx.Snapshot()
+	require.NoError(t, err)
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"command": ["deploy", "<env>"],
+		"values": [
+			{"name": "<env>", "value": "prod", "source": "command-line"},
+			{"name": "<services>", "value": ["api", "web"], "source": "command-line"},
+			{"name": "--debug", "value": true, "source": "env", "env": "SNAPSHOT_DEBUG"},
+			{"name": "--region", "value": "us-east-1", "source": "default"},
+			{"name": "--tags", "value": ["a", "b"], "source": "command-line"},
+			{"name": "--replicas", "value": 3, "source": "command-line"}
+		]
+	}`, string(data))
+
+	t.Setenv("SNAPSHOT_DEBUG", "")
+	restored := &kong.Snapshot{}
+	require.NoError(t, json.Unmarshal(data, restored))
+	var fresh snapshotCLI
+	ctx, err = mustNew(t, &fresh).Restore(restored)
+	require.NoError(t, err)
+	require.Equal(t, cli, fresh)
+	require.Equal(t, command, ctx.Command())
+	require.Equal(t, kong.SourceEnv, ctx.Source("debug").Kind)
+	require.Equal(t, kong.SourceCommandLine, ctx.Source("replicas").Kind)
+
+	_, err = mustNew(t, &fresh).Restore(&kong.Snapshot{Command: []string{"destroy"}})
+	require.EqualError(t, err, `unknown command "destroy" in snapshot`)
+}
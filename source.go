@@ -0,0 +1,117 @@
+package kong
+
+import "fmt"
+
+// ValueSourceKind describes where the value of a flag or positional argument came from.
+type ValueSourceKind int
+
+// Sources of values, in increasing order of precedence.
+const (
+	// SourceUnset is a value that was never set, and is the zero value.
+	SourceUnset ValueSourceKind = iota
+	// SourceDefault is a value from the "default" tag.
+	SourceDefault
+	// SourceEnv is a value from an environment variable.
+	SourceEnv
+	// SourceResolver is a value from a Resolver, such as a configuration file.
+	SourceResolver
+	// SourceCommandLine is a value explicitly set on the command-line.
+	SourceCommandLine
+)
+
+func (v ValueSourceKind) String() string {
+	switch v {
+	case SourceDefault:
+		return "default"
+	case SourceEnv:
+		return "env"
+	case SourceResolver:
+		return "resolver"
+	case SourceCommandLine:
+		return "command-line"
+	default:
+		return "unset"
+	}
+}
+
+// MarshalText encodes the kind as its name, eg. "command-line".
+func (v ValueSourceKind) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText decodes a kind from its name.
+func (v *ValueSourceKind) UnmarshalText(text []byte) error {
+	for kind := SourceUnset; kind <= SourceCommandLine; kind++ {
+		if kind.String() == string(text) {
+			*v = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown value source %q", text)
+}
+
+// ValueSource describes where a value came from.
+type ValueSource struct {
+	Kind ValueSourceKind
+	// Environment variable the value came from, if Kind is SourceEnv.
+	Env string
+	// Resolver the value came from, if Kind is SourceResolver.
+	Resolver Resolver
+}
+
+// Source returns where the value of the flag or positional argument called "name" came from.
+//
+// Only flags and positional arguments in the selected command path are considered. Unknown names are
+// reported as SourceUnset.
+func (c *Context) Source(name string) ValueSource {
+	value := c.findValue(name)
+	if value == nil {
+		return ValueSource{}
+	}
+	return c.valueSource(value)
+}
+
+func (c *Context) valueSource(value *Value) ValueSource {
+	// Later path elements take precedence, as they are applied last.
+	for i := len(c.Path) - 1; i >= 0; i-- {
+		path := c.Path[i]
+		switch {
+		case path.Flag != nil && path.Flag.Value == value:
+			if path.Resolved {
+				return ValueSource{Kind: SourceResolver, Resolver: path.Resolver}
+			}
+			return ValueSource{Kind: SourceCommandLine}
+
+		case path.Positional == value, path.Argument != nil && path.Argument.Argument == value:
+			return ValueSource{Kind: SourceCommandLine}
+		}
+	}
+	return c.sources[value]
+}
+
+// IsSet returns true if the flag or positional argument called "name" was explicitly set, on the command-line,
+// by an environment variable or by a resolver, rather than left at its default.
+//
+// This differs from Value.Set, which is also true for values set from their default.
+func (c *Context) IsSet(name string) bool {
+	return c.Source(name).Kind > SourceDefault
+}
+
+// Find a flag or positional argument by name in the selected command path.
+func (c *Context) findValue(name string) *Value {
+	for _, flag := range c.Flags() {
+		if flag.Name == name {
+			return flag.Value
+		}
+	}
+	for _, path := range c.Path {
+		if node := path.Node(); node != nil {
+			for _, positional := range node.Positional {
+				if positional.Name == name {
+					return positional
+				}
+			}
+		}
+	}
+	return nil
+}
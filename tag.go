@@ -25,21 +25,45 @@ type Tag struct {
 	Env         string
 	Short       rune
 	Hidden      bool
+	Internal    bool
 	Sep         rune
 	MapSep      rune
 	Enum        string
 	Group       string
+	Category    string
 	Xor         []string
+	And         []string
 	Vars        Vars
 	Prefix      string // Optional prefix on anonymous structs. All sub-flags will have this prefix.
 	EnvPrefix   string
 	Embed       bool
 	Aliases     []string
 	Negatable   bool
+	ShortName   string // Multi-character short name, eg. "rf" for -rf. See MultiRuneShortFlags().
+	ShortOnly   bool   // Flag only has a short form, eg. "-x", for re-implementations of tools such as tar.
 	Passthrough bool
+	Tuple       int                    // Number of consecutive values decoded into each struct element of a slice.
+	Presets     map[string]string      // Values of this flag for each named preset.
+	Transforms  []string               // Names of transforms applied to raw values before mapping.
+	Hooks       map[string][]string    // Names of functions registered with NamedHook() to call for each hook, eg. "BeforeApply".
+	Remember    bool                   // Flag defaults to its last explicitly used value.
+	History     bool                   // Values passed on the command-line are recorded for completion. See CompletionHistory().
+	Feature     string                 // Name of the feature that must be enabled for the flag or command to be available.
+	Resolvers   []string               // Names of resolvers registered with NamedResolver() to consult for flags.
+	Use         string                 // Name of the factory registered with RegisterCommandFactory() that creates a command.
+	Unit        string                 // Unit of numeric and duration values, eg. "ms" or "MiB".
+	Redact      bool                   // Value is replaced with Redacted in audit records.
+	Check       string                 // Expression that must hold for the values of the node, eg. "size < 1024".
+	Profiles    map[string]string      // Default values of this flag for each named profile. See Profile().
+	Predictor   string                 // Name of the predictor registered with NamedPredictor() that completes values.
+	Duplicates  string                 // Policy for a flag given more than once: "last", "first" or "error". See DuplicateFlags().
+	Extensions  map[string]interface{} // Values of custom tag keys parsed by extensions registered with TagExtensions().
+	Examples    []string               // Example command-lines of a command, shown in man pages.
 
 	// Storage for all tag keys for arbitrary lookups.
 	items map[string][]string
+	// Set if the enum is implied by an EnumType whose zero value is one of its values, so it needs no default.
+	zeroEnum bool
 }
 
 type tagChars struct {
@@ -154,10 +178,26 @@ func parseTag(parent reflect.Value, ft reflect.StructField) (*Tag, error) {
 	if err != nil {
 		return nil, err
 	}
+	implied, err := conventionalTagItems(ft.Type)
+	if err != nil {
+		return nil, failField(parent, ft, "invalid tags implied by %s: %s", ft.Type, err)
+	}
+	_, explicitEnum := items["enum"]
+	for key, values := range implied {
+		if _, ok := items[key]; !ok {
+			items[key] = values
+		}
+	}
 	t := &Tag{
-		items: items,
+		items:    items,
+		zeroEnum: !explicitEnum && enumZeroValid(ft.Type, implied["enum"]),
+	}
+	// Pointer fields are described by the type they point to.
+	typ := ft.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
 	}
-	err = hydrateTag(t, ft.Type.Name(), ft.Type.Kind() == reflect.Bool)
+	err = hydrateTag(t, typ.Name(), typ.Kind() == reflect.Bool)
 	if err != nil {
 		return nil, failField(parent, ft, "%s", err)
 	}
@@ -168,6 +208,11 @@ func hydrateTag(t *Tag, typeName string, isBool bool) error {
 	var err error
 	t.Cmd = t.Has("cmd")
 	t.Arg = t.Has("arg")
+	t.Use = t.Get("use")
+	// Fields instantiated by a command factory are commands, unless they're branching arguments.
+	if t.Use != "" && !t.Arg {
+		t.Cmd = true
+	}
 	required := t.Has("required")
 	optional := t.Has("optional")
 	if required && optional {
@@ -185,29 +230,81 @@ func hydrateTag(t *Tag, typeName string, isBool bool) error {
 	t.Type = t.Get("type")
 	t.Env = t.Get("env")
 	t.Short, err = t.GetRune("short")
-	if err != nil && t.Get("short") != "" {
-		return fmt.Errorf("invalid short flag name %q: %s", t.Get("short"), err)
+	if short := t.Get("short"); err != nil && short != "" {
+		// Multi-character short names are checked against MultiRuneShortFlags() when the model is built.
+		if !utf8.ValidString(short) || strings.ContainsAny(short, "-= ") {
+			return fmt.Errorf("invalid short flag name %q: %s", short, err)
+		}
+		t.ShortName = short
 	}
-	t.Hidden = t.Has("hidden")
+	t.Internal = t.Has("internal")
+	// Internal flags are hidden from everything, including --help-hidden.
+	t.Hidden = t.Has("hidden") || t.Internal
 	t.Format = t.Get("format")
+	t.Unit = t.Get("unit")
+	t.Redact = t.Has("redact")
+	t.Check = t.Get("check")
+	t.Predictor = t.Get("predictor")
+	t.Duplicates = t.Get("duplicates")
+	if _, ok := duplicatePolicies[t.Duplicates]; t.Duplicates != "" && !ok {
+		return fmt.Errorf("invalid duplicates policy %q, must be one of last, first or error", t.Duplicates)
+	}
 	t.Sep, _ = t.GetSep("sep", ',')
 	t.MapSep, _ = t.GetSep("mapsep", ';')
 	t.Group = t.Get("group")
+	t.Category = t.Get("category")
+	if t.Has("tuple") {
+		tuple, err := t.GetInt("tuple")
+		if err != nil || tuple < 1 {
+			return fmt.Errorf("invalid tuple size %q", t.Get("tuple"))
+		}
+		t.Tuple = int(tuple)
+	}
+	for _, transform := range t.GetAll("transform") {
+		t.Transforms = append(t.Transforms, strings.FieldsFunc(transform, tagSplitFn)...)
+	}
+	t.Hooks = map[string][]string{}
+	for key, hook := range hookTags {
+		for _, names := range t.GetAll(key) {
+			t.Hooks[hook] = append(t.Hooks[hook], strings.FieldsFunc(names, tagSplitFn)...)
+		}
+	}
 	for _, xor := range t.GetAll("xor") {
 		t.Xor = append(t.Xor, strings.FieldsFunc(xor, tagSplitFn)...)
 	}
+	for _, and := range t.GetAll("and") {
+		t.And = append(t.And, strings.FieldsFunc(and, tagSplitFn)...)
+	}
 	t.Prefix = t.Get("prefix")
 	t.EnvPrefix = t.Get("envprefix")
 	t.Embed = t.Has("embed")
+	t.Remember = t.Has("remember")
+	t.History = t.Has("history")
+	t.Feature = t.Get("feature")
+	for _, resolvers := range t.GetAll("resolver") {
+		t.Resolvers = append(t.Resolvers, strings.FieldsFunc(resolvers, tagSplitFn)...)
+	}
 	negatable := t.Has("negatable")
 	if negatable && !isBool {
 		return fmt.Errorf("negatable can only be set on booleans")
 	}
 	t.Negatable = negatable
+	t.ShortOnly = t.Has("shortonly")
+	if t.ShortOnly && t.Short == 0 && t.ShortName == "" {
+		return fmt.Errorf("shortonly requires a short flag name")
+	}
+	if t.ShortOnly && negatable {
+		return fmt.Errorf("negatable can't be combined with shortonly, as there is no long flag to negate")
+	}
 	aliases := t.Get("aliases")
 	if len(aliases) > 0 {
 		t.Aliases = append(t.Aliases, strings.FieldsFunc(aliases, tagSplitFn)...)
 	}
+	for _, example := range t.GetAll("example") {
+		if example != "" {
+			t.Examples = append(t.Examples, example)
+		}
+	}
 	t.Vars = Vars{}
 	for _, set := range t.GetAll("set") {
 		parts := strings.SplitN(set, "=", 2)
@@ -216,17 +313,36 @@ func hydrateTag(t *Tag, typeName string, isBool bool) error {
 		}
 		t.Vars[parts[0]] = parts[1]
 	}
+	t.Presets = map[string]string{}
+	for _, preset := range t.GetAll("preset") {
+		parts := strings.SplitN(preset, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("preset should be in the form name=value but got %q", preset)
+		}
+		t.Presets[parts[0]] = parts[1]
+	}
+	t.Profiles = map[string]string{}
+	for _, profileDefault := range t.GetAll("profile-default") {
+		parts := strings.SplitN(profileDefault, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("profile-default should be in the form profile=value but got %q", profileDefault)
+		}
+		t.Profiles[parts[0]] = parts[1]
+	}
 	t.PlaceHolder = t.Get("placeholder")
-	if t.PlaceHolder == "" {
+	if t.PlaceHolder == "" && t.Unit != "" {
+		t.PlaceHolder = strings.ToUpper(UnitName(t.Unit))
+	} else if t.PlaceHolder == "" {
 		t.PlaceHolder = strings.ToUpper(dashedString(typeName))
 	}
 	t.Enum = t.Get("enum")
-	if t.Enum != "" && !(t.Required || t.Default != "") {
+	// Positional arguments are required unless they're optional, so their enums are always checked.
+	if t.Enum != "" && !(t.Required || t.Default != "" || t.Arg && !t.Optional || t.zeroEnum) {
 		return fmt.Errorf("enum value is only valid if it is either required or has a valid default value")
 	}
 	passthrough := t.Has("passthrough")
-	if passthrough && !t.Arg {
-		return fmt.Errorf("passthrough only makes sense for positional arguments")
+	if passthrough && !t.Arg && !t.Cmd {
+		return fmt.Errorf("passthrough only makes sense for positional arguments and commands")
 	}
 	t.Passthrough = passthrough
 	return nil
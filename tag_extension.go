@@ -0,0 +1,53 @@
+package kong
+
+import (
+	"fmt"
+)
+
+// A TagExtension parses an application-defined tag key, eg. `sensitive:"mask"`.
+//
+// Extensions are registered with TagExtensions(), and parse the key wherever it appears when the model is
+// built, so invalid values are reported by New() rather than when parsing the command-line. The parsed
+// value is stored in Tag.Extensions under the key, where mappers, hooks and help printers can find it
+// through the Tag of a Value or Node.
+type TagExtension interface {
+	// TagKey returns the key parsed by the extension.
+	TagKey() string
+	// ParseTag parses the values of the key in a tag, in the order they appear, returning the value to store
+	// in Tag.Extensions.
+	ParseTag(tag *Tag, values []string) (interface{}, error)
+}
+
+// TagExtensions registers extensions that parse custom tag keys.
+func TagExtensions(extensions ...TagExtension) Option {
+	return OptionFunc(func(k *Kong) error {
+		for _, extension := range extensions {
+			for _, existing := range k.tagExtensions {
+				if existing.TagKey() == extension.TagKey() {
+					return fmt.Errorf("duplicate tag extension %q", extension.TagKey())
+				}
+			}
+			k.tagExtensions = append(k.tagExtensions, extension)
+		}
+		return nil
+	})
+}
+
+// Parse the keys of tag handled by extensions into Tag.Extensions.
+func (k *Kong) parseTagExtensions(tag *Tag) error {
+	for _, extension := range k.tagExtensions {
+		key := extension.TagKey()
+		if !tag.Has(key) {
+			continue
+		}
+		value, err := extension.ParseTag(tag, tag.GetAll(key))
+		if err != nil {
+			return fmt.Errorf("invalid %s tag: %s", key, err)
+		}
+		if tag.Extensions == nil {
+			tag.Extensions = map[string]interface{}{}
+		}
+		tag.Extensions[key] = value
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package kong_test
 
 import (
+	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -200,3 +203,46 @@ func TestInvalidRuneErrors(t *testing.T) {
 	_, err := kong.New(&cli)
 	require.EqualError(t, err, "<anonymous struct>.Flag: invalid short flag name \"invalid\": invalid rune")
 }
+
+// Parses mask:"N", the number of trailing characters of a value left unmasked.
+type maskExtension struct{}
+
+func (maskExtension) TagKey() string { return "mask" }
+
+func (maskExtension) ParseTag(tag *kong.Tag, values []string) (interface{}, error) {
+	if len(values) > 1 {
+		return nil, fmt.Errorf("only one mask can be given")
+	}
+	return strconv.Atoi(values[0])
+}
+
+func TestTagExtensions(t *testing.T) {
+	var cli struct {
+		Token string `mask:"4" type:"masked"`
+		User  string
+	}
+	masked := kong.MapperFunc(func(ctx *kong.DecodeContext, target reflect.Value) error {
+		var value string
+		if err := ctx.Scan.PopValueInto("value", &value); err != nil {
+			return err
+		}
+		keep := ctx.Value.Tag.Extensions["mask"].(int)
+		target.SetString(strings.Repeat("*", len(value)-keep) + value[len(value)-keep:])
+		return nil
+	})
+	p := mustNew(t, &cli, kong.TagExtensions(maskExtension{}), kong.NamedMapper("masked", masked))
+	_, err := p.Parse([]string{"--token=abcdef123456", "--user=bob"})
+	require.NoError(t, err)
+	require.Equal(t, "********3456", cli.Token)
+	require.Equal(t, map[string]interface{}{"mask": 4}, p.Model.Flags[1].Tag.Extensions)
+	require.Nil(t, p.Model.Flags[2].Tag.Extensions)
+
+	var invalid struct {
+		Token string `mask:"all"`
+	}
+	_, err = kong.New(&invalid, kong.TagExtensions(maskExtension{}))
+	require.EqualError(t, err, `<anonymous struct>.Token: invalid mask tag: strconv.Atoi: parsing "all": invalid syntax`)
+
+	_, err = kong.New(&invalid, kong.TagExtensions(maskExtension{}, maskExtension{}))
+	require.EqualError(t, err, `duplicate tag extension "mask"`)
+}
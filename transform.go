@@ -0,0 +1,56 @@
+package kong
+
+import (
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// A TransformFunc rewrites a raw string value before it is mapped to a Go value.
+//
+// Transforms are applied to flags and arguments with the "transform" tag, eg.
+// `transform:"trimspace,lower"`, in the order given.
+type TransformFunc func(value string) string
+
+// RegisterTransform registers a transform to be used if a value has a "transform" tag containing name.
+//
+// eg.
+//
+// 		Colour string `transform:"canonical-colour"`
+//   	registry.RegisterTransform("canonical-colour", ...)
+func (r *Registry) RegisterTransform(name string, transform TransformFunc) *Registry {
+	r.transforms[name] = transform
+	return r
+}
+
+// RegisterDefaultTransforms registers the builtin transforms "trimspace", "lower", "upper" and "expandenv".
+func (r *Registry) RegisterDefaultTransforms() *Registry {
+	return r.RegisterTransform("trimspace", strings.TrimSpace).
+		RegisterTransform("lower", strings.ToLower).
+		RegisterTransform("upper", strings.ToUpper).
+		RegisterTransform("expandenv", os.ExpandEnv)
+}
+
+// ForTransforms composes the named transforms into a single TransformFunc.
+//
+// Will return nil if names is empty.
+func (r *Registry) ForTransforms(names []string) (TransformFunc, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	transforms := make([]TransformFunc, 0, len(names))
+	for _, name := range names {
+		transform, ok := r.transforms[name]
+		if !ok {
+			return nil, errors.Errorf("unknown transform %q", name)
+		}
+		transforms = append(transforms, transform)
+	}
+	return func(value string) string {
+		for _, transform := range transforms {
+			value = transform(value)
+		}
+		return value
+	}, nil
+}
@@ -0,0 +1,97 @@
+package kong
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Names of well known units, used in help and placeholders. Other units are used as is.
+var unitNames = map[string]string{
+	"ns":  "nanoseconds",
+	"us":  "microseconds",
+	"µs":  "microseconds",
+	"ms":  "milliseconds",
+	"s":   "seconds",
+	"m":   "minutes",
+	"h":   "hours",
+	"B":   "bytes",
+	"KB":  "kilobytes",
+	"MB":  "megabytes",
+	"GB":  "gigabytes",
+	"TB":  "terabytes",
+	"KiB": "kibibytes",
+	"MiB": "mebibytes",
+	"GiB": "gibibytes",
+	"TiB": "tebibytes",
+	"%":   "percent",
+}
+
+// Units understood by time.ParseDuration().
+var durationUnits = map[string]bool{"ns": true, "us": true, "µs": true, "ms": true, "s": true, "m": true, "h": true}
+
+// UnitName returns the name of unit, eg. "milliseconds" for "ms", or the unit itself if it's not well
+// known.
+func UnitName(unit string) string {
+	if name, ok := unitNames[unit]; ok {
+		return name
+	}
+	return unit
+}
+
+// Returns a TransformFunc normalising values of typ with unit, or nil if values don't need normalising.
+//
+// Bare numbers given for durations are in unit, eg. "500" is "500ms", while the unit is stripped from
+// numbers given with it for numeric values, eg. "512MiB" is 512.
+func unitTransform(unit string, typ reflect.Type) (TransformFunc, error) {
+	if unit == "" {
+		return nil, nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		if !durationUnits[unit] {
+			return nil, errors.Errorf("unit %q is not a valid duration unit", unit)
+		}
+		return func(value string) string {
+			if isNumber(value) {
+				return value + unit
+			}
+			return value
+		}, nil
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return func(value string) string {
+			if number := strings.TrimSpace(strings.TrimSuffix(value, unit)); number != value && isNumber(number) {
+				return number
+			}
+			return value
+		}, nil
+	}
+	return nil, nil
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// Compose transforms, either of which may be nil.
+func chainTransforms(first, second TransformFunc) TransformFunc {
+	switch {
+	case first == nil:
+		return second
+	case second == nil:
+		return first
+	}
+	return func(value string) string {
+		return second(first(value))
+	}
+}
@@ -0,0 +1,65 @@
+package kong_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/alecthomas/kong"
+)
+
+func TestUnits(t *testing.T) {
+	var cli struct {
+		Timeout time.Duration `unit:"ms" default:"500" help:"Request timeout."`
+		Memory  int           `unit:"MiB" env:"MEMORY" help:"Memory limit."`
+		Ratio   float64       `unit:"%"`
+	}
+	p := mustNew(t, &cli)
+	_, err := p.Parse([]string{"--memory=512MiB", "--ratio", "12.5"})
+	require.NoError(t, err)
+	require.Equal(t, 500*time.Millisecond, cli.Timeout)
+	require.Equal(t, 512, cli.Memory)
+	require.Equal(t, 12.5, cli.Ratio)
+
+	_, err = p.Parse([]string{"--timeout=250", "--memory=64"})
+	require.NoError(t, err)
+	require.Equal(t, 250*time.Millisecond, cli.Timeout)
+	require.Equal(t, 64, cli.Memory)
+
+	_, err = p.Parse([]string{"--timeout=2s"})
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Second, cli.Timeout)
+
+	_, err = p.Parse([]string{"--memory=1GiB"})
+	require.EqualError(t, err, `--memory: expected a valid 64 bit int but got "1GiB"`)
+}
+
+func TestUnitsHelp(t *testing.T) {
+	var cli struct {
+		Timeout time.Duration `unit:"ms" default:"500" help:"Request timeout."`
+		Memory  int           `unit:"MiB" env:"MEMORY" help:"Memory limit."`
+		Ratio   float64       `unit:"%"`
+	}
+	w := &bytes.Buffer{}
+	p := mustNew(t, &cli, kong.Writers(w, w), kong.Exit(func(int) {}))
+	_, err := p.Parse([]string{"--help"})
+	require.NoError(t, err)
+	require.Equal(t, `Usage: test
+
+Flags:
+  -h, --help                Show context-sensitive help.
+      --timeout=500ms       Request timeout (in milliseconds).
+      --memory=MEBIBYTES    Memory limit (in mebibytes) ($MEMORY).
+      --ratio=PERCENT       (in percent)
+`, w.String())
+}
+
+func TestUnitsInvalidDuration(t *testing.T) {
+	var cli struct {
+		Timeout time.Duration `unit:"MiB"`
+	}
+	_, err := kong.New(&cli)
+	require.EqualError(t, err, `<anonymous struct>.Timeout: unit "MiB" is not a valid duration unit`)
+}
@@ -27,9 +27,37 @@ func (c ConfigFlag) BeforeResolve(kong *Kong, ctx *Context, trace *Path) error {
 // VersionFlag is a flag type that can be used to display a version number, stored in the "version" variable.
 type VersionFlag bool
 
-// BeforeApply writes the version variable and terminates with a 0 exit status.
+// BeforeApply writes the version variable and terminates with a 0 exit status, or returns
+// ErrVersionRequested if the NoExit() option is set.
 func (v VersionFlag) BeforeApply(app *Kong, vars Vars) error {
 	fmt.Fprintln(app.Stdout, vars["version"])
-	app.Exit(0)
+	return app.exitOr(ErrVersionRequested)
+}
+
+// SetFlag is a flag type that collects repeated "key=value" overrides, eg. --set server.port=8080.
+//
+// Use this as a flag value to support ad-hoc overrides of any flag, with the same semantics as
+// Kong.ParseWithOverrides():
+//
+//     Set kong.SetFlag `help:"Override a flag." placeholder:"KEY=VALUE"`
+type SetFlag []string
+
+// Decode a single "key=value" pair, without splitting on separators.
+func (s *SetFlag) Decode(ctx *DecodeContext) error {
+	var value string
+	if err := ctx.Scan.PopValueInto("key=value", &value); err != nil {
+		return err
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+// BeforeResolve adds a resolver applying the overrides.
+func (s SetFlag) BeforeResolve(ctx *Context, trace *Path) error {
+	resolver, err := newOverrideResolver(ctx.FlagValue(trace.Flag).(SetFlag))
+	if err != nil {
+		return err
+	}
+	ctx.AddResolver(resolver)
 	return nil
 }
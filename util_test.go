@@ -42,3 +42,26 @@ func TestVersionFlag(t *testing.T) {
 	require.Equal(t, "0.1.1", strings.TrimSpace(w.String()))
 	require.Equal(t, 0, called)
 }
+
+func TestSetFlag(t *testing.T) {
+	type cli struct {
+		Set    SetFlag
+		Labels []string
+		Server struct {
+			Port int `default:"80"`
+		} `cmd:""`
+	}
+
+	var c cli
+	p := Must(&c)
+	_, err := p.Parse([]string{"--set", "labels=a,b", "--set=server.port=8080", "server"})
+	require.NoError(t, err)
+	require.Equal(t, SetFlag{"labels=a,b", "server.port=8080"}, c.Set)
+	require.Equal(t, []string{"a", "b"}, c.Labels)
+	require.Equal(t, 8080, c.Server.Port)
+
+	c = cli{}
+	p = Must(&c)
+	_, err = p.Parse([]string{"--set", "server.missing=1", "server"})
+	require.EqualError(t, err, `unknown override "server.missing"`)
+}
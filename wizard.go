@@ -0,0 +1,201 @@
+package kong
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// WizardAnswer is a single value collected by Wizard.
+type WizardAnswer struct {
+	Value  *Value
+	Answer string
+}
+
+// WizardAnswers are the values collected by Wizard for a command.
+type WizardAnswers struct {
+	// Command the answers apply to, or the Application root node.
+	Command *Node
+	Answers []WizardAnswer
+}
+
+// Args returns a command-line that, when parsed, selects the command and applies all answers.
+func (w *WizardAnswers) Args() []string {
+	args := []string{}
+	for node := w.Command; node != nil && node.Type == CommandNode; node = node.Parent {
+		args = append([]string{node.Name}, args...)
+	}
+	positionals := []string{}
+	for _, answer := range w.Answers {
+		if answer.Value.Flag != nil {
+			args = append(args, fmt.Sprintf("--%s=%s", answer.Value.Name, answer.Answer))
+		} else if answer.Value.IsCumulative() {
+			positionals = append(positionals, strings.Fields(answer.Answer)...)
+		} else {
+			positionals = append(positionals, answer.Answer)
+		}
+	}
+	if len(positionals) > 0 {
+		args = append(args, "--")
+		args = append(args, positionals...)
+	}
+	return args
+}
+
+// WriteJSON writes the flag answers as a JSON configuration file compatible with the JSON resolver.
+//
+// Positional arguments are not included.
+func (w *WizardAnswers) WriteJSON(out io.Writer) error {
+	config := map[string]string{}
+	for _, answer := range w.Answers {
+		if answer.Value.Flag != nil {
+			config[configKey(answer.Value.Flag)] = answer.Answer
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(config)
+}
+
+// Wizard interactively prompts for the value of every visible flag and positional argument of a command.
+//
+// "command" is the space separated path to the command, eg. "server start", or "" for the application
+// itself. Prompts are written to "out" and answers read line by line from "in". Empty answers select the
+// default value, if any. Each answer is validated against the value's mapper and enum before being accepted.
+//
+// The returned answers can either be parsed and run:
+//
+//     answers, err := parser.Wizard("server start", os.Stdin, os.Stdout)
+//     ctx, err := parser.Parse(answers.Args())
+//     err = ctx.Run()
+//
+// Or written to a configuration file with WizardAnswers.WriteJSON().
+func (k *Kong) Wizard(command string, in io.Reader, out io.Writer) (*WizardAnswers, error) {
+	node := k.Model.Node
+	for _, name := range strings.Fields(command) {
+		var found *Node
+		for _, child := range node.Children {
+			if child.Type == CommandNode && child.Name == name {
+				found = child
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.Errorf("unknown command %q", command)
+		}
+		node = found
+	}
+	if len(node.Children) > 0 {
+		return nil, errors.Errorf("%s is not a leaf command", node.FullPath())
+	}
+	answers := &WizardAnswers{Command: node}
+	reader := bufio.NewReader(in)
+	for _, group := range node.AllFlags(true) {
+		for _, flag := range group {
+			if isActionFlag(flag) {
+				continue
+			}
+			answer, ok, err := promptValue(reader, out, flag.Value)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				answers.Answers = append(answers.Answers, WizardAnswer{Value: flag.Value, Answer: answer})
+			}
+		}
+	}
+	for _, positional := range node.Positional {
+		answer, ok, err := promptValue(reader, out, positional)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			answers.Answers = append(answers.Answers, WizardAnswer{Value: positional, Answer: answer})
+		}
+	}
+	return answers, nil
+}
+
+// Flags with hooks, such as --help or --version, are actions rather than configuration.
+func isActionFlag(flag *Flag) bool {
+	for _, hook := range []string{"BeforeResolve", "BeforeApply", "AfterApply"} {
+		if getMethod(flag.Target, hook).IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// Prompt for a single value until a valid answer is provided.
+//
+// Returns false if the value should be left unset.
+func promptValue(r *bufio.Reader, w io.Writer, value *Value) (string, bool, error) {
+	prompt := value.Name
+	if value.Flag != nil {
+		prompt = "--" + prompt
+	} else {
+		prompt = "<" + prompt + ">"
+	}
+	if value.Help != "" {
+		prompt += " (" + value.Help + ")"
+	}
+	if value.Enum != "" {
+		enums := []string{}
+		for enum := range value.EnumMap() {
+			enums = append(enums, enum)
+		}
+		sort.Strings(enums)
+		prompt += " {" + strings.Join(enums, ",") + "}"
+	}
+	if value.Default != "" {
+		prompt += " [" + value.Default + "]"
+	}
+	for {
+		fmt.Fprintf(w, "%s: ", prompt)
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", false, errors.Wrap(err, value.ShortSummary())
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if value.Default != "" {
+				return value.Default, true, nil
+			}
+			if !value.Required {
+				return "", false, nil
+			}
+			fmt.Fprintf(w, "%s is required\n", value.ShortSummary())
+			continue
+		}
+		if err := checkWizardAnswer(value, answer); err != nil {
+			fmt.Fprintf(w, "%s\n", err)
+			continue
+		}
+		return answer, true, nil
+	}
+}
+
+func checkWizardAnswer(value *Value, answer string) error {
+	target := reflect.New(value.Target.Type()).Elem()
+	scan := ScanFromTokens(Token{Type: FlagValueToken, Value: answer})
+	if value.Flag == nil && value.IsCumulative() {
+		tokens := []Token{}
+		for _, field := range strings.Fields(answer) {
+			tokens = append(tokens, Token{Type: PositionalArgumentToken, Value: field})
+		}
+		scan = ScanFromTokens(tokens...)
+	}
+	if err := value.Mapper.Decode(&DecodeContext{Value: value, Scan: scan}, target); err != nil {
+		return errors.Wrap(err, value.ShortSummary())
+	}
+	if value.Enum != "" {
+		return checkEnum(value, target)
+	}
+	return nil
+}
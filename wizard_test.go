@@ -0,0 +1,49 @@
+package kong_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWizard(t *testing.T) {
+	var cli struct {
+		Debug bool `help:"Enable debug mode."`
+
+		Server struct {
+			Start struct {
+				Port  int    `help:"Port to listen on." default:"8080"`
+				Level string `help:"Log level." enum:"debug,info" default:"info"`
+				Name  string `help:"Server name." required:""`
+
+				Root string `arg:"" help:"Root directory."`
+			} `cmd:""`
+		} `cmd:""`
+	}
+	p := mustNew(t, &cli)
+	in := strings.NewReader("yes\nnotanumber\n\nverbose\ndebug\n\nalpha\n/srv\n")
+	out := &bytes.Buffer{}
+	answers, err := p.Wizard("server start", in, out)
+	require.NoError(t, err)
+	require.Equal(t, []string{"server", "start", "--debug=yes", "--port=8080", "--level=debug", "--name=alpha", "--", "/srv"}, answers.Args())
+	require.Contains(t, out.String(), `--level (Log level.) {debug,info} [info]: `)
+	require.Contains(t, out.String(), `--name is required`)
+	require.Contains(t, out.String(), `--level must be one of "debug","info" but got "verbose"`)
+
+	config := &bytes.Buffer{}
+	require.NoError(t, answers.WriteJSON(config))
+	require.JSONEq(t, `{"debug": "yes", "port": "8080", "level": "debug", "name": "alpha"}`, config.String())
+
+	_, err = p.Parse(answers.Args())
+	require.NoError(t, err)
+	require.True(t, cli.Debug)
+	require.Equal(t, "alpha", cli.Server.Start.Name)
+	require.Equal(t, "/srv", cli.Server.Start.Root)
+
+	t.Run("UnknownCommand", func(t *testing.T) {
+		_, err := p.Wizard("server stop", strings.NewReader(""), out)
+		require.EqualError(t, err, `unknown command "server stop"`)
+	})
+}